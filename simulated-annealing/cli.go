@@ -0,0 +1,250 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/experiment"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+)
+
+// sensitivityFlag parses a gateway sensitivity table written as "sf:dBm,sf:dBm,...".
+type sensitivityFlag map[int16]float32
+
+func (s sensitivityFlag) String() string {
+	sfs := make([]int, 0, len(s))
+	for sf := range s {
+		sfs = append(sfs, int(sf))
+	}
+	slices.Sort(sfs)
+
+	entries := make([]string, 0, len(sfs))
+	for _, sf := range sfs {
+		entries = append(entries, fmt.Sprintf("%d:%g", sf, s[int16(sf)]))
+	}
+	return strings.Join(entries, ",")
+}
+
+func (s sensitivityFlag) Set(value string) error {
+	clear(s)
+	for _, entry := range strings.Split(value, ",") {
+		sf, dbm, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found {
+			return fmt.Errorf("invalid sensitivity entry %q, expected sf:dBm", entry)
+		}
+
+		sfValue, err := strconv.ParseInt(sf, 10, 16)
+		if err != nil {
+			return fmt.Errorf("invalid spreading factor %q: %v", sf, err)
+		}
+
+		dbmValue, err := strconv.ParseFloat(dbm, 32)
+		if err != nil {
+			return fmt.Errorf("invalid sensitivity %q: %v", dbm, err)
+		}
+
+		s[int16(sfValue)] = float32(dbmValue)
+	}
+	return nil
+}
+
+type float32Flag struct {
+	value *float32
+}
+
+func (f float32Flag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(float64(*f.value), 'g', -1, 32)
+}
+
+func (f float32Flag) Set(value string) error {
+	v, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return err
+	}
+	*f.value = float32(v)
+	return nil
+}
+
+// float32ListFlag parses a comma separated list of values, such as "30,45,60".
+type float32ListFlag struct {
+	values *[]float32
+}
+
+func (f float32ListFlag) String() string {
+	if f.values == nil {
+		return ""
+	}
+	entries := make([]string, 0, len(*f.values))
+	for _, value := range *f.values {
+		entries = append(entries, strconv.FormatFloat(float64(value), 'g', -1, 32))
+	}
+	return strings.Join(entries, ",")
+}
+
+func (f float32ListFlag) Set(value string) error {
+	values := make([]float32, 0)
+	for _, entry := range strings.Split(value, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(entry), 32)
+		if err != nil {
+			return err
+		}
+		values = append(values, float32(v))
+	}
+	*f.values = values
+	return nil
+}
+
+// float64ListFlag parses a comma separated list of values, such as "40,60".
+type float64ListFlag struct {
+	values *[]float64
+}
+
+func (f float64ListFlag) String() string {
+	if f.values == nil {
+		return ""
+	}
+	entries := make([]string, 0, len(*f.values))
+	for _, value := range *f.values {
+		entries = append(entries, strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	return strings.Join(entries, ",")
+}
+
+func (f float64ListFlag) Set(value string) error {
+	values := make([]float64, 0)
+	for _, entry := range strings.Split(value, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(entry), 64)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+	}
+	*f.values = values
+	return nil
+}
+
+// stringListFlag parses a comma separated list of names, such as "uavs,sf-load".
+type stringListFlag struct {
+	values *[]string
+}
+
+func (f stringListFlag) String() string {
+	if f.values == nil {
+		return ""
+	}
+	return strings.Join(*f.values, ",")
+}
+
+func (f stringListFlag) Set(value string) error {
+	values := make([]string, 0)
+	for _, entry := range strings.Split(value, ",") {
+		values = append(values, strings.TrimSpace(entry))
+	}
+	*f.values = values
+	return nil
+}
+
+func bindInstanceFlags(fs *flag.FlagSet, cfg *experiment.InstanceConfig) {
+	fs.StringVar(&cfg.DeviceFile, "devices", cfg.DeviceFile, "end device positions file (x y z per line)")
+	fs.StringVar(&cfg.SliceFile, "slices", cfg.SliceFile, "device to slice association file (device slice per line)")
+	fs.StringVar(&cfg.PositionFile, "positions", cfg.PositionFile, "UAV candidate positions file (x y z per line)")
+	fs.Var(float32ListFlag{&cfg.Altitudes}, "altitudes", "altitudes offered over every ground point of the positions file, as z,z,...")
+
+	fs.Var(float32Flag{&cfg.Gateway.Bandwidth}, "bandwidth", "slice bandwidth in Hz")
+	fs.Var(float32Flag{&cfg.Gateway.MaxDatarate}, "max-datarate", "maximum datarate per slice on each UAV")
+	fs.Var(sensitivityFlag(cfg.Gateway.Sensitivity), "sensitivity", "gateway sensitivity table as sf:dBm,...")
+
+	fs.StringVar(&cfg.PathLoss.Model, "path-loss", cfg.PathLoss.Model, "path loss model: "+strings.Join(experiment.PathLossModels, ", "))
+	fs.Float64Var(&cfg.PathLoss.Frequency, "frequency", cfg.PathLoss.Frequency, "carrier frequency in MHz, used by every path loss model but log-distance")
+	fs.StringVar(&cfg.PathLoss.Environment, "environment", cfg.PathLoss.Environment, "propagation environment of the okumura-hata and air-to-ground models")
+	fs.Float64Var(&cfg.PathLoss.Exponent, "pl-exponent", cfg.PathLoss.Exponent, "attenuation exponent of the log-distance model")
+	fs.Float64Var(&cfg.PathLoss.Reliability, "reliability", cfg.PathLoss.Reliability, "probability with which air-to-ground links must close, 0 uses the mean loss")
+
+	fs.BoolVar(&cfg.Continuous.Enabled, "continuous", cfg.Continuous.Enabled, "place UAVs anywhere in the area instead of on the candidate positions (sa only)")
+	fs.Float64Var(&cfg.Continuous.Step, "step", cfg.Continuous.Step, "standard deviation in m of UAV moves in continuous mode")
+
+	fs.Float64Var(&cfg.Weights.Alpha, "alpha", cfg.Weights.Alpha, "weight of the deployed UAV count in the cost")
+	fs.Float64Var(&cfg.Weights.Beta, "beta", cfg.Weights.Beta, "weight of the maximum SF count in the cost")
+	fs.Float64Var(&cfg.Weights.ChangeUav, "change-uav", cfg.Weights.ChangeUav, "probability of a neighbour move changing the UAV instead of the configuration")
+	fs.Float64Var(&cfg.Weights.NewUavChance, "new-uav-chance", cfg.Weights.NewUavChance, "probability of a UAV move picking a non-deployed UAV")
+	fs.Float64Var(&cfg.Weights.AltitudeChance, "altitude-chance", cfg.Weights.AltitudeChance, "probability of a neighbour move changing the altitude of a deployed UAV")
+}
+
+func bindSolverFlags(fs *flag.FlagSet, cfg *experiment.SolverConfig) {
+	b := &cfg.Budget
+	fs.DurationVar((*time.Duration)(&b.WallTime), "time-limit", time.Duration(b.WallTime), "wall time budget, 0 disables it")
+	fs.IntVar(&b.MaxIterations, "budget-iterations", b.MaxIterations, "iteration budget, 0 disables it")
+	fs.IntVar(&b.MaxEvaluations, "budget-evaluations", b.MaxEvaluations, "solution evaluation budget, 0 disables it")
+	fs.Float64Var(&b.TargetCost, "target-cost", b.TargetCost, "stop once the best cost is at or below this value, 0 disables it")
+	fs.IntVar(&b.Stagnation, "stagnation", b.Stagnation, "stop after this many iterations without improvement, 0 disables it")
+
+	switch cfg.Name {
+	case experiment.SolverSA:
+		p := &cfg.SA
+		fs.Float64Var(&p.InitialTemp, "initial-temp", p.InitialTemp, "initial temperature")
+		fs.Float64Var(&p.CoolingRate, "cooling-rate", p.CoolingRate, "geometric cooling rate")
+		fs.IntVar(&p.IterationsPerTemp, "iterations-per-temp", p.IterationsPerTemp, "iterations at each temperature")
+		fs.IntVar(&p.MaxIterations, "max-iterations", p.MaxIterations, "maximum number of iterations")
+		fs.IntVar(&p.MinDistance, "min-distance", p.MinDistance, "minimum number of moves in a neighbour")
+		fs.IntVar(&p.MaxDistance, "max-distance", p.MaxDistance, "maximum number of moves in a neighbour")
+	case experiment.SolverTS:
+		p := &cfg.TS
+		fs.IntVar(&p.MaxIterations, "max-iterations", p.MaxIterations, "maximum number of iterations")
+		fs.IntVar(&p.TabuListSize, "tabu-list-size", p.TabuListSize, "size of the tabu move list")
+		fs.IntVar(&p.BatchSize, "batch-size", p.BatchSize, "neighbours evaluated per iteration")
+		fs.IntVar(&p.MaxIterationsWithoutEnhancement, "max-iterations-we", p.MaxIterationsWithoutEnhancement, "iterations without enhancement before diversification")
+		fs.Var(float32Flag{&p.TabuUavRatio}, "tabu-uav-ratio", "ratio of tabu UAVs tolerated in diversified solutions")
+	case experiment.SolverGA:
+		p := &cfg.GA
+		fs.IntVar(&p.Generations, "generations", p.Generations, "maximum number of generations")
+		fs.IntVar(&p.Population, "population", p.Population, "population size")
+		fs.IntVar(&p.MaxTabuIterations, "tabu-iterations", p.MaxTabuIterations, "tabu search iterations applied to the best individuals")
+		fs.Float64Var(&p.CrossRate, "cross-rate", p.CrossRate, "crossover probability")
+		fs.Float64Var(&p.MutationRate, "mutation-rate", p.MutationRate, "per gene mutation probability")
+	case experiment.SolverPSO:
+		p := &cfg.PSO
+		fs.IntVar(&p.SwarmSize, "swarm-size", p.SwarmSize, "number of particles")
+		fs.IntVar(&p.MaxIterations, "max-iterations", p.MaxIterations, "maximum number of iterations")
+		fs.Float64Var(&p.Inertia, "inertia", p.Inertia, "weight of the previous velocity")
+		fs.Float64Var(&p.Cognitive, "cognitive", p.Cognitive, "pull towards the best position of each particle")
+		fs.Float64Var(&p.Social, "social", p.Social, "pull towards the best position of the swarm")
+		fs.Float64Var(&p.MaxVelocity, "max-velocity", p.MaxVelocity, "bound on the absolute velocity of every bit")
+	case experiment.SolverVNS:
+		p := &cfg.VNS
+		fs.IntVar(&p.MaxIterations, "max-iterations", p.MaxIterations, "maximum number of shaking iterations")
+		fs.IntVar(&p.MaxShake, "max-shake", p.MaxShake, "largest number of random moves in a shake")
+		fs.IntVar(&p.Samples, "samples", p.Samples, "neighbours sampled from each neighbourhood per descent step")
+	case experiment.SolverACO:
+		p := &cfg.ACO
+		fs.IntVar(&p.Ants, "ants", p.Ants, "solutions built per iteration")
+		fs.IntVar(&p.MaxIterations, "max-iterations", p.MaxIterations, "maximum number of iterations")
+		fs.Float64Var(&p.PheromoneWeight, "pheromone-weight", p.PheromoneWeight, "exponent of the pheromone trails in the choices of the ants")
+		fs.Float64Var(&p.HeuristicWeight, "heuristic-weight", p.HeuristicWeight, "exponent of the coverage desirability in the choices of the ants")
+		fs.Float64Var(&p.Evaporation, "evaporation", p.Evaporation, "fraction of the trails evaporated per iteration")
+		fs.BoolVar(&p.MMAS, "mmas", p.MMAS, "bound the trails and deposit from the iteration best only (MAX-MIN Ant System)")
+		fs.IntVar(&p.LocalSearch, "local-search", p.LocalSearch, "tabu search iterations applied to the iteration best, 0 disables it")
+	case experiment.SolverNSGA2:
+		p := &cfg.NSGA2
+		fs.IntVar(&p.Population, "population", p.Population, "population size")
+		fs.IntVar(&p.Generations, "generations", p.Generations, "maximum number of generations")
+		fs.Float64Var(&p.CrossRate, "cross-rate", p.CrossRate, "crossover probability")
+		fs.Float64Var(&p.MutationRate, "mutation-rate", p.MutationRate, "per gene mutation probability")
+		fs.Var(stringListFlag{&p.Objectives}, "objectives", "objectives traded off, as name,name,... among "+strings.Join(problem.ObjectiveNames, ", "))
+	}
+}
+
+func checkRequired(fs *flag.FlagSet, names ...string) error {
+	for _, name := range names {
+		if fs.Lookup(name).Value.String() == "" {
+			return fmt.Errorf("missing required flag -%s", name)
+		}
+	}
+	return nil
+}
@@ -14,7 +14,7 @@ type Device struct {
 
 func NewDevice(x, y, z float32) *Device {
 	return &Device{
-		pos: utils.Position{x, y, z},
+		pos: utils.Position{X: x, Y: y, Z: z},
 	}
 }
 
@@ -33,7 +33,7 @@ func (d *Device) Slice() int32 {
 func (d *Device) Copy() *Device {
 	return &Device{
 		id:    d.id,
-		pos:   utils.Position{d.pos.X, d.pos.Y, d.pos.Z},
+		pos:   d.pos,
 		slice: d.slice,
 	}
 }
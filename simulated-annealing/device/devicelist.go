@@ -2,9 +2,11 @@ package device
 
 import (
 	"encoding/csv"
+	"fmt"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/utils"
 	"math/rand"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -34,7 +36,7 @@ func (dl *DeviceList) Copy() *DeviceList {
 	return &deviceList
 }
 
-func ReadDeviceList(devicePath, slicePath string) *DeviceList {
+func ReadDeviceList(devicePath, slicePath string) (*DeviceList, error) {
 	deviceList := DeviceList{
 		count:   0,
 		devices: make(map[DeviceId]*Device, 0),
@@ -44,12 +46,12 @@ func ReadDeviceList(devicePath, slicePath string) *DeviceList {
 
 	file, err := os.Open(devicePath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	reader := csv.NewReader(file)
 
-	for {
+	for line := 1; ; line++ {
 		devicePos, err := reader.Read()
 
 		if devicePos == nil {
@@ -57,25 +59,27 @@ func ReadDeviceList(devicePath, slicePath string) *DeviceList {
 		}
 
 		if err != nil {
-			panic(err)
+			file.Close()
+			return nil, fmt.Errorf("%s:%d: %v", devicePath, line, err)
 		}
 
-		devicePos = strings.Split(devicePos[0], " ")
-		devicePosX, err := strconv.ParseFloat(devicePos[0], 32)
-		if err != nil {
-			panic(err)
+		devicePos = strings.Fields(devicePos[0])
+		if len(devicePos) < 3 {
+			file.Close()
+			return nil, fmt.Errorf("%s:%d: expected x y z, got %q", devicePath, line, strings.Join(devicePos, " "))
 		}
 
-		devicePosY, err := strconv.ParseFloat(devicePos[1], 32)
-		if err != nil {
-			panic(err)
+		coords := make([]float32, 3)
+		for i := range coords {
+			value, err := strconv.ParseFloat(devicePos[i], 32)
+			if err != nil {
+				file.Close()
+				return nil, fmt.Errorf("%s:%d: %v", devicePath, line, err)
+			}
+			coords[i] = float32(value)
 		}
 
-		devicePosZ, err := strconv.ParseFloat(devicePos[2], 32)
-		if err != nil {
-			panic(err)
-		}
-		dev := NewDevice(float32(devicePosX), float32(devicePosY), float32(devicePosZ))
+		dev := NewDevice(coords[0], coords[1], coords[2])
 		deviceList.addDevice(dev)
 	}
 
@@ -85,13 +89,13 @@ func ReadDeviceList(devicePath, slicePath string) *DeviceList {
 
 	file, err = os.Open(slicePath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	defer file.Close()
 
 	reader = csv.NewReader(file)
 
-	for {
+	for line := 1; ; line++ {
 		association, err := reader.Read()
 
 		if association == nil {
@@ -99,28 +103,56 @@ func ReadDeviceList(devicePath, slicePath string) *DeviceList {
 		}
 
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("%s:%d: %v", slicePath, line, err)
+		}
+
+		association = strings.Fields(association[0])
+		if len(association) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected device slice, got %q", slicePath, line, strings.Join(association, " "))
 		}
 
-		association = strings.Split(association[0], " ")
 		deviceId, err := strconv.ParseInt(association[0], 10, 32)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("%s:%d: %v", slicePath, line, err)
 		}
 
 		slice, err := strconv.ParseInt(association[1], 10, 32)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("%s:%d: %v", slicePath, line, err)
+		}
+
+		dev, found := deviceList.devices[DeviceId(deviceId)]
+		if !found {
+			return nil, fmt.Errorf("%s:%d: unknown device %d", slicePath, line, deviceId)
 		}
 
 		if !utils.Contains(deviceList.slices, int32(slice)) {
 			deviceList.slices = append(deviceList.slices, int32(slice))
 		}
 
-		deviceList.devices[DeviceId(deviceId)].slice = int32(slice)
+		dev.slice = int32(slice)
 	}
 
-	return &deviceList
+	if deviceList.count == 0 {
+		return nil, fmt.Errorf("%s: no devices found", devicePath)
+	}
+
+	deviceList.renumberSlices()
+	return &deviceList, nil
+}
+
+// renumberSlices maps the slice IDs read to 0..n-1 in increasing order, so that solutions can index
+// their loads by slice. Files numbering them so already keep their IDs.
+func (dl *DeviceList) renumberSlices() {
+	slices.Sort(dl.slices)
+	index := make(map[int32]int32, len(dl.slices))
+	for i, slice := range dl.slices {
+		index[slice] = int32(i)
+		dl.slices[i] = int32(i)
+	}
+	for _, dev := range dl.devices {
+		dev.slice = index[dev.slice]
+	}
 }
 
 func (dl *DeviceList) addDevice(device *Device) {
@@ -138,8 +170,8 @@ func (dl *DeviceList) Count() int32 {
 	return dl.count
 }
 
-func (dl *DeviceList) GetRandomDevice() *Device {
-	deviceIdx := rand.Int31n(int32(len(dl.deviceIds)))
+func (dl *DeviceList) GetRandomDevice(rng *rand.Rand) *Device {
+	deviceIdx := rng.Int31n(int32(len(dl.deviceIds)))
 	return dl.devices[dl.deviceIds[deviceIdx]]
 }
 
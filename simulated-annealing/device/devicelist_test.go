@@ -0,0 +1,51 @@
+package device
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadDeviceListRenumbersSlices(t *testing.T) {
+	tests := []struct {
+		name   string
+		slices string
+		want   []int32
+	}{
+		{"dense", "0 0\n1 1\n2 2\n3 1\n", []int32{0, 1, 2, 1}},
+		{"from one", "0 1\n1 2\n2 3\n3 2\n", []int32{0, 1, 2, 1}},
+		{"gaps", "0 9\n1 5\n2 9\n3 7\n", []int32{2, 0, 2, 1}},
+	}
+
+	dir := t.TempDir()
+	devicePath := filepath.Join(dir, "devices.dat")
+	if err := os.WriteFile(devicePath, []byte("0 0 1\n10 0 1\n0 10 1\n10 10 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slicePath := filepath.Join(dir, test.name+".dat")
+			if err := os.WriteFile(slicePath, []byte(test.slices), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			deviceList, err := ReadDeviceList(devicePath, slicePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]int32, 0, deviceList.Count())
+			for _, deviceId := range deviceList.GetDeviceIds() {
+				got = append(got, deviceList.GetDevice(deviceId).Slice())
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("device slices = %v, want %v", got, test.want)
+			}
+			if want := []int32{0, 1, 2}; !slices.Equal(deviceList.Slices(), want) {
+				t.Errorf("Slices() = %v, want %v", deviceList.Slices(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package experiment
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/stats"
+)
+
+// BatchRow is one row of a batch results CSV as written by RunBatch.
+type BatchRow struct {
+	Instance string
+	Label    string
+	values   map[string]string
+}
+
+// ReadBatchResults reads and concatenates batch results CSVs, which must all have the columns that
+// identify the instance and the solver.
+func ReadBatchResults(paths ...string) ([]BatchRow, error) {
+	rows := make([]BatchRow, 0)
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		records, err := csv.NewReader(file).ReadAll()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("%s: missing header", path)
+		}
+
+		header := records[0]
+		for _, column := range []string{"seed", "numDevices", "numGateways", "label"} {
+			if !slices.Contains(header, column) {
+				return nil, fmt.Errorf("%s: missing column %q", path, column)
+			}
+		}
+
+		for _, record := range records[1:] {
+			values := make(map[string]string, len(header))
+			for i, column := range header {
+				values[column] = record[i]
+			}
+			rows = append(rows, BatchRow{
+				Instance: fmt.Sprintf("%s/%s/%s", values["seed"], values["numDevices"], values["numGateways"]),
+				Label:    values["label"],
+				values:   values,
+			})
+		}
+	}
+	return rows, nil
+}
+
+func (row BatchRow) Metric(column string) (float64, error) {
+	value, found := row.values[column]
+	if !found {
+		return 0, fmt.Errorf("no column %q", column)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// AnalysisConfig selects the compared metric, where lower is better, the confidence level of the
+// intervals and the significance level of the tests, 0.05 or 0.1 for Nemenyi's critical values.
+type AnalysisConfig struct {
+	Metric     string
+	Confidence float64
+	Alpha      float64
+}
+
+func DefaultAnalysisConfig() AnalysisConfig {
+	return AnalysisConfig{
+		Metric:     "bestCost",
+		Confidence: 0.95,
+		Alpha:      0.05,
+	}
+}
+
+func (cfg AnalysisConfig) Validate() error {
+	v := &validator{}
+	v.check(cfg.Metric != "", "metric is required")
+	v.check(cfg.Confidence > 0 && cfg.Confidence < 1, "confidence must be in (0, 1), got %g", cfg.Confidence)
+	v.check(cfg.Alpha == 0.05 || cfg.Alpha == 0.1, "alpha must be 0.05 or 0.1, got %g", cfg.Alpha)
+	return v.err()
+}
+
+// Analyze compares the solvers of batch results on cfg.Metric. Instances are named seed/devices/
+// gateways. It returns, in order:
+//   - the summary of every solver on every instance, with the confidence interval of the mean
+//   - Mann-Whitney U tests between the replications of every pair of solvers on each instance
+//   - Wilcoxon signed-rank tests between the per instance means of every pair of solvers
+//   - the Friedman test over instances, with average ranks, and Nemenyi's pairwise comparisons
+//
+// The last two need at least two instances on which every solver ran, and are left out otherwise.
+func Analyze(rows []BatchRow, cfg AnalysisConfig) ([]stats.Table, error) {
+	instances := make([]string, 0)
+	labels := make([]string, 0)
+	samples := make(map[string]map[string][]float64)
+	for _, row := range rows {
+		value, err := row.Metric(cfg.Metric)
+		if err != nil {
+			return nil, fmt.Errorf("instance %s, solver %s: %v", row.Instance, row.Label, err)
+		}
+		if !slices.Contains(instances, row.Instance) {
+			instances = append(instances, row.Instance)
+			samples[row.Instance] = make(map[string][]float64)
+		}
+		if !slices.Contains(labels, row.Label) {
+			labels = append(labels, row.Label)
+		}
+		samples[row.Instance][row.Label] = append(samples[row.Instance][row.Label], value)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no results to analyze")
+	}
+
+	tables := []stats.Table{summaryTable(instances, labels, samples, cfg)}
+	if len(labels) < 2 {
+		return tables, nil
+	}
+	tables = append(tables, mannWhitneyTable(instances, labels, samples, cfg))
+
+	complete := make([]string, 0)
+	for _, instance := range instances {
+		if len(samples[instance]) == len(labels) {
+			complete = append(complete, instance)
+		}
+	}
+	if len(complete) < 2 {
+		return tables, nil
+	}
+
+	means := make([][]float64, len(complete))
+	for i, instance := range complete {
+		means[i] = make([]float64, len(labels))
+		for j, label := range labels {
+			means[i][j] = stats.Mean(samples[instance][label])
+		}
+	}
+
+	wilcoxon, err := wilcoxonTable(labels, means, cfg)
+	if err != nil {
+		return nil, err
+	}
+	friedman, nemenyi, err := friedmanTables(labels, means, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return append(tables, wilcoxon, friedman, nemenyi), nil
+}
+
+func summaryTable(instances, labels []string, samples map[string]map[string][]float64, cfg AnalysisConfig) stats.Table {
+	table := stats.Table{
+		Caption: fmt.Sprintf("%s per instance and solver, with the %g%% confidence interval of the mean", cfg.Metric, 100*cfg.Confidence),
+		Header:  []string{"instance", "solver", "n", "mean", "median", "std", "min", "max", "ci low", "ci high"},
+	}
+	for _, instance := range instances {
+		for _, label := range labels {
+			values, found := samples[instance][label]
+			if !found {
+				continue
+			}
+			s := stats.Describe(values, cfg.Confidence)
+			table.Rows = append(table.Rows, []string{
+				instance, label, strconv.Itoa(s.N), formatValue(s.Mean), formatValue(s.Median), formatValue(s.Std),
+				formatValue(s.Min), formatValue(s.Max), formatValue(s.CILow), formatValue(s.CIHigh),
+			})
+		}
+	}
+	return table
+}
+
+func mannWhitneyTable(instances, labels []string, samples map[string]map[string][]float64, cfg AnalysisConfig) stats.Table {
+	table := stats.Table{
+		Caption: fmt.Sprintf("Mann-Whitney U tests on %s between the runs of each pair of solvers, at alpha = %g (* normal approximation)", cfg.Metric, cfg.Alpha),
+		Header:  []string{"instance", "solver a", "solver b", "U", "p-value", "better"},
+	}
+	for _, instance := range instances {
+		for i, a := range labels {
+			for _, b := range labels[i+1:] {
+				x, y := samples[instance][a], samples[instance][b]
+				if len(x) == 0 || len(y) == 0 {
+					continue
+				}
+				result, _ := stats.MannWhitneyU(x, y)
+				table.Rows = append(table.Rows, []string{
+					instance, a, b, formatValue(result.Statistic), formatPValue(result),
+					better(a, b, stats.Median(x), stats.Median(y), result.PValue < cfg.Alpha),
+				})
+			}
+		}
+	}
+	return table
+}
+
+func wilcoxonTable(labels []string, means [][]float64, cfg AnalysisConfig) (stats.Table, error) {
+	table := stats.Table{
+		Caption: fmt.Sprintf("Wilcoxon signed-rank tests on the mean %s per instance of each pair of solvers, over %d instances, at alpha = %g (* normal approximation)", cfg.Metric, len(means), cfg.Alpha),
+		Header:  []string{"solver a", "solver b", "W", "p-value", "better"},
+	}
+	for i, a := range labels {
+		for j := i + 1; j < len(labels); j++ {
+			x, y := column(means, i), column(means, j)
+			result, err := stats.WilcoxonSignedRank(x, y)
+			if err != nil {
+				return stats.Table{}, err
+			}
+			table.Rows = append(table.Rows, []string{
+				a, labels[j], formatValue(result.Statistic), formatPValue(result),
+				better(a, labels[j], stats.Median(x), stats.Median(y), result.PValue < cfg.Alpha),
+			})
+		}
+	}
+	return table, nil
+}
+
+func friedmanTables(labels []string, means [][]float64, cfg AnalysisConfig) (stats.Table, stats.Table, error) {
+	result, err := stats.Friedman(means)
+	if err != nil {
+		return stats.Table{}, stats.Table{}, err
+	}
+
+	friedman := stats.Table{
+		Caption: fmt.Sprintf("Average Friedman ranks on the mean %s per instance over %d instances, chi2 = %s, p-value = %s",
+			cfg.Metric, len(means), formatValue(result.Statistic), formatProbability(result.PValue)),
+		Header: []string{"solver", "average rank"},
+	}
+	for j, label := range labels {
+		friedman.Rows = append(friedman.Rows, []string{label, strconv.FormatFloat(result.AverageRanks[j], 'f', 3, 64)})
+	}
+
+	cd, err := stats.NemenyiCD(len(labels), len(means), cfg.Alpha)
+	if err != nil {
+		return stats.Table{}, stats.Table{}, err
+	}
+	nemenyi := stats.Table{
+		Caption: fmt.Sprintf("Nemenyi post-hoc comparisons, critical difference %.3f at alpha = %g", cd, cfg.Alpha),
+		Header:  []string{"solver a", "solver b", "rank difference", "better"},
+	}
+	for i, a := range labels {
+		for j := i + 1; j < len(labels); j++ {
+			ra, rb := result.AverageRanks[i], result.AverageRanks[j]
+			nemenyi.Rows = append(nemenyi.Rows, []string{
+				a, labels[j], strconv.FormatFloat(ra-rb, 'f', 3, 64),
+				better(a, labels[j], ra, rb, result.PValue < cfg.Alpha && math.Abs(ra-rb) > cd),
+			})
+		}
+	}
+	return friedman, nemenyi, nil
+}
+
+func column(data [][]float64, j int) []float64 {
+	values := make([]float64, len(data))
+	for i, row := range data {
+		values[i] = row[j]
+	}
+	return values
+}
+
+// better names the solver with the lower score when the difference is significant, and is "-"
+// otherwise.
+func better(a, b string, scoreA, scoreB float64, significant bool) string {
+	switch {
+	case !significant || scoreA == scoreB:
+		return "-"
+	case scoreA < scoreB:
+		return a
+	default:
+		return b
+	}
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+func formatPValue(result stats.TestResult) string {
+	if result.Exact {
+		return formatProbability(result.PValue)
+	}
+	return formatProbability(result.PValue) + "*"
+}
+
+func formatProbability(p float64) string {
+	if p < 0.0001 {
+		return "<0.0001"
+	}
+	return strconv.FormatFloat(p, 'f', 4, 64)
+}
@@ -0,0 +1,279 @@
+package experiment
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/solver"
+)
+
+type GridConfig struct {
+	Seeds    []int `json:"seeds" yaml:"seeds"`
+	Devices  []int `json:"devices" yaml:"devices"`
+	Gateways []int `json:"gateways" yaml:"gateways"`
+}
+
+// BatchConfig describes a full factorial experiment. The instance file paths are templates
+// where {seed}, {devices} and {gateways} are replaced by each point of the grid.
+type BatchConfig struct {
+	Instances    InstanceConfig `json:"instances" yaml:"instances"`
+	Grid         GridConfig     `json:"grid" yaml:"grid"`
+	Solvers      []SolverConfig `json:"solvers" yaml:"solvers"`
+	Replications int            `json:"replications" yaml:"replications"`
+	Workers      int            `json:"workers" yaml:"workers"`
+	Output       string         `json:"output" yaml:"output"`
+	RngSeed      int64          `json:"rngSeed" yaml:"rngSeed"`
+}
+
+type Run struct {
+	Seed        int
+	NumDevices  int
+	NumGateways int
+	Replication int
+	RngSeed     int64
+	Instance    InstanceConfig
+	Solver      SolverConfig
+}
+
+type RunResult struct {
+	Run
+	solver.Result
+	Err error
+}
+
+var batchHeader = []string{
+	"seed", "numDevices", "numGateways", "solver", "label", "replication", "rngSeed", "params",
+	"bestCost", "costA", "costB", "numUavs", "timeToBest", "iterations", "evaluations", "elapsed", "stopReason",
+}
+
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		Instances:    DefaultInstanceConfig(),
+		Replications: 1,
+		Workers:      runtime.NumCPU(),
+	}
+}
+
+func LoadBatchConfig(path string) (BatchConfig, error) {
+	cfg := DefaultBatchConfig()
+	cfg.Instances.Gateway.Sensitivity = nil
+
+	if err := decodeFile(path, &cfg); err != nil {
+		return BatchConfig{}, err
+	}
+
+	if cfg.Instances.Gateway.Sensitivity == nil {
+		cfg.Instances.Gateway.Sensitivity = DefaultGatewayConfig().Sensitivity
+	}
+
+	dir := filepath.Dir(path)
+	cfg.Instances = cfg.Instances.resolvePaths(dir)
+	cfg.Output = resolvePath(dir, cfg.Output)
+
+	return cfg, nil
+}
+
+func (cfg BatchConfig) Validate() error {
+	v := &validator{}
+	v.check(len(cfg.Grid.Seeds) > 0, "grid.seeds must not be empty")
+	v.check(len(cfg.Grid.Devices) > 0, "grid.devices must not be empty")
+	v.check(len(cfg.Grid.Gateways) > 0, "grid.gateways must not be empty")
+	v.check(len(cfg.Solvers) > 0, "solvers must not be empty")
+	v.check(cfg.Replications > 0, "replications must be positive, got %d", cfg.Replications)
+	v.check(cfg.Workers > 0, "workers must be positive, got %d", cfg.Workers)
+	v.check(cfg.Output != "", "output is required")
+
+	for _, seed := range cfg.Grid.Seeds {
+		for _, numDevices := range cfg.Grid.Devices {
+			for _, numGateways := range cfg.Grid.Gateways {
+				path := fmt.Sprintf("instances[seed=%d,devices=%d,gateways=%d]", seed, numDevices, numGateways)
+				cfg.Instances.Expand(seed, numDevices, numGateways).validate(v, path)
+			}
+		}
+	}
+
+	labels := make(map[string]bool, len(cfg.Solvers))
+	for i, solverConfig := range cfg.Solvers {
+		solverConfig.validate(v, fmt.Sprintf("solvers[%d]", i))
+		v.check(!cfg.Instances.Continuous.Enabled || solverConfig.Name == SolverSA, "solvers[%d]: instances.continuous only supports the %s solver, got %q", i, SolverSA, solverConfig.Name)
+		v.check(!labels[solverConfig.GetLabel()], "solvers[%d] label %q is used more than once", i, solverConfig.GetLabel())
+		labels[solverConfig.GetLabel()] = true
+	}
+
+	return v.err()
+}
+
+// Runs lists every run of the grid. The random seed of each run is derived from cfg.RngSeed and the
+// run coordinates, so adding a solver or a replication leaves the seeds of the other runs unchanged.
+func (cfg BatchConfig) Runs() []Run {
+	runs := make([]Run, 0)
+	for _, seed := range cfg.Grid.Seeds {
+		for _, numDevices := range cfg.Grid.Devices {
+			for _, numGateways := range cfg.Grid.Gateways {
+				instance := cfg.Instances.Expand(seed, numDevices, numGateways)
+				for _, solverConfig := range cfg.Solvers {
+					for replication := 0; replication < cfg.Replications; replication++ {
+						runs = append(runs, Run{
+							Seed:        seed,
+							NumDevices:  numDevices,
+							NumGateways: numGateways,
+							Replication: replication,
+							RngSeed:     runSeed(cfg.RngSeed, seed, numDevices, numGateways, solverConfig.GetLabel(), replication),
+							Instance:    instance,
+							Solver:      solverConfig,
+						})
+					}
+				}
+			}
+		}
+	}
+	return runs
+}
+
+func runSeed(base int64, seed, numDevices, numGateways int, label string, replication int) int64 {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d/%d/%d/%d/%s/%d", base, seed, numDevices, numGateways, label, replication)
+	return int64(hash.Sum64() &^ (1 << 63))
+}
+
+func (run Run) Execute(ctx context.Context) (result RunResult) {
+	result.Run = run
+	defer func() {
+		if r := recover(); r != nil {
+			result.Err = fmt.Errorf("solver panicked: %v", r)
+		}
+	}()
+
+	instance, err := run.Instance.Load()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	rng, _ := NewRand(run.RngSeed)
+	instance.SetRand(rng)
+
+	var s solver.Solver
+	if run.Instance.Continuous.Enabled {
+		s, err = run.Solver.CreateContinuous(run.Instance.Continuous.Create(instance))
+	} else {
+		s, err = run.Solver.Create(instance)
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Result = s.Solve(ctx)
+	return result
+}
+
+func (result RunResult) record() []string {
+	return []string{
+		strconv.Itoa(result.Seed),
+		strconv.Itoa(result.NumDevices),
+		strconv.Itoa(result.NumGateways),
+		result.Solver.Name,
+		result.Solver.GetLabel(),
+		strconv.Itoa(result.Replication),
+		strconv.FormatInt(result.RngSeed, 10),
+		result.Solver.Params(),
+		strconv.FormatFloat(result.Cost, 'f', -1, 64),
+		strconv.FormatFloat(result.CostA, 'f', -1, 64),
+		strconv.FormatFloat(result.CostB, 'f', -1, 64),
+		strconv.Itoa(result.NumUavs),
+		strconv.FormatFloat(result.TimeToBest.Seconds(), 'f', 6, 64),
+		strconv.Itoa(result.Iterations),
+		strconv.Itoa(result.Evaluations),
+		strconv.FormatFloat(result.Elapsed.Seconds(), 'f', 6, 64),
+		string(result.StopReason),
+	}
+}
+
+// RunBatch executes every run of the grid on a pool of cfg.Workers goroutines and writes one CSV
+// row per finished run. Failed runs are reported to stderr and left out of the CSV. Cancelling ctx
+// stops the running solvers, whose rows are still written, and skips the runs not yet started.
+func RunBatch(ctx context.Context, cfg BatchConfig, w io.Writer) error {
+	if cfg.RngSeed == 0 {
+		_, cfg.RngSeed = NewRand(0)
+	}
+	fmt.Printf("Batch RNG seed: %d\n", cfg.RngSeed)
+	runs := cfg.Runs()
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(batchHeader); err != nil {
+		return err
+	}
+	writer.Flush()
+
+	jobs := make(chan Run)
+	results := make(chan RunResult)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for run := range jobs {
+				results <- run.Execute(ctx)
+			}
+		}()
+	}
+
+	go func() {
+	dispatch:
+		for _, run := range runs {
+			select {
+			case jobs <- run:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	// keep draining results after a write error so that no worker is left blocked
+	var writeErr error
+	failed := 0
+	done := 0
+	for result := range results {
+		done++
+		if writeErr != nil {
+			continue
+		}
+
+		if result.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "run %d/%d failed (seed %d, devices %d, gateways %d, %s #%d): %v\n",
+				done, len(runs), result.Seed, result.NumDevices, result.NumGateways, result.Solver.GetLabel(), result.Replication, result.Err)
+			continue
+		}
+
+		fmt.Printf("run %d/%d done (seed %d, devices %d, gateways %d, %s #%d): cost %f, stopped by %s\n",
+			done, len(runs), result.Seed, result.NumDevices, result.NumGateways, result.Solver.GetLabel(), result.Replication, result.Cost, result.StopReason)
+
+		writer.Write(result.record())
+		writer.Flush()
+		writeErr = writer.Error()
+	}
+
+	if writeErr != nil {
+		return writeErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d runs failed", failed, len(runs))
+	}
+	if done < len(runs) {
+		return fmt.Errorf("batch cancelled after %d of %d runs: %w", done, len(runs), ctx.Err())
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package experiment
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputConfig places the result files. Progress is the interval between console progress updates,
+// where 0 turns them off.
+type OutputConfig struct {
+	Dir      string   `json:"dir" yaml:"dir"`
+	Prefix   string   `json:"prefix" yaml:"prefix"`
+	Progress Duration `json:"progress" yaml:"progress"`
+}
+
+// Config describes a single run. WarmStart optionally names a solution file (.json or .csv) written
+// by a previous run on the same instance, which the solver starts from. Bound is an optional lower
+// bound on the optimal cost, against which the gap of the best solution is reported.
+type Config struct {
+	Instance  InstanceConfig `json:"instance" yaml:"instance"`
+	Solver    SolverConfig   `json:"solver" yaml:"solver"`
+	Output    OutputConfig   `json:"output" yaml:"output"`
+	RngSeed   int64          `json:"rngSeed" yaml:"rngSeed"`
+	WarmStart string         `json:"warmStart" yaml:"warmStart"`
+	Bound     float64        `json:"bound" yaml:"bound"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Instance: DefaultInstanceConfig(),
+		Solver:   DefaultSolverConfig(""),
+		Output:   OutputConfig{Dir: "output", Progress: Duration(time.Second)},
+	}
+}
+
+// LoadConfig reads an experiment from a .json, .yaml or .yml file. Fields left out keep their
+// defaults and relative file paths are resolved against the directory holding the config file.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	// a sensitivity table given in the file replaces the default one instead of being merged into it
+	cfg.Instance.Gateway.Sensitivity = nil
+
+	if err := decodeFile(path, &cfg); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.Instance.Gateway.Sensitivity == nil {
+		cfg.Instance.Gateway.Sensitivity = DefaultGatewayConfig().Sensitivity
+	}
+	if cfg.Output.Prefix == "" {
+		cfg.Output.Prefix = strings.ToUpper(cfg.Solver.Name)
+	}
+
+	dir := filepath.Dir(path)
+	cfg.Instance = cfg.Instance.resolvePaths(dir)
+	cfg.Output.Dir = resolvePath(dir, cfg.Output.Dir)
+	cfg.WarmStart = resolvePath(dir, cfg.WarmStart)
+
+	return cfg, nil
+}
+
+func decodeFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(v)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(v)
+	default:
+		return fmt.Errorf("%s: unsupported config format %q, use .json, .yaml or .yml", path, ext)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	return nil
+}
+
+// Duration is a time.Duration written as a Go duration string such as "90s" or "5m".
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *Duration) parse(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return d.parse(value)
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(any) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return d.parse(value)
+}
+
+// NewRand returns a random source for seed, where a zero seed is replaced by one taken from the
+// clock. The seed actually used is returned so that the run can be reproduced.
+func NewRand(seed int64) (*rand.Rand, int64) {
+	for seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed)), seed
+}
+
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func (cfg Config) Validate() error {
+	v := &validator{}
+	cfg.Instance.validate(v, "instance")
+	cfg.Solver.validate(v, "solver")
+	v.check(!cfg.Instance.Continuous.Enabled || cfg.Solver.Name == SolverSA, "instance.continuous only supports the %s solver, got %q", SolverSA, cfg.Solver.Name)
+	v.check(cfg.Output.Dir != "", "output.dir is required")
+	v.check(cfg.Output.Prefix != "", "output.prefix is required")
+	v.check(cfg.Output.Progress >= 0, "output.progress must not be negative, got %v", cfg.Output.Progress)
+	v.check(cfg.Bound >= 0, "bound must not be negative, got %g", cfg.Bound)
+	if cfg.WarmStart != "" {
+		v.checkFile(cfg.WarmStart, "warmStart")
+	}
+	return v.err()
+}
+
+// validator collects every problem found in a config so they can be reported at once.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(ok bool, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf(format, args...))
+	}
+}
+
+func (v *validator) checkProbability(value float64, path string) {
+	v.check(value >= 0 && value <= 1, "%s must be in [0, 1], got %g", path, value)
+}
+
+func (v *validator) checkFile(path, field string) {
+	if path == "" {
+		v.check(false, "%s is required", field)
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		v.check(false, "%s: %v", field, err)
+		return
+	}
+	v.check(!info.IsDir(), "%s: %s is a directory", field, path)
+}
+
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
+}
@@ -0,0 +1,305 @@
+package experiment
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/gateway"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/utils"
+)
+
+type GatewayConfig struct {
+	Bandwidth   float32           `json:"bandwidth" yaml:"bandwidth"`
+	MaxDatarate float32           `json:"maxDatarate" yaml:"maxDatarate"`
+	Sensitivity map[int16]float32 `json:"sensitivity" yaml:"sensitivity"`
+}
+
+type WeightsConfig struct {
+	Alpha          float64 `json:"alpha" yaml:"alpha"`
+	Beta           float64 `json:"beta" yaml:"beta"`
+	ChangeUav      float64 `json:"changeUav" yaml:"changeUav"`
+	NewUavChance   float64 `json:"newUavChance" yaml:"newUavChance"`
+	AltitudeChance float64 `json:"altitudeChance" yaml:"altitudeChance"`
+}
+
+const (
+	PathLossLogDistance = "log-distance"
+	PathLossFreeSpace   = "free-space"
+	PathLossHata        = "okumura-hata"
+	PathLossAirToGround = "air-to-ground"
+)
+
+var PathLossModels = []string{PathLossLogDistance, PathLossFreeSpace, PathLossHata, PathLossAirToGround}
+
+// PathLossConfig selects the propagation model. Frequency, in MHz, applies to every model but
+// log-distance, which uses the reference loss, distance and exponent instead. Environment is urban
+// or suburban for okumura-hata, and suburban, urban, dense-urban or highrise for air-to-ground.
+// Reliability, for air-to-ground only, is the probability with which every link must close; zero
+// uses the mean loss instead.
+type PathLossConfig struct {
+	Model             string  `json:"model" yaml:"model"`
+	Frequency         float64 `json:"frequency" yaml:"frequency"`
+	Environment       string  `json:"environment" yaml:"environment"`
+	ReferenceLoss     float64 `json:"referenceLoss" yaml:"referenceLoss"`
+	ReferenceDistance float64 `json:"referenceDistance" yaml:"referenceDistance"`
+	Exponent          float64 `json:"exponent" yaml:"exponent"`
+	Reliability       float64 `json:"reliability" yaml:"reliability"`
+}
+
+// AreaConfig bounds the UAV positions in continuous mode, in the units of the position file.
+type AreaConfig struct {
+	MinX float32 `json:"minX" yaml:"minX"`
+	MinY float32 `json:"minY" yaml:"minY"`
+	MinZ float32 `json:"minZ" yaml:"minZ"`
+	MaxX float32 `json:"maxX" yaml:"maxX"`
+	MaxY float32 `json:"maxY" yaml:"maxY"`
+	MaxZ float32 `json:"maxZ" yaml:"maxZ"`
+}
+
+// ContinuousConfig lets UAVs take any position in Area rather than the candidate positions, which
+// then only seed the initial solution. Step is the standard deviation, in m, of position moves. An
+// Area left at zero is the bounding box of the candidate positions.
+type ContinuousConfig struct {
+	Enabled bool       `json:"enabled" yaml:"enabled"`
+	Step    float64    `json:"step" yaml:"step"`
+	Area    AreaConfig `json:"area" yaml:"area"`
+}
+
+// InstanceConfig describes an instance. When Altitudes is set, every ground (x, y) point of the
+// position file is offered at each of those altitudes instead of the ones in the file.
+type InstanceConfig struct {
+	DeviceFile   string           `json:"devices" yaml:"devices"`
+	SliceFile    string           `json:"slices" yaml:"slices"`
+	PositionFile string           `json:"positions" yaml:"positions"`
+	Altitudes    []float32        `json:"altitudes" yaml:"altitudes"`
+	Gateway      GatewayConfig    `json:"gateway" yaml:"gateway"`
+	PathLoss     PathLossConfig   `json:"pathLoss" yaml:"pathLoss"`
+	Weights      WeightsConfig    `json:"weights" yaml:"weights"`
+	Continuous   ContinuousConfig `json:"continuous" yaml:"continuous"`
+}
+
+func DefaultGatewayConfig() GatewayConfig {
+	return GatewayConfig{
+		Bandwidth:   125000.0,
+		MaxDatarate: 15197.75390625,
+		Sensitivity: map[int16]float32{7: -130.0, 8: -132.5, 9: -135.0, 10: -137.5, 11: -140.0, 12: -142.5},
+	}
+}
+
+func DefaultWeightsConfig() WeightsConfig {
+	return WeightsConfig{
+		Alpha:          100.0,
+		Beta:           1.0,
+		ChangeUav:      0.0,
+		NewUavChance:   0.0,
+		AltitudeChance: 0.0,
+	}
+}
+
+func DefaultPathLossConfig() PathLossConfig {
+	defaults := problem.DefaultPathLoss()
+	return PathLossConfig{
+		Model:             PathLossLogDistance,
+		Frequency:         868.0,
+		Environment:       "urban",
+		ReferenceLoss:     defaults.ReferenceLoss,
+		ReferenceDistance: defaults.ReferenceDistance,
+		Exponent:          defaults.Exponent,
+	}
+}
+
+func DefaultContinuousConfig() ContinuousConfig {
+	return ContinuousConfig{
+		Enabled: false,
+		Step:    100.0,
+	}
+}
+
+func DefaultInstanceConfig() InstanceConfig {
+	return InstanceConfig{
+		Gateway:    DefaultGatewayConfig(),
+		PathLoss:   DefaultPathLossConfig(),
+		Weights:    DefaultWeightsConfig(),
+		Continuous: DefaultContinuousConfig(),
+	}
+}
+
+func (cfg InstanceConfig) resolvePaths(dir string) InstanceConfig {
+	cfg.DeviceFile = resolvePath(dir, cfg.DeviceFile)
+	cfg.SliceFile = resolvePath(dir, cfg.SliceFile)
+	cfg.PositionFile = resolvePath(dir, cfg.PositionFile)
+	return cfg
+}
+
+// Expand fills the {seed}, {devices} and {gateways} placeholders of the file paths.
+func (cfg InstanceConfig) Expand(seed, numDevices, numGateways int) InstanceConfig {
+	replacer := strings.NewReplacer(
+		"{seed}", strconv.Itoa(seed),
+		"{devices}", strconv.Itoa(numDevices),
+		"{gateways}", strconv.Itoa(numGateways),
+	)
+
+	cfg.DeviceFile = replacer.Replace(cfg.DeviceFile)
+	cfg.SliceFile = replacer.Replace(cfg.SliceFile)
+	cfg.PositionFile = replacer.Replace(cfg.PositionFile)
+	return cfg
+}
+
+func (cfg GatewayConfig) validate(v *validator, path string) {
+	v.check(cfg.Bandwidth > 0, "%s.bandwidth must be positive, got %g", path, cfg.Bandwidth)
+	v.check(cfg.MaxDatarate > 0, "%s.maxDatarate must be positive, got %g", path, cfg.MaxDatarate)
+	for sf := int16(device.MinSF); sf <= device.MaxSF; sf++ {
+		_, found := cfg.Sensitivity[sf]
+		v.check(found, "%s.sensitivity has no entry for SF%d", path, sf)
+	}
+	for sf := range cfg.Sensitivity {
+		v.check(sf >= device.MinSF && sf <= device.MaxSF, "%s.sensitivity has an entry for SF%d, valid SFs are %d-%d", path, sf, device.MinSF, device.MaxSF)
+	}
+}
+
+func (cfg PathLossConfig) validate(v *validator, path string) {
+	switch cfg.Model {
+	case PathLossLogDistance:
+		v.check(cfg.ReferenceDistance > 0, "%s.referenceDistance must be positive, got %g", path, cfg.ReferenceDistance)
+		v.check(cfg.Exponent > 0, "%s.exponent must be positive, got %g", path, cfg.Exponent)
+	case PathLossFreeSpace:
+		v.check(cfg.Frequency > 0, "%s.frequency must be positive, got %g", path, cfg.Frequency)
+	case PathLossHata:
+		v.check(cfg.Frequency >= 150 && cfg.Frequency <= 1500, "%s.frequency must be in [150, 1500] MHz for okumura-hata, got %g", path, cfg.Frequency)
+		v.check(cfg.Environment == string(problem.HataUrban) || cfg.Environment == string(problem.HataSuburban),
+			"%s.environment must be urban or suburban for okumura-hata, got %q", path, cfg.Environment)
+	case PathLossAirToGround:
+		v.check(cfg.Frequency > 0, "%s.frequency must be positive, got %g", path, cfg.Frequency)
+		_, found := problem.AirToGroundEnvironments[cfg.Environment]
+		v.check(found, "%s.environment %q is not known for air-to-ground, valid environments are %s", path, cfg.Environment, strings.Join(airToGroundEnvironments(), ", "))
+		v.check(cfg.Reliability >= 0 && cfg.Reliability < 1, "%s.reliability must be in [0, 1), got %g", path, cfg.Reliability)
+	default:
+		v.check(false, "%s.model %q is not a known model, valid models are %s", path, cfg.Model, strings.Join(PathLossModels, ", "))
+	}
+	if cfg.Model != PathLossAirToGround {
+		v.check(cfg.Reliability == 0, "%s.reliability only applies to air-to-ground, got %g with %s", path, cfg.Reliability, cfg.Model)
+	}
+}
+
+func airToGroundEnvironments() []string {
+	names := make([]string, 0, len(problem.AirToGroundEnvironments))
+	for name := range problem.AirToGroundEnvironments {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (cfg PathLossConfig) Create() (problem.PathLossModel, error) {
+	switch cfg.Model {
+	case PathLossLogDistance:
+		return problem.LogDistance{ReferenceLoss: cfg.ReferenceLoss, ReferenceDistance: cfg.ReferenceDistance, Exponent: cfg.Exponent}, nil
+	case PathLossFreeSpace:
+		return problem.FreeSpace{Frequency: cfg.Frequency}, nil
+	case PathLossHata:
+		return problem.OkumuraHata{Frequency: cfg.Frequency, Environment: problem.HataEnvironment(cfg.Environment)}, nil
+	case PathLossAirToGround:
+		model, found := problem.AirToGroundEnvironments[cfg.Environment]
+		if !found {
+			return nil, fmt.Errorf("unknown air-to-ground environment %q", cfg.Environment)
+		}
+		model.Frequency = cfg.Frequency
+		model.Reliability = cfg.Reliability
+		return model, nil
+	default:
+		return nil, fmt.Errorf("unknown path loss model %q", cfg.Model)
+	}
+}
+
+func (cfg WeightsConfig) validate(v *validator, path string) {
+	v.check(cfg.Alpha >= 0, "%s.alpha must not be negative, got %g", path, cfg.Alpha)
+	v.check(cfg.Beta >= 0, "%s.beta must not be negative, got %g", path, cfg.Beta)
+	v.check(cfg.Alpha+cfg.Beta > 0, "%s.alpha and %s.beta cannot both be zero", path, path)
+	v.checkProbability(cfg.ChangeUav, path+".changeUav")
+	v.checkProbability(cfg.NewUavChance, path+".newUavChance")
+	v.checkProbability(cfg.AltitudeChance, path+".altitudeChance")
+}
+
+func (cfg ContinuousConfig) validate(v *validator, path string) {
+	if !cfg.Enabled {
+		return
+	}
+	v.check(cfg.Step > 0, "%s.step must be positive, got %g", path, cfg.Step)
+	a := cfg.Area
+	v.check(a.MinX <= a.MaxX && a.MinY <= a.MaxY && a.MinZ <= a.MaxZ, "%s.area has a minimum above its maximum", path)
+}
+
+// Create places UAVs in the configured area, or in the bounding box of the candidate positions of
+// base when the area is left at zero.
+func (cfg ContinuousConfig) Create(base *problem.UAVProblem) *problem.ContinuousProblem {
+	area := base.GetArea()
+	if cfg.Area != (AreaConfig{}) {
+		a := cfg.Area
+		area = problem.Area{
+			Min: utils.Position{X: a.MinX, Y: a.MinY, Z: a.MinZ},
+			Max: utils.Position{X: a.MaxX, Y: a.MaxY, Z: a.MaxZ},
+		}
+	}
+	return problem.CreateContinuousProblem(base, area, cfg.Step)
+}
+
+func (cfg InstanceConfig) validate(v *validator, path string) {
+	v.checkFile(cfg.DeviceFile, path+".devices")
+	v.checkFile(cfg.SliceFile, path+".slices")
+	v.checkFile(cfg.PositionFile, path+".positions")
+	for i, altitude := range cfg.Altitudes {
+		v.check(altitude > 0, "%s.altitudes[%d] must be positive, got %g", path, i, altitude)
+		v.check(!slices.Contains(cfg.Altitudes[:i], altitude), "%s.altitudes[%d] repeats altitude %g", path, i, altitude)
+	}
+	cfg.Gateway.validate(v, path+".gateway")
+	cfg.PathLoss.validate(v, path+".pathLoss")
+	cfg.Weights.validate(v, path+".weights")
+	cfg.Continuous.validate(v, path+".continuous")
+}
+
+func (cfg InstanceConfig) Validate() error {
+	v := &validator{}
+	cfg.validate(v, "instance")
+	return v.err()
+}
+
+func (cfg InstanceConfig) Load() (*problem.UAVProblem, error) {
+	// ---------- Load Data
+	deviceList, err := device.ReadDeviceList(cfg.DeviceFile, cfg.SliceFile)
+	if err != nil {
+		return nil, err
+	}
+
+	candidatePosList, err := gateway.ReadCandidatePositionList(cfg.PositionFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Altitudes) > 0 {
+		candidatePosList = candidatePosList.WithAltitudes(cfg.Altitudes)
+	}
+
+	gw := &gateway.Gateway{}
+	gw.SetSensitivity(cfg.Gateway.Sensitivity)
+	for _, slice := range deviceList.Slices() {
+		gw.AddSlice(slice, cfg.Gateway.Bandwidth, cfg.Gateway.MaxDatarate)
+	}
+
+	pathLoss, err := cfg.PathLoss.Create()
+	if err != nil {
+		return nil, err
+	}
+
+	// ---------- Create problem instance
+	w := cfg.Weights
+	instance, err := problem.CreateUAVProblemInstanceWithPathLoss(w.Alpha, w.Beta, w.ChangeUav, w.NewUavChance, pathLoss, deviceList, candidatePosList, gw)
+	if err != nil {
+		return nil, err
+	}
+	instance.SetAltitudeChance(w.AltitudeChance)
+
+	return instance, nil
+}
@@ -0,0 +1,531 @@
+package experiment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/solver"
+)
+
+const (
+	SolverSA    = "sa"
+	SolverTS    = "ts"
+	SolverGA    = "ga"
+	SolverGRASP = "grasp"
+	SolverBB    = "bb"
+	SolverPSO   = "pso"
+	SolverVNS   = "vns"
+	SolverACO   = "aco"
+	SolverNSGA2 = "nsga2"
+)
+
+var SolverNames = []string{SolverSA, SolverTS, SolverGA, SolverGRASP, SolverBB, SolverPSO, SolverVNS, SolverACO, SolverNSGA2}
+
+type SAParams struct {
+	InitialTemp       float64 `json:"initialTemp" yaml:"initialTemp"`
+	CoolingRate       float64 `json:"coolingRate" yaml:"coolingRate"`
+	IterationsPerTemp int     `json:"iterationsPerTemp" yaml:"iterationsPerTemp"`
+	MaxIterations     int     `json:"maxIterations" yaml:"maxIterations"`
+	MinDistance       int     `json:"minDistance" yaml:"minDistance"`
+	MaxDistance       int     `json:"maxDistance" yaml:"maxDistance"`
+}
+
+type TSParams struct {
+	MaxIterations                   int     `json:"maxIterations" yaml:"maxIterations"`
+	TabuListSize                    int     `json:"tabuListSize" yaml:"tabuListSize"`
+	BatchSize                       int     `json:"batchSize" yaml:"batchSize"`
+	MaxIterationsWithoutEnhancement int     `json:"maxIterationsWithoutEnhancement" yaml:"maxIterationsWithoutEnhancement"`
+	TabuUavRatio                    float32 `json:"tabuUavRatio" yaml:"tabuUavRatio"`
+}
+
+type GAParams struct {
+	Generations       int     `json:"generations" yaml:"generations"`
+	Population        int     `json:"population" yaml:"population"`
+	MaxTabuIterations int     `json:"maxTabuIterations" yaml:"maxTabuIterations"`
+	CrossRate         float64 `json:"crossRate" yaml:"crossRate"`
+	MutationRate      float64 `json:"mutationRate" yaml:"mutationRate"`
+}
+
+type GRASPParams struct{}
+
+type BBParams struct{}
+
+type PSOParams struct {
+	SwarmSize     int     `json:"swarmSize" yaml:"swarmSize"`
+	MaxIterations int     `json:"maxIterations" yaml:"maxIterations"`
+	Inertia       float64 `json:"inertia" yaml:"inertia"`
+	Cognitive     float64 `json:"cognitive" yaml:"cognitive"`
+	Social        float64 `json:"social" yaml:"social"`
+	MaxVelocity   float64 `json:"maxVelocity" yaml:"maxVelocity"`
+}
+
+type VNSParams struct {
+	MaxIterations int `json:"maxIterations" yaml:"maxIterations"`
+	MaxShake      int `json:"maxShake" yaml:"maxShake"`
+	Samples       int `json:"samples" yaml:"samples"`
+}
+
+type ACOParams struct {
+	Ants            int     `json:"ants" yaml:"ants"`
+	MaxIterations   int     `json:"maxIterations" yaml:"maxIterations"`
+	PheromoneWeight float64 `json:"pheromoneWeight" yaml:"pheromoneWeight"`
+	HeuristicWeight float64 `json:"heuristicWeight" yaml:"heuristicWeight"`
+	Evaporation     float64 `json:"evaporation" yaml:"evaporation"`
+	MMAS            bool    `json:"mmas" yaml:"mmas"`
+	LocalSearch     int     `json:"localSearch" yaml:"localSearch"`
+}
+
+// NSGA2Params lists the objectives by name, see problem.GetObjective.
+type NSGA2Params struct {
+	Population   int      `json:"population" yaml:"population"`
+	Generations  int      `json:"generations" yaml:"generations"`
+	CrossRate    float64  `json:"crossRate" yaml:"crossRate"`
+	MutationRate float64  `json:"mutationRate" yaml:"mutationRate"`
+	Objectives   []string `json:"objectives" yaml:"objectives"`
+}
+
+// BudgetConfig bounds a run independently of the solver. Zero values disable a limit and the run
+// stops as soon as any of the enabled ones is reached.
+type BudgetConfig struct {
+	WallTime       Duration `json:"wallTime" yaml:"wallTime"`
+	MaxIterations  int      `json:"maxIterations" yaml:"maxIterations"`
+	MaxEvaluations int      `json:"maxEvaluations" yaml:"maxEvaluations"`
+	TargetCost     float64  `json:"targetCost" yaml:"targetCost"`
+	Stagnation     int      `json:"stagnation" yaml:"stagnation"`
+}
+
+type SolverConfig struct {
+	Name   string       `json:"name" yaml:"name"`
+	Label  string       `json:"label,omitempty" yaml:"label,omitempty"`
+	Budget BudgetConfig `json:"budget" yaml:"budget"`
+	SA     SAParams     `json:"sa" yaml:"sa"`
+	TS     TSParams     `json:"ts" yaml:"ts"`
+	GA     GAParams     `json:"ga" yaml:"ga"`
+	GRASP  GRASPParams  `json:"grasp" yaml:"grasp"`
+	BB     BBParams     `json:"bb" yaml:"bb"`
+	PSO    PSOParams    `json:"pso" yaml:"pso"`
+	VNS    VNSParams    `json:"vns" yaml:"vns"`
+	ACO    ACOParams    `json:"aco" yaml:"aco"`
+	NSGA2  NSGA2Params  `json:"nsga2" yaml:"nsga2"`
+}
+
+func DefaultSAParams() SAParams {
+	return SAParams{
+		InitialTemp:       250.0,
+		CoolingRate:       0.99985,
+		IterationsPerTemp: 20,
+		MaxIterations:     1000000,
+		MinDistance:       1,
+		MaxDistance:       5,
+	}
+}
+
+func DefaultTSParams() TSParams {
+	return TSParams{
+		MaxIterations:                   150000,
+		TabuListSize:                    40,
+		BatchSize:                       20,
+		MaxIterationsWithoutEnhancement: 10000,
+		TabuUavRatio:                    0.25,
+	}
+}
+
+func DefaultGAParams() GAParams {
+	return GAParams{
+		Generations:       15000,
+		Population:        50,
+		MaxTabuIterations: 100,
+		CrossRate:         0.6,
+		MutationRate:      0.0001,
+	}
+}
+
+func DefaultPSOParams() PSOParams {
+	return PSOParams{
+		SwarmSize:     30,
+		MaxIterations: 5000,
+		Inertia:       0.7,
+		Cognitive:     1.5,
+		Social:        1.5,
+		MaxVelocity:   4.0,
+	}
+}
+
+func DefaultVNSParams() VNSParams {
+	return VNSParams{
+		MaxIterations: 20000,
+		MaxShake:      5,
+		Samples:       20,
+	}
+}
+
+func DefaultACOParams() ACOParams {
+	return ACOParams{
+		Ants:            20,
+		MaxIterations:   2000,
+		PheromoneWeight: 1.0,
+		HeuristicWeight: 2.0,
+		Evaporation:     0.1,
+		MMAS:            true,
+		// The ants decode their UAVs on SF 10, so only the local search balances the SFs
+		LocalSearch: 1000,
+	}
+}
+
+func DefaultNSGA2Params() NSGA2Params {
+	return NSGA2Params{
+		Population:   100,
+		Generations:  2000,
+		CrossRate:    0.6,
+		MutationRate: 0.001,
+		Objectives:   []string{problem.ObjectiveUavs, problem.ObjectiveSfLoad},
+	}
+}
+
+func DefaultBudgetConfig() BudgetConfig {
+	return BudgetConfig{
+		WallTime: Duration(60 * time.Second),
+	}
+}
+
+func DefaultSolverConfig(name string) SolverConfig {
+	return SolverConfig{
+		Name:   name,
+		Budget: DefaultBudgetConfig(),
+		SA:     DefaultSAParams(),
+		TS:     DefaultTSParams(),
+		GA:     DefaultGAParams(),
+		PSO:    DefaultPSOParams(),
+		VNS:    DefaultVNSParams(),
+		ACO:    DefaultACOParams(),
+		NSGA2:  DefaultNSGA2Params(),
+	}
+}
+
+func (cfg BudgetConfig) validate(v *validator, path string) {
+	v.check(cfg.WallTime >= 0, "%s.wallTime must not be negative, got %v", path, cfg.WallTime)
+	v.check(cfg.MaxIterations >= 0, "%s.maxIterations must not be negative, got %d", path, cfg.MaxIterations)
+	v.check(cfg.MaxEvaluations >= 0, "%s.maxEvaluations must not be negative, got %d", path, cfg.MaxEvaluations)
+	v.check(cfg.TargetCost >= 0, "%s.targetCost must not be negative, got %g", path, cfg.TargetCost)
+	v.check(cfg.Stagnation >= 0, "%s.stagnation must not be negative, got %d", path, cfg.Stagnation)
+}
+
+// Criterion combines the enabled limits, returning nil when the budget is unbounded.
+func (cfg BudgetConfig) Criterion() solver.StopCriterion {
+	criteria := make([]solver.StopCriterion, 0)
+	if cfg.WallTime > 0 {
+		criteria = append(criteria, solver.WallTime(time.Duration(cfg.WallTime)))
+	}
+	if cfg.MaxIterations > 0 {
+		criteria = append(criteria, solver.MaxIterations(cfg.MaxIterations))
+	}
+	if cfg.MaxEvaluations > 0 {
+		criteria = append(criteria, solver.MaxEvaluations(cfg.MaxEvaluations))
+	}
+	if cfg.TargetCost > 0 {
+		criteria = append(criteria, solver.TargetCost(cfg.TargetCost))
+	}
+	if cfg.Stagnation > 0 {
+		criteria = append(criteria, solver.Stagnation(cfg.Stagnation))
+	}
+
+	if len(criteria) == 0 {
+		return nil
+	}
+	return solver.Any(criteria...)
+}
+
+func (p SAParams) validate(v *validator, path string) {
+	v.check(p.InitialTemp > 0, "%s.initialTemp must be positive, got %g", path, p.InitialTemp)
+	v.check(p.CoolingRate > 0 && p.CoolingRate < 1, "%s.coolingRate must be in (0, 1), got %g", path, p.CoolingRate)
+	v.check(p.IterationsPerTemp > 0, "%s.iterationsPerTemp must be positive, got %d", path, p.IterationsPerTemp)
+	v.check(p.MaxIterations > 0, "%s.maxIterations must be positive, got %d", path, p.MaxIterations)
+	v.check(p.MinDistance > 0, "%s.minDistance must be positive, got %d", path, p.MinDistance)
+	v.check(p.MaxDistance > p.MinDistance, "%s.maxDistance must be greater than minDistance, got %d <= %d", path, p.MaxDistance, p.MinDistance)
+}
+
+func (p TSParams) validate(v *validator, path string) {
+	v.check(p.MaxIterations > 0, "%s.maxIterations must be positive, got %d", path, p.MaxIterations)
+	v.check(p.TabuListSize >= 0, "%s.tabuListSize must not be negative, got %d", path, p.TabuListSize)
+	v.check(p.BatchSize > 0, "%s.batchSize must be positive, got %d", path, p.BatchSize)
+	v.check(p.MaxIterationsWithoutEnhancement > 0, "%s.maxIterationsWithoutEnhancement must be positive, got %d", path, p.MaxIterationsWithoutEnhancement)
+	v.checkProbability(float64(p.TabuUavRatio), path+".tabuUavRatio")
+}
+
+func (p GAParams) validate(v *validator, path string) {
+	v.check(p.Generations > 0, "%s.generations must be positive, got %d", path, p.Generations)
+	v.check(p.Population >= 10, "%s.population must be at least 10, got %d", path, p.Population)
+	v.check(p.MaxTabuIterations >= 0, "%s.maxTabuIterations must not be negative, got %d", path, p.MaxTabuIterations)
+	v.checkProbability(p.CrossRate, path+".crossRate")
+	v.checkProbability(p.MutationRate, path+".mutationRate")
+}
+
+func (p PSOParams) validate(v *validator, path string) {
+	v.check(p.SwarmSize >= 2, "%s.swarmSize must be at least 2, got %d", path, p.SwarmSize)
+	v.check(p.MaxIterations > 0, "%s.maxIterations must be positive, got %d", path, p.MaxIterations)
+	v.check(p.Inertia >= 0, "%s.inertia must not be negative, got %g", path, p.Inertia)
+	v.check(p.Cognitive >= 0, "%s.cognitive must not be negative, got %g", path, p.Cognitive)
+	v.check(p.Social >= 0, "%s.social must not be negative, got %g", path, p.Social)
+	v.check(p.MaxVelocity > 0, "%s.maxVelocity must be positive, got %g", path, p.MaxVelocity)
+}
+
+func (p VNSParams) validate(v *validator, path string) {
+	v.check(p.MaxIterations > 0, "%s.maxIterations must be positive, got %d", path, p.MaxIterations)
+	v.check(p.MaxShake > 0, "%s.maxShake must be positive, got %d", path, p.MaxShake)
+	v.check(p.Samples > 0, "%s.samples must be positive, got %d", path, p.Samples)
+}
+
+func (p ACOParams) validate(v *validator, path string) {
+	v.check(p.Ants > 0, "%s.ants must be positive, got %d", path, p.Ants)
+	v.check(p.MaxIterations > 0, "%s.maxIterations must be positive, got %d", path, p.MaxIterations)
+	v.check(p.PheromoneWeight >= 0, "%s.pheromoneWeight must not be negative, got %g", path, p.PheromoneWeight)
+	v.check(p.HeuristicWeight >= 0, "%s.heuristicWeight must not be negative, got %g", path, p.HeuristicWeight)
+	v.check(p.Evaporation > 0 && p.Evaporation < 1, "%s.evaporation must be in (0, 1), got %g", path, p.Evaporation)
+	v.check(p.LocalSearch >= 0, "%s.localSearch must not be negative, got %d", path, p.LocalSearch)
+}
+
+func (p NSGA2Params) validate(v *validator, path string) {
+	v.check(p.Population >= 4, "%s.population must be at least 4, got %d", path, p.Population)
+	v.check(p.Generations > 0, "%s.generations must be positive, got %d", path, p.Generations)
+	v.checkProbability(p.CrossRate, path+".crossRate")
+	v.checkProbability(p.MutationRate, path+".mutationRate")
+	v.check(len(p.Objectives) >= 2, "%s.objectives must name at least 2 objectives, got %d", path, len(p.Objectives))
+	for i, name := range p.Objectives {
+		_, err := problem.GetObjective(name)
+		v.check(err == nil, "%s.objectives[%d]: %v", path, i, err)
+		v.check(!slices.Contains(p.Objectives[:i], name), "%s.objectives[%d]: %q is repeated", path, i, name)
+	}
+}
+
+// objectives resolves the objective names, which validate has checked.
+func (p NSGA2Params) objectives() []problem.Objective {
+	objectives := make([]problem.Objective, len(p.Objectives))
+	for i, name := range p.Objectives {
+		objectives[i], _ = problem.GetObjective(name)
+	}
+	return objectives
+}
+
+func (cfg SolverConfig) validate(v *validator, path string) {
+	cfg.Budget.validate(v, path+".budget")
+	switch cfg.Name {
+	case SolverSA:
+		cfg.SA.validate(v, path+".sa")
+	case SolverTS:
+		cfg.TS.validate(v, path+".ts")
+	case SolverGA:
+		cfg.GA.validate(v, path+".ga")
+	case SolverPSO:
+		cfg.PSO.validate(v, path+".pso")
+	case SolverVNS:
+		cfg.VNS.validate(v, path+".vns")
+	case SolverACO:
+		cfg.ACO.validate(v, path+".aco")
+	case SolverNSGA2:
+		cfg.NSGA2.validate(v, path+".nsga2")
+	case SolverGRASP, SolverBB:
+	case "":
+		v.check(false, "%s.name is required, valid solvers are %s", path, strings.Join(SolverNames, ", "))
+	default:
+		v.check(false, "%s.name %q is not a known solver, valid solvers are %s", path, cfg.Name, strings.Join(SolverNames, ", "))
+	}
+}
+
+func (cfg SolverConfig) Validate() error {
+	v := &validator{}
+	cfg.validate(v, "solver")
+	return v.err()
+}
+
+// UnmarshalJSON starts from the default parameters so that a solver block only needs the values it
+// changes, also when the block is an element of a list.
+func (cfg *SolverConfig) UnmarshalJSON(data []byte) error {
+	type plain SolverConfig
+	p := plain(DefaultSolverConfig(""))
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&p); err != nil {
+		return err
+	}
+
+	*cfg = SolverConfig(p)
+	return nil
+}
+
+// UnmarshalYAML uses the callback form so that the strict field checking of the decoder still applies.
+func (cfg *SolverConfig) UnmarshalYAML(unmarshal func(any) error) error {
+	type plain SolverConfig
+	p := plain(DefaultSolverConfig(""))
+
+	if err := unmarshal(&p); err != nil {
+		return err
+	}
+
+	*cfg = SolverConfig(p)
+	return nil
+}
+
+// GetLabel identifies the solver in reports, falling back to its name when no label was given.
+func (cfg SolverConfig) GetLabel() string {
+	if cfg.Label != "" {
+		return cfg.Label
+	}
+	return cfg.Name
+}
+
+// Params renders the parameters of the selected solver and the enabled budget limits as "key=value"
+// pairs separated by ";".
+func (cfg SolverConfig) Params() string {
+	params := cfg.paramsValue()
+	if !params.IsValid() {
+		return ""
+	}
+
+	pairs := appendPairs(nil, params, false)
+	pairs = appendPairs(pairs, reflect.ValueOf(cfg.Budget), true)
+	return strings.Join(pairs, ";")
+}
+
+// paramsValue is the settable parameter block of the selected solver, invalid for an unknown one.
+func (cfg *SolverConfig) paramsValue() reflect.Value {
+	var params any
+	switch cfg.Name {
+	case SolverSA:
+		params = &cfg.SA
+	case SolverTS:
+		params = &cfg.TS
+	case SolverGA:
+		params = &cfg.GA
+	case SolverGRASP:
+		params = &cfg.GRASP
+	case SolverBB:
+		params = &cfg.BB
+	case SolverPSO:
+		params = &cfg.PSO
+	case SolverVNS:
+		params = &cfg.VNS
+	case SolverACO:
+		params = &cfg.ACO
+	case SolverNSGA2:
+		params = &cfg.NSGA2
+	default:
+		return reflect.Value{}
+	}
+	return reflect.ValueOf(params).Elem()
+}
+
+// paramField is the field of the selected solver's parameter block with the given json name.
+func (cfg *SolverConfig) paramField(name string) (reflect.Value, error) {
+	params := cfg.paramsValue()
+	if !params.IsValid() {
+		return reflect.Value{}, fmt.Errorf("unknown solver %q", cfg.Name)
+	}
+	for i := 0; i < params.NumField(); i++ {
+		if key, _, _ := strings.Cut(params.Type().Field(i).Tag.Get("json"), ","); key == name {
+			return params.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("solver %q has no parameter %q", cfg.Name, name)
+}
+
+// setParam parses value into the parameter of the selected solver with the given json name.
+func (cfg *SolverConfig) setParam(name, value string) error {
+	field, err := cfg.paramField(name)
+	if err != nil {
+		return err
+	}
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parameter %q: %v", name, err)
+		}
+		field.SetInt(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parameter %q: %v", name, err)
+		}
+		field.SetFloat(parsed)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("parameter %q: %v", name, err)
+		}
+		field.SetBool(parsed)
+	case reflect.String:
+		field.SetString(value)
+	default:
+		return fmt.Errorf("parameter %q of type %s cannot be set", name, field.Type())
+	}
+	return nil
+}
+
+func appendPairs(pairs []string, value reflect.Value, skipZero bool) []string {
+	for i := 0; i < value.NumField(); i++ {
+		if skipZero && value.Field(i).IsZero() {
+			continue
+		}
+		key, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, value.Field(i).Interface()))
+	}
+	return pairs
+}
+
+// CreateContinuous builds the solver on the continuous mode of an instance. Only SA works on it,
+// the other solvers relying on the candidate positions.
+func (cfg SolverConfig) CreateContinuous(instance *problem.ContinuousProblem) (solver.Solver, error) {
+	if cfg.Name != SolverSA {
+		return nil, fmt.Errorf("solver %q does not support continuous positioning, use %s", cfg.Name, SolverSA)
+	}
+
+	p := cfg.SA
+	s := solver.CreateSASolver(p.InitialTemp, p.CoolingRate, p.IterationsPerTemp, p.MaxIterations, p.MinDistance, p.MaxDistance, instance)
+	s.SetStopCriterion(cfg.Budget.Criterion())
+	return s, nil
+}
+
+func (cfg SolverConfig) Create(instance *problem.UAVProblem) (solver.Solver, error) {
+	var s solver.Solver
+	switch cfg.Name {
+	case SolverSA:
+		p := cfg.SA
+		s = solver.CreateSASolver(p.InitialTemp, p.CoolingRate, p.IterationsPerTemp, p.MaxIterations, p.MinDistance, p.MaxDistance, instance)
+	case SolverTS:
+		p := cfg.TS
+		s = solver.CreateTSSolver(p.MaxIterations, p.BatchSize, p.TabuListSize, p.MaxIterationsWithoutEnhancement, p.TabuUavRatio, instance)
+	case SolverGA:
+		p := cfg.GA
+		s = solver.CreateGASolver(instance, p.Generations, p.Population, p.MaxTabuIterations, p.CrossRate, p.MutationRate)
+	case SolverGRASP:
+		s = solver.CreateGRASPSolver(instance)
+	case SolverBB:
+		s = solver.CreateBBSolver(instance)
+	case SolverPSO:
+		p := cfg.PSO
+		s = solver.CreatePSOSolver(instance, p.SwarmSize, p.MaxIterations, p.Inertia, p.Cognitive, p.Social, p.MaxVelocity)
+	case SolverVNS:
+		p := cfg.VNS
+		s = solver.CreateVNSSolver(instance, p.MaxIterations, p.MaxShake, p.Samples)
+	case SolverACO:
+		p := cfg.ACO
+		s = solver.CreateACOSolver(instance, p.Ants, p.MaxIterations, p.PheromoneWeight, p.HeuristicWeight, p.Evaporation, p.MMAS, p.LocalSearch)
+	case SolverNSGA2:
+		p := cfg.NSGA2
+		s = solver.CreateNSGA2Solver(instance, p.objectives(), p.Population, p.Generations, p.CrossRate, p.MutationRate)
+	default:
+		return nil, fmt.Errorf("unknown solver %q", cfg.Name)
+	}
+
+	s.SetStopCriterion(cfg.Budget.Criterion())
+	return s, nil
+}
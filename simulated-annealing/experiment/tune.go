@@ -0,0 +1,631 @@
+package experiment
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/stats"
+)
+
+const (
+	ParameterReal        = "real"
+	ParameterInteger     = "integer"
+	ParameterCategorical = "categorical"
+)
+
+var ParameterTypes = []string{ParameterReal, ParameterInteger, ParameterCategorical}
+
+// ParameterSpace is the range of one solver parameter explored by the tuner, named by its json
+// field in the parameter block of the tuned solver, such as coolingRate for sa. Real and integer
+// parameters are drawn from [Min, Max], on a log scale when Log is set, and categorical ones take
+// one of Values.
+type ParameterSpace struct {
+	Name   string   `json:"name" yaml:"name"`
+	Type   string   `json:"type" yaml:"type"`
+	Min    float64  `json:"min" yaml:"min"`
+	Max    float64  `json:"max" yaml:"max"`
+	Log    bool     `json:"log" yaml:"log"`
+	Values []string `json:"values" yaml:"values"`
+}
+
+// TuneConfig describes an iterated race, as in irace, over the parameters of one solver. Every
+// candidate configuration starts from Solver, with the parameters of the space set, and runs on
+// the training instances of the grid until MaxRuns solver runs are spent.
+//
+// Each iteration samples new candidates, around the elites of the previous one after the first,
+// and races them with the elites: they all run on the same stream of instances and random seeds,
+// and from the FirstTest-th instance on, those significantly worse than the best, by a Friedman
+// test and Conover's post-hoc comparisons at level Alpha, are dropped. The race ends when at most
+// Elites candidates are left or its share of the budget is spent, and the best survivors are the
+// elites of the next iteration. Iterations 0 picks 2+log2(parameters) of them.
+type TuneConfig struct {
+	Instances  InstanceConfig   `json:"instances" yaml:"instances"`
+	Training   GridConfig       `json:"training" yaml:"training"`
+	Solver     SolverConfig     `json:"solver" yaml:"solver"`
+	Parameters []ParameterSpace `json:"parameters" yaml:"parameters"`
+	MaxRuns    int              `json:"maxRuns" yaml:"maxRuns"`
+	Iterations int              `json:"iterations" yaml:"iterations"`
+	FirstTest  int              `json:"firstTest" yaml:"firstTest"`
+	Elites     int              `json:"elites" yaml:"elites"`
+	Alpha      float64          `json:"alpha" yaml:"alpha"`
+	Workers    int              `json:"workers" yaml:"workers"`
+	Output     string           `json:"output" yaml:"output"`
+	RngSeed    int64            `json:"rngSeed" yaml:"rngSeed"`
+}
+
+// Elite is a configuration that survived the last race, with the values of the tuned parameters
+// as name=value pairs and its mean cost over the runs it took part in.
+type Elite struct {
+	ID       int
+	Params   string
+	Solver   SolverConfig
+	MeanCost float64
+	Runs     int
+}
+
+func DefaultTuneConfig() TuneConfig {
+	return TuneConfig{
+		Instances: DefaultInstanceConfig(),
+		Solver:    DefaultSolverConfig(""),
+		MaxRuns:   1000,
+		FirstTest: 5,
+		Elites:    3,
+		Alpha:     0.05,
+		Workers:   runtime.NumCPU(),
+	}
+}
+
+func LoadTuneConfig(path string) (TuneConfig, error) {
+	cfg := DefaultTuneConfig()
+	cfg.Instances.Gateway.Sensitivity = nil
+
+	if err := decodeFile(path, &cfg); err != nil {
+		return TuneConfig{}, err
+	}
+
+	if cfg.Instances.Gateway.Sensitivity == nil {
+		cfg.Instances.Gateway.Sensitivity = DefaultGatewayConfig().Sensitivity
+	}
+
+	dir := filepath.Dir(path)
+	cfg.Instances = cfg.Instances.resolvePaths(dir)
+	cfg.Output = resolvePath(dir, cfg.Output)
+
+	return cfg, nil
+}
+
+func (cfg TuneConfig) Validate() error {
+	v := &validator{}
+	v.check(len(cfg.Training.Seeds) > 0, "training.seeds must not be empty")
+	v.check(len(cfg.Training.Devices) > 0, "training.devices must not be empty")
+	v.check(len(cfg.Training.Gateways) > 0, "training.gateways must not be empty")
+	v.check(len(cfg.Parameters) > 0, "parameters must not be empty")
+	v.check(cfg.Iterations >= 0, "iterations must not be negative, got %d", cfg.Iterations)
+	v.check(cfg.FirstTest >= 2, "firstTest must be at least 2, got %d", cfg.FirstTest)
+	v.check(cfg.Elites > 0, "elites must be positive, got %d", cfg.Elites)
+	v.check(cfg.Alpha > 0 && cfg.Alpha < 1, "alpha must be in (0, 1), got %g", cfg.Alpha)
+	v.check(cfg.Workers > 0, "workers must be positive, got %d", cfg.Workers)
+	v.check(cfg.Output != "", "output is required")
+	if minRuns := 2 * cfg.iterations() * (cfg.FirstTest + 1); cfg.MaxRuns < minRuns {
+		v.check(false, "maxRuns must be at least %d to race two candidates in each of the %d iterations, got %d", minRuns, cfg.iterations(), cfg.MaxRuns)
+	}
+
+	for _, seed := range cfg.Training.Seeds {
+		for _, numDevices := range cfg.Training.Devices {
+			for _, numGateways := range cfg.Training.Gateways {
+				path := fmt.Sprintf("instances[seed=%d,devices=%d,gateways=%d]", seed, numDevices, numGateways)
+				cfg.Instances.Expand(seed, numDevices, numGateways).validate(v, path)
+			}
+		}
+	}
+
+	cfg.Solver.validate(v, "solver")
+	v.check(!cfg.Instances.Continuous.Enabled || cfg.Solver.Name == SolverSA, "instances.continuous only supports the %s solver, got %q", SolverSA, cfg.Solver.Name)
+
+	names := make(map[string]bool, len(cfg.Parameters))
+	for i, space := range cfg.Parameters {
+		path := fmt.Sprintf("parameters[%d]", i)
+		v.check(!names[space.Name], "%s name %q is used more than once", path, space.Name)
+		names[space.Name] = true
+		space.validate(v, path, cfg.Solver)
+	}
+
+	return v.err()
+}
+
+func (space ParameterSpace) validate(v *validator, path string, solverConfig SolverConfig) {
+	field, err := solverConfig.paramField(space.Name)
+	if err != nil {
+		v.check(false, "%s: %v", path, err)
+		return
+	}
+
+	kind := field.Kind()
+	switch space.Type {
+	case ParameterReal, ParameterInteger:
+		if space.Type == ParameterReal {
+			v.check(kind == reflect.Float32 || kind == reflect.Float64, "%s: %s is not a real parameter", path, space.Name)
+		} else {
+			v.check(field.CanInt() || kind == reflect.Float32 || kind == reflect.Float64, "%s: %s is not a numeric parameter", path, space.Name)
+			v.check(space.Min == math.Trunc(space.Min) && space.Max == math.Trunc(space.Max), "%s: min and max must be integers, got [%g, %g]", path, space.Min, space.Max)
+		}
+		v.check(space.Min < space.Max, "%s: min must be below max, got [%g, %g]", path, space.Min, space.Max)
+		v.check(!space.Log || space.Min > 0, "%s: min must be positive on a log scale, got %g", path, space.Min)
+		v.check(len(space.Values) == 0, "%s.values is only used by categorical parameters", path)
+	case ParameterCategorical:
+		v.check(len(space.Values) > 0, "%s.values must not be empty", path)
+		for _, value := range space.Values {
+			if err := solverConfig.setParam(space.Name, value); err != nil {
+				v.check(false, "%s: %v", path, err)
+			}
+		}
+	default:
+		v.check(false, "%s.type must be one of %s, got %q", path, strings.Join(ParameterTypes, ", "), space.Type)
+	}
+}
+
+func (cfg TuneConfig) iterations() int {
+	if cfg.Iterations > 0 {
+		return cfg.Iterations
+	}
+	return 2 + int(math.Log2(float64(max(len(cfg.Parameters), 1))))
+}
+
+// bounds is the range from which numeric values are drawn, log scaled when Log is set. The upper
+// bound of integers is widened by one so that flooring gives Max the same chance as the others.
+func (space ParameterSpace) bounds() (float64, float64) {
+	low, high := space.Min, space.Max
+	if space.Type == ParameterInteger {
+		high++
+	}
+	if space.Log {
+		return math.Log(low), math.Log(high)
+	}
+	return low, high
+}
+
+func (space ParameterSpace) toScale(value string) float64 {
+	x, _ := strconv.ParseFloat(value, 64)
+	if space.Type == ParameterInteger {
+		x += 0.5
+	}
+	if space.Log {
+		return math.Log(x)
+	}
+	return x
+}
+
+func (space ParameterSpace) fromScale(x float64) string {
+	if space.Log {
+		x = math.Exp(x)
+	}
+	if space.Type == ParameterInteger {
+		return strconv.FormatFloat(math.Max(space.Min, math.Min(space.Max, math.Floor(x))), 'f', 0, 64)
+	}
+	return strconv.FormatFloat(math.Max(space.Min, math.Min(space.Max, x)), 'g', 6, 64)
+}
+
+func (space ParameterSpace) sample(rng *rand.Rand) string {
+	if space.Type == ParameterCategorical {
+		return space.Values[rng.Intn(len(space.Values))]
+	}
+	low, high := space.bounds()
+	return space.fromScale(low + rng.Float64()*(high-low))
+}
+
+// perturb draws a value close to the one of a parent elite. Numeric values follow a normal
+// distribution around it, truncated to the range, whose deviation is spread times half the range.
+// Categorical values are kept with probability keep and drawn uniformly otherwise.
+func (space ParameterSpace) perturb(rng *rand.Rand, parent string, spread, keep float64) string {
+	if space.Type == ParameterCategorical {
+		if rng.Float64() < keep {
+			return parent
+		}
+		return space.sample(rng)
+	}
+
+	low, high := space.bounds()
+	center := space.toScale(parent)
+	deviation := spread * (high - low) / 2
+	for try := 0; try < 100; try++ {
+		if x := center + rng.NormFloat64()*deviation; x >= low && x < high {
+			return space.fromScale(x)
+		}
+	}
+	return parent
+}
+
+// candidate is a configuration of the race with its cost on each step of the instance stream run
+// so far. Parent is the elite it was sampled around, 0 in the first iteration.
+type candidate struct {
+	id     int
+	parent int
+	values []string
+	solver SolverConfig
+	costs  []float64
+}
+
+type evaluation struct {
+	candidate *candidate
+	step      int
+	run       Run
+	result    RunResult
+}
+
+var tuneHeader = []string{"iteration", "candidate", "parent", "step", "seed", "numDevices", "numGateways", "rngSeed"}
+
+type tuner struct {
+	cfg       TuneConfig
+	rng       *rand.Rand
+	training  []Run
+	order     []int
+	runs      int
+	lastID    int
+	iteration int
+	writer    *csv.Writer
+}
+
+// Tune runs the iterated race of cfg and returns the final elites, best first. Every solver run is
+// written to w as a CSV row with the tuned parameters and the cost. When ctx is cancelled or a run
+// fails, the elites of the last finished iteration are returned with the error.
+func Tune(ctx context.Context, cfg TuneConfig, w io.Writer) ([]Elite, error) {
+	if cfg.RngSeed == 0 {
+		_, cfg.RngSeed = NewRand(0)
+	}
+	fmt.Printf("Tuning RNG seed: %d\n", cfg.RngSeed)
+	rng, _ := NewRand(cfg.RngSeed)
+
+	t := &tuner{cfg: cfg, rng: rng, writer: csv.NewWriter(w)}
+	for _, seed := range cfg.Training.Seeds {
+		for _, numDevices := range cfg.Training.Devices {
+			for _, numGateways := range cfg.Training.Gateways {
+				t.training = append(t.training, Run{
+					Seed:        seed,
+					NumDevices:  numDevices,
+					NumGateways: numGateways,
+					Instance:    cfg.Instances.Expand(seed, numDevices, numGateways),
+				})
+			}
+		}
+	}
+	t.order = rng.Perm(len(t.training))
+
+	header := slices.Clone(tuneHeader)
+	for _, space := range cfg.Parameters {
+		header = append(header, space.Name)
+	}
+	header = append(header, "bestCost", "numUavs", "evaluations", "elapsed", "stopReason")
+	if err := t.writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	iterations := cfg.iterations()
+	elites := make([]*candidate, 0)
+	for t.iteration = 1; ; t.iteration++ {
+		budget := (cfg.MaxRuns - t.runs) / max(iterations-t.iteration+1, 1)
+		size := budget / (cfg.FirstTest + min(5, t.iteration))
+		if size < 2 || size <= len(elites) {
+			break
+		}
+
+		fresh, err := t.sample(size-len(elites), elites)
+		if err != nil {
+			return t.elites(elites), err
+		}
+		fmt.Printf("iteration %d: racing %d candidates, %d new, on a budget of %d runs\n", t.iteration, size, len(fresh), budget)
+
+		survivors, err := t.race(ctx, append(slices.Clone(elites), fresh...), budget)
+		if err != nil {
+			return t.elites(elites), err
+		}
+		elites = survivors[:min(len(survivors), cfg.Elites)]
+
+		best := elites[0]
+		fmt.Printf("iteration %d done after %d of %d runs: best candidate %d, mean cost %f over %d runs (%s)\n",
+			t.iteration, t.runs, cfg.MaxRuns, best.id, stats.Mean(best.costs), len(best.costs), t.describe(best))
+	}
+
+	return t.elites(elites), t.writer.Error()
+}
+
+// sample draws count new candidates. In the first iteration they are drawn uniformly. Afterwards
+// each one perturbs an elite, picked with a probability decreasing linearly with its rank, and the
+// perturbations shrink from one iteration to the next so that the search converges.
+func (t *tuner) sample(count int, elites []*candidate) ([]*candidate, error) {
+	spaces := t.cfg.Parameters
+	spread := math.Pow(1/float64(count), float64(t.iteration-1)/float64(len(spaces)))
+	keep := math.Min(float64(t.iteration-1)/float64(t.cfg.iterations()), 1)
+
+	seen := make(map[string]bool)
+	for _, elite := range elites {
+		seen[strings.Join(elite.values, ";")] = true
+	}
+
+	fresh := make([]*candidate, 0, count)
+	for len(fresh) < count {
+		values := make([]string, len(spaces))
+		parent := 0
+		// redraw configurations already in the race, giving up after a while on small spaces
+		for try := 0; try < 100; try++ {
+			if len(elites) == 0 {
+				for i, space := range spaces {
+					values[i] = space.sample(t.rng)
+				}
+			} else {
+				elite := elites[rankedPick(t.rng, len(elites))]
+				parent = elite.id
+				for i, space := range spaces {
+					values[i] = space.perturb(t.rng, elite.values[i], spread, keep)
+				}
+			}
+			if !seen[strings.Join(values, ";")] {
+				break
+			}
+		}
+		seen[strings.Join(values, ";")] = true
+
+		t.lastID++
+		c := &candidate{id: t.lastID, parent: parent, values: values, solver: t.cfg.Solver}
+		for i, space := range spaces {
+			if err := c.solver.setParam(space.Name, values[i]); err != nil {
+				return nil, err
+			}
+		}
+		fresh = append(fresh, c)
+	}
+	return fresh, nil
+}
+
+// rankedPick picks an index in [0, n) with weight n-i, favouring the first ones.
+func rankedPick(rng *rand.Rand, n int) int {
+	draw := rng.Intn(n * (n + 1) / 2)
+	for i := 0; i < n; i++ {
+		if draw < n-i {
+			return i
+		}
+		draw -= n - i
+	}
+	return n - 1
+}
+
+// race runs the candidates on the instance stream, all of them on the first cfg.FirstTest steps
+// and then one step at a time, dropping after each step those found significantly worse than the
+// best. It stops when at most cfg.Elites candidates are left or the next step would take more than
+// budget new runs, and returns the survivors best first.
+func (t *tuner) race(ctx context.Context, candidates []*candidate, budget int) ([]*candidate, error) {
+	alive := candidates
+	steps := 0
+	for len(alive) > 1 {
+		next := max(steps+1, t.cfg.FirstTest)
+		pending := t.pending(alive, next)
+		if len(pending) > budget {
+			break
+		}
+		if err := t.evaluate(ctx, pending); err != nil {
+			return nil, err
+		}
+		budget -= len(pending)
+		steps = next
+
+		before := len(alive)
+		alive = t.eliminate(alive, steps)
+		if len(alive) < before {
+			fmt.Printf("iteration %d, step %d: %d of %d candidates left\n", t.iteration, steps, len(alive), before)
+		}
+		if len(alive) <= t.cfg.Elites {
+			break
+		}
+	}
+	return rankCandidates(alive, steps), nil
+}
+
+// pending lists the runs the candidates still need to have results on the first steps of the
+// instance stream. Elites keep the results of their previous races.
+func (t *tuner) pending(candidates []*candidate, steps int) []evaluation {
+	evaluations := make([]evaluation, 0)
+	for _, c := range candidates {
+		for step := len(c.costs); step < steps; step++ {
+			training := t.training[t.order[step%len(t.order)]]
+			run := training
+			run.Replication = step
+			run.RngSeed = runSeed(t.cfg.RngSeed, training.Seed, training.NumDevices, training.NumGateways, "tune", step)
+			run.Solver = c.solver
+			evaluations = append(evaluations, evaluation{candidate: c, step: step, run: run})
+		}
+	}
+	return evaluations
+}
+
+func (t *tuner) evaluate(ctx context.Context, evaluations []evaluation) error {
+	jobs := make(chan evaluation)
+	results := make(chan evaluation)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < min(t.cfg.Workers, len(evaluations)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range jobs {
+				e.result = e.run.Execute(ctx)
+				results <- e
+			}
+		}()
+	}
+
+	go func() {
+		for _, e := range evaluations {
+			jobs <- e
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	// keep draining results after an error so that no worker is left blocked
+	var err error
+	for e := range results {
+		t.runs++
+		c := e.candidate
+		if e.result.Err != nil {
+			if err == nil {
+				err = fmt.Errorf("candidate %d (%s) on seed %d, devices %d, gateways %d: %v",
+					c.id, t.describe(c), e.run.Seed, e.run.NumDevices, e.run.NumGateways, e.result.Err)
+			}
+			continue
+		}
+
+		for len(c.costs) <= e.step {
+			c.costs = append(c.costs, math.NaN())
+		}
+		c.costs[e.step] = e.result.Cost
+
+		record := []string{
+			strconv.Itoa(t.iteration),
+			strconv.Itoa(c.id),
+			strconv.Itoa(c.parent),
+			strconv.Itoa(e.step),
+			strconv.Itoa(e.run.Seed),
+			strconv.Itoa(e.run.NumDevices),
+			strconv.Itoa(e.run.NumGateways),
+			strconv.FormatInt(e.run.RngSeed, 10),
+		}
+		record = append(record, c.values...)
+		record = append(record,
+			strconv.FormatFloat(e.result.Cost, 'f', -1, 64),
+			strconv.Itoa(e.result.NumUavs),
+			strconv.Itoa(e.result.Evaluations),
+			strconv.FormatFloat(e.result.Elapsed.Seconds(), 'f', 6, 64),
+			string(e.result.StopReason),
+		)
+		if err == nil {
+			t.writer.Write(record)
+			t.writer.Flush()
+			err = t.writer.Error()
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("tuning cancelled in iteration %d: %w", t.iteration, ctx.Err())
+	}
+	return nil
+}
+
+// eliminate drops the candidates significantly worse than the best over the first steps. With
+// three candidates or more, a significant Friedman test is followed by Conover's comparisons of
+// the rank sums with the best one. Two candidates are compared by a Wilcoxon signed-rank test.
+func (t *tuner) eliminate(alive []*candidate, steps int) []*candidate {
+	if len(alive) < 2 || steps < t.cfg.FirstTest {
+		return alive
+	}
+
+	blocks := make([][]float64, steps)
+	for step := range blocks {
+		blocks[step] = make([]float64, len(alive))
+		for i, c := range alive {
+			blocks[step][i] = c.costs[step]
+		}
+	}
+
+	if len(alive) == 2 {
+		result, err := stats.WilcoxonSignedRank(column(blocks, 0), column(blocks, 1))
+		if err != nil || !(result.PValue < t.cfg.Alpha) {
+			return alive
+		}
+		if stats.Mean(column(blocks, 0)) <= stats.Mean(column(blocks, 1)) {
+			return alive[:1]
+		}
+		return alive[1:]
+	}
+
+	friedman, err := stats.Friedman(blocks)
+	if err != nil || !(friedman.PValue < t.cfg.Alpha) {
+		return alive
+	}
+	rankSums, cd, err := stats.ConoverCD(blocks, t.cfg.Alpha)
+	if err != nil {
+		return alive
+	}
+
+	best := slices.Min(rankSums)
+	survivors := make([]*candidate, 0, len(alive))
+	for i, c := range alive {
+		if rankSums[i]-best <= cd {
+			survivors = append(survivors, c)
+		}
+	}
+	return survivors
+}
+
+// rankCandidates orders the candidates by their rank sum over the first steps, then by their mean
+// cost over those steps.
+func rankCandidates(candidates []*candidate, steps int) []*candidate {
+	rankSums := make(map[*candidate]float64, len(candidates))
+	means := make(map[*candidate]float64, len(candidates))
+	for step := 0; step < steps; step++ {
+		block := make([]float64, len(candidates))
+		for i, c := range candidates {
+			block[i] = c.costs[step]
+		}
+		ranks, _ := stats.Ranks(block)
+		for i, c := range candidates {
+			rankSums[c] += ranks[i]
+			means[c] += block[i] / float64(steps)
+		}
+	}
+
+	ranked := slices.Clone(candidates)
+	slices.SortStableFunc(ranked, func(a, b *candidate) int {
+		switch {
+		case rankSums[a] != rankSums[b]:
+			return compareFloat(rankSums[a], rankSums[b])
+		default:
+			return compareFloat(means[a], means[b])
+		}
+	})
+	return ranked
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (t *tuner) describe(c *candidate) string {
+	pairs := make([]string, len(c.values))
+	for i, space := range t.cfg.Parameters {
+		pairs[i] = space.Name + "=" + c.values[i]
+	}
+	return strings.Join(pairs, ";")
+}
+
+func (t *tuner) elites(candidates []*candidate) []Elite {
+	elites := make([]Elite, len(candidates))
+	for i, c := range candidates {
+		elites[i] = Elite{
+			ID:       c.id,
+			Params:   t.describe(c),
+			Solver:   c.solver,
+			MeanCost: stats.Mean(c.costs),
+			Runs:     len(c.costs),
+		}
+	}
+	return elites
+}
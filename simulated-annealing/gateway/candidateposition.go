@@ -2,21 +2,33 @@ package gateway
 
 import (
 	"encoding/csv"
+	"fmt"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/utils"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 )
 
 type CandidatePosition struct {
-	id  int32
-	pos utils.Position
+	id     int32
+	ground int32
+	pos    utils.Position
 }
 
+type groundKey struct {
+	x, y float32
+}
+
+// CandidatePositionList groups the candidates sharing X and Y into ground points, so that the
+// altitudes available over a point can be told apart from other positions.
 type CandidatePositionList struct {
 	count        int32
 	candidates   map[int32]*CandidatePosition
 	candidateIds []int32
+	groundIds    map[groundKey]int32
+	grounds      [][]int32
 }
 
 func (cpl *CandidatePositionList) Copy() *CandidatePositionList {
@@ -24,6 +36,8 @@ func (cpl *CandidatePositionList) Copy() *CandidatePositionList {
 		count:        cpl.count,
 		candidates:   make(map[int32]*CandidatePosition, 0),
 		candidateIds: make([]int32, len(cpl.candidateIds)),
+		groundIds:    maps.Clone(cpl.groundIds),
+		grounds:      make([][]int32, len(cpl.grounds)),
 	}
 
 	for key, value := range cpl.candidates {
@@ -31,25 +45,29 @@ func (cpl *CandidatePositionList) Copy() *CandidatePositionList {
 	}
 
 	copy(candidatePositionList.candidateIds, cpl.candidateIds)
+	for i, ground := range cpl.grounds {
+		candidatePositionList.grounds[i] = slices.Clone(ground)
+	}
 
 	return &candidatePositionList
 }
 
-func ReadCandidatePositionList(filePath string) *CandidatePositionList {
+func ReadCandidatePositionList(filePath string) (*CandidatePositionList, error) {
 	candidatePositionList := CandidatePositionList{
 		count:      0,
 		candidates: make(map[int32]*CandidatePosition, 0),
+		groundIds:  make(map[groundKey]int32),
 	}
 
 	file, err := os.Open(filePath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	defer file.Close()
 
 	reader := csv.NewReader(file)
 
-	for {
+	for line := 1; ; line++ {
 		candidatePos, err := reader.Read()
 
 		if candidatePos == nil {
@@ -57,34 +75,37 @@ func ReadCandidatePositionList(filePath string) *CandidatePositionList {
 		}
 
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("%s:%d: %v", filePath, line, err)
 		}
 
-		candidatePos = strings.Split(candidatePos[0], " ")
-		candidatePosX, err := strconv.ParseFloat(candidatePos[0], 32)
-		if err != nil {
-			panic(err)
+		candidatePos = strings.Fields(candidatePos[0])
+		if len(candidatePos) < 3 {
+			return nil, fmt.Errorf("%s:%d: expected x y z, got %q", filePath, line, strings.Join(candidatePos, " "))
 		}
 
-		candidatePosY, err := strconv.ParseFloat(candidatePos[1], 32)
-		if err != nil {
-			panic(err)
+		coords := make([]float32, 3)
+		for i := range coords {
+			value, err := strconv.ParseFloat(candidatePos[i], 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", filePath, line, err)
+			}
+			coords[i] = float32(value)
 		}
 
-		candidatePosZ, err := strconv.ParseFloat(candidatePos[2], 32)
-		if err != nil {
-			panic(err)
-		}
-		candidate := NewCandidatePosition(float32(candidatePosX), float32(candidatePosY), float32(candidatePosZ))
+		candidate := NewCandidatePosition(coords[0], coords[1], coords[2])
 		candidatePositionList.addCandidatePosition(candidate)
 	}
 
-	return &candidatePositionList
+	if candidatePositionList.count == 0 {
+		return nil, fmt.Errorf("%s: no candidate positions found", filePath)
+	}
+
+	return &candidatePositionList, nil
 }
 
 func NewCandidatePosition(x, y, z float32) *CandidatePosition {
 	return &CandidatePosition{
-		pos: utils.Position{x, y, z},
+		pos: utils.Position{X: x, Y: y, Z: z},
 	}
 }
 
@@ -97,6 +118,49 @@ func (cpl *CandidatePositionList) addCandidatePosition(candidate *CandidatePosit
 	cpl.candidates[candidate.id] = candidate
 	cpl.candidateIds = append(cpl.candidateIds, candidate.id)
 	cpl.count++
+
+	key := groundKey{candidate.pos.X, candidate.pos.Y}
+	ground, found := cpl.groundIds[key]
+	if !found {
+		ground = int32(len(cpl.grounds))
+		cpl.groundIds[key] = ground
+		cpl.grounds = append(cpl.grounds, nil)
+	}
+	candidate.ground = ground
+	cpl.grounds[ground] = append(cpl.grounds[ground], candidate.id)
+}
+
+// WithAltitudes returns a list holding, for every ground point in order, one candidate at each of
+// the given altitudes. The altitudes in the original list are dropped.
+func (cpl *CandidatePositionList) WithAltitudes(altitudes []float32) *CandidatePositionList {
+	expanded := CandidatePositionList{
+		count:      0,
+		candidates: make(map[int32]*CandidatePosition, 0),
+		groundIds:  make(map[groundKey]int32),
+	}
+
+	for _, ground := range cpl.grounds {
+		pos := cpl.candidates[ground[0]].pos
+		for _, altitude := range altitudes {
+			expanded.addCandidatePosition(NewCandidatePosition(pos.X, pos.Y, altitude))
+		}
+	}
+
+	return &expanded
+}
+
+func (cpl *CandidatePositionList) CountGrounds() int32 {
+	return int32(len(cpl.grounds))
+}
+
+func (cpl *CandidatePositionList) GetGroundId(posId int32) int32 {
+	return cpl.candidates[posId].ground
+}
+
+// GetAltitudes returns the candidates over the same ground point as posId, posId included, in the
+// order they were added.
+func (cpl *CandidatePositionList) GetAltitudes(posId int32) []int32 {
+	return slices.Clone(cpl.grounds[cpl.candidates[posId].ground])
 }
 
 func (candidates *CandidatePositionList) GetCandidatePosition(posId int32) utils.Position {
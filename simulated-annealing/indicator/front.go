@@ -0,0 +1,68 @@
+package indicator
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+)
+
+// ReadFront reads a front CSV as written by solver.NSGA2Solver.WriteFront. Columns named after an
+// objective are kept, in file order, and the others are ignored. Values of objectives to maximise
+// are negated.
+func ReadFront(path string) ([]problem.Objective, Front, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("%s: missing header", path)
+	}
+
+	objectives := make([]problem.Objective, 0)
+	columns := make([]int, 0)
+	for i, name := range records[0] {
+		if objective, err := problem.GetObjective(name); err == nil {
+			objectives = append(objectives, objective)
+			columns = append(columns, i)
+		}
+	}
+	if len(objectives) < 2 {
+		return nil, nil, fmt.Errorf("%s: found %d objective columns, need at least 2", path, len(objectives))
+	}
+
+	front := make(Front, 0, len(records)-1)
+	for line, record := range records[1:] {
+		values := make([]float64, len(columns))
+		for i, column := range columns {
+			values[i], err = strconv.ParseFloat(record[column], 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s:%d: %s: %v", path, line+2, objectives[i].Name, err)
+			}
+		}
+		front = append(front, Minimised(objectives, values))
+	}
+
+	return objectives, front, nil
+}
+
+// Minimised turns values of the given objectives, in natural units, into a point by negating the
+// objectives to maximise.
+func Minimised(objectives []problem.Objective, values []float64) Point {
+	p := slices.Clone(values)
+	for i, objective := range objectives {
+		if objective.Maximise {
+			p[i] = -p[i]
+		}
+	}
+	return p
+}
@@ -0,0 +1,41 @@
+package indicator
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+)
+
+func TestReadFront(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "front.csv")
+	content := "rank,uavs,qos,power\n0,3,1.5,20\n0,4,1.75,30\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	objectives, front, err := ReadFront(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(objectives))
+	for i, objective := range objectives {
+		names[i] = objective.Name
+	}
+	if want := []string{problem.ObjectiveUavs, problem.ObjectiveQoS, problem.ObjectivePower}; !slices.Equal(names, want) {
+		t.Errorf("objectives = %v, want %v", names, want)
+	}
+	want := Front{{3, -1.5, 20}, {4, -1.75, 30}}
+	if !slices.EqualFunc(front, want, func(a, b Point) bool { return slices.Equal(a, b) }) {
+		t.Errorf("front = %v, want %v", front, want)
+	}
+
+	if err := os.WriteFile(path, []byte("rank,uavs\n0,3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadFront(path); err == nil {
+		t.Error("ReadFront accepted a single objective column")
+	}
+}
@@ -0,0 +1,232 @@
+// Package indicator measures the quality of Pareto fronts. Every point is in minimisation form, so
+// objectives to maximise must be negated first, which ReadFront does for the fronts written by the
+// multi-objective solvers.
+package indicator
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+type Point []float64
+
+type Front []Point
+
+// Dominates tells whether a is no worse than b in every objective and better in at least one.
+func Dominates(a, b Point) bool {
+	better := false
+	for i := range a {
+		if a[i] > b[i] {
+			return false
+		}
+		if a[i] < b[i] {
+			better = true
+		}
+	}
+	return better
+}
+
+// NonDominated returns the distinct points of the given fronts that no other point dominates.
+func NonDominated(fronts ...Front) Front {
+	result := make(Front, 0)
+	for _, front := range fronts {
+		for _, p := range front {
+			if slices.ContainsFunc(result, func(q Point) bool { return Dominates(q, p) || slices.Equal(q, p) }) {
+				continue
+			}
+			result = slices.DeleteFunc(result, func(q Point) bool { return Dominates(p, q) })
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Hypervolume is the volume dominated by front and bounded by ref. Points that do not strictly
+// dominate ref in every objective add nothing.
+func Hypervolume(front Front, ref Point) float64 {
+	points := make(Front, 0, len(front))
+	for _, p := range front {
+		inside := true
+		for i := range p {
+			inside = inside && p[i] < ref[i]
+		}
+		if inside {
+			points = append(points, p)
+		}
+	}
+	points = NonDominated(points)
+	if len(points) == 0 {
+		return 0
+	}
+
+	if len(ref) == 2 {
+		return hypervolume2D(points, ref)
+	}
+	return hypervolumeSlicing(points, ref, len(ref))
+}
+
+// hypervolume2D sweeps the non-dominated points by the first objective, along which the second one
+// decreases.
+func hypervolume2D(points Front, ref Point) float64 {
+	points = slices.Clone(points)
+	slices.SortFunc(points, func(a, b Point) int { return cmp.Compare(a[0], b[0]) })
+
+	volume := 0.0
+	top := ref[1]
+	for _, p := range points {
+		volume += (ref[0] - p[0]) * (top - p[1])
+		top = p[1]
+	}
+	return volume
+}
+
+// hypervolumeSlicing cuts the volume into slabs along objective dims-1, between consecutive values
+// of the points, and adds up the hypervolume of the points below each slab in the remaining
+// objectives. It is exponential in the number of objectives, which is fine for the few this
+// problem has.
+func hypervolumeSlicing(points Front, ref Point, dims int) float64 {
+	if dims == 2 {
+		return hypervolume2D(NonDominated(project(points, 2)), ref[:2])
+	}
+
+	last := dims - 1
+	sorted := slices.Clone(points)
+	slices.SortFunc(sorted, func(a, b Point) int { return cmp.Compare(a[last], b[last]) })
+
+	volume := 0.0
+	for i, p := range sorted {
+		upper := ref[last]
+		if i+1 < len(sorted) {
+			upper = sorted[i+1][last]
+		}
+		if upper <= p[last] {
+			continue
+		}
+		below := NonDominated(project(sorted[:i+1], last))
+		volume += (upper - p[last]) * hypervolumeSlicing(below, ref, last)
+	}
+	return volume
+}
+
+func project(points Front, dims int) Front {
+	projected := make(Front, len(points))
+	for i, p := range points {
+		projected[i] = p[:dims]
+	}
+	return projected
+}
+
+// IGD is the mean distance from each point of reference to its nearest point of front.
+func IGD(front, reference Front) float64 {
+	return meanNearest(front, reference, distance)
+}
+
+// IGDPlus is IGD counting only the objectives where a point of front is worse than the reference
+// point, which makes it weakly Pareto compliant.
+func IGDPlus(front, reference Front) float64 {
+	return meanNearest(front, reference, func(a, z Point) float64 {
+		sum := 0.0
+		for i := range a {
+			d := math.Max(a[i]-z[i], 0)
+			sum += d * d
+		}
+		return math.Sqrt(sum)
+	})
+}
+
+func meanNearest(front, reference Front, dist func(a, z Point) float64) float64 {
+	if len(front) == 0 || len(reference) == 0 {
+		return math.Inf(1)
+	}
+
+	sum := 0.0
+	for _, z := range reference {
+		nearest := math.Inf(1)
+		for _, a := range front {
+			nearest = math.Min(nearest, dist(a, z))
+		}
+		sum += nearest
+	}
+	return sum / float64(len(reference))
+}
+
+// Spread is the generalized spread of Zhou et al., which extends Deb's Δ to any number of
+// objectives: how far front is from the extremes of reference, the points best in each objective,
+// plus how unevenly its points are spaced, relative to the mean spacing. 0 is a perfectly even
+// front reaching the extremes.
+func Spread(front, reference Front) float64 {
+	if len(front) < 2 {
+		return math.Inf(1)
+	}
+
+	extremes := 0.0
+	for m := range reference[0] {
+		extreme := reference[0]
+		for _, z := range reference {
+			if z[m] < extreme[m] || (z[m] == extreme[m] && slices.Compare(z, extreme) < 0) {
+				extreme = z
+			}
+		}
+		extremes += nearestDistance(extreme, front, -1)
+	}
+
+	spacing := make([]float64, len(front))
+	mean := 0.0
+	for i, p := range front {
+		spacing[i] = nearestDistance(p, front, i)
+		mean += spacing[i]
+	}
+	mean /= float64(len(front))
+
+	deviation := 0.0
+	for _, d := range spacing {
+		deviation += math.Abs(d - mean)
+	}
+
+	if extremes+float64(len(front))*mean == 0 {
+		return 0
+	}
+	return (extremes + deviation) / (extremes + float64(len(front))*mean)
+}
+
+// nearestDistance is the distance from p to the nearest point of front other than front[skip].
+func nearestDistance(p Point, front Front, skip int) float64 {
+	nearest := math.Inf(1)
+	for i, q := range front {
+		if i != skip {
+			nearest = math.Min(nearest, distance(p, q))
+		}
+	}
+	return nearest
+}
+
+// Epsilon is the additive epsilon indicator: the smallest amount by which front must be shifted
+// in every objective to weakly dominate reference.
+func Epsilon(front, reference Front) float64 {
+	if len(front) == 0 {
+		return math.Inf(1)
+	}
+
+	epsilon := math.Inf(-1)
+	for _, z := range reference {
+		best := math.Inf(1)
+		for _, a := range front {
+			shift := math.Inf(-1)
+			for i := range a {
+				shift = math.Max(shift, a[i]-z[i])
+			}
+			best = math.Min(best, shift)
+		}
+		epsilon = math.Max(epsilon, best)
+	}
+	return epsilon
+}
+
+func distance(a, b Point) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return math.Sqrt(sum)
+}
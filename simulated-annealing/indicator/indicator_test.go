@@ -0,0 +1,77 @@
+package indicator
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestDominates(t *testing.T) {
+	tests := []struct {
+		a, b Point
+		want bool
+	}{
+		{Point{1, 2}, Point{2, 3}, true},
+		{Point{1, 3}, Point{2, 3}, true},
+		{Point{2, 3}, Point{2, 3}, false},
+		{Point{1, 4}, Point{2, 3}, false},
+	}
+	for _, test := range tests {
+		if got := Dominates(test.a, test.b); got != test.want {
+			t.Errorf("Dominates(%v, %v) = %t, want %t", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestNonDominated(t *testing.T) {
+	got := NonDominated(Front{{3, 3}, {1, 5}, {2, 3}}, Front{{2, 3}, {4, 1}, {4, 4}})
+	slices.SortFunc(got, func(a, b Point) int { return slices.Compare(a, b) })
+	want := Front{{1, 5}, {2, 3}, {4, 1}}
+	if !slices.EqualFunc(got, want, func(a, b Point) bool { return slices.Equal(a, b) }) {
+		t.Errorf("NonDominated = %v, want %v", got, want)
+	}
+}
+
+func TestHypervolume(t *testing.T) {
+	tests := []struct {
+		name  string
+		front Front
+		ref   Point
+		want  float64
+	}{
+		{"2D", Front{{1, 5}, {2, 3}, {4, 1}}, Point{5, 6}, 12},
+		{"2D with dominated and outside points", Front{{1, 5}, {2, 3}, {4, 1}, {3, 4}, {6, 0}}, Point{5, 6}, 12},
+		{"2D single point", Front{{1, 1}}, Point{3, 4}, 6},
+		{"3D", Front{{1, 5, 3}, {2, 3, 2}, {4, 1, 1}}, Point{5, 6, 4}, 28},
+		{"3D single point", Front{{1, 1, 1}}, Point{2, 3, 4}, 6},
+		{"empty", Front{}, Point{5, 6}, 0},
+	}
+	for _, test := range tests {
+		if got := Hypervolume(test.front, test.ref); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: Hypervolume = %f, want %f", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDistanceIndicators(t *testing.T) {
+	even := Front{{0, 2}, {1, 1}, {2, 0}}
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"IGD", IGD(Front{{0, 0}}, Front{{3, 4}}), 5},
+		{"IGD mean", IGD(Front{{0, 0}, {3, 4}}, Front{{0, 1}, {3, 4}}), 0.5},
+		{"IGD+", IGDPlus(Front{{1, 1}}, Front{{0, 2}}), 1},
+		{"IGD+ dominating", IGDPlus(Front{{0, 0}}, Front{{1, 1}}), 0},
+		{"Epsilon", Epsilon(Front{{1, 2}}, Front{{0, 0}}), 2},
+		{"Epsilon dominating", Epsilon(Front{{0, 0}}, Front{{1, 1}}), -1},
+		{"Spread even", Spread(even, even), 0},
+		{"IGD empty", IGD(Front{}, even), math.Inf(1)},
+	}
+	for _, test := range tests {
+		if test.got != test.want && math.Abs(test.got-test.want) > 1e-9 {
+			t.Errorf("%s = %f, want %f", test.name, test.got, test.want)
+		}
+	}
+}
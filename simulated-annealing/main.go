@@ -1,220 +1,580 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
-	"sync"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
-	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/gateway"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/experiment"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/indicator"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/solver"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/stats"
 )
 
-var mainWG = &sync.WaitGroup{}
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\n", filepath.Base(os.Args[0]))
+	fmt.Fprintf(os.Stderr, "commands:\n")
+	fmt.Fprintf(os.Stderr, "  sa          solve with simulated annealing\n")
+	fmt.Fprintf(os.Stderr, "  ts          solve with tabu search\n")
+	fmt.Fprintf(os.Stderr, "  ga          solve with the genetic algorithm\n")
+	fmt.Fprintf(os.Stderr, "  grasp       solve with GRASP\n")
+	fmt.Fprintf(os.Stderr, "  bb          solve exactly with branch-and-bound (small instances)\n")
+	fmt.Fprintf(os.Stderr, "  pso         solve with binary particle swarm optimization\n")
+	fmt.Fprintf(os.Stderr, "  vns         solve with variable neighbourhood search\n")
+	fmt.Fprintf(os.Stderr, "  aco         solve with ant colony optimization\n")
+	fmt.Fprintf(os.Stderr, "  nsga2       find the Pareto front of several objectives with NSGA-II\n")
+	fmt.Fprintf(os.Stderr, "  run         run an experiment described by a config file\n")
+	fmt.Fprintf(os.Stderr, "  batch       run a grid of experiments with replications in parallel\n")
+	fmt.Fprintf(os.Stderr, "  tune        tune the parameters of a solver by iterated racing\n")
+	fmt.Fprintf(os.Stderr, "  validate    check a solution file against an instance\n")
+	fmt.Fprintf(os.Stderr, "  export      write the exact MILP model of an instance as LP or MPS\n")
+	fmt.Fprintf(os.Stderr, "  indicators  compare Pareto front files with quality indicators\n")
+	fmt.Fprintf(os.Stderr, "  analyze     compare the solvers of batch results with statistical tests\n")
+	fmt.Fprintf(os.Stderr, "\nrun '%s <command> -h' for the flags of a command\n", filepath.Base(os.Args[0]))
+}
 
 func main() {
-	// ---------- Parse Arguments
-	args := os.Args[1:]
-	seed := args[0]
-	numDevices := args[1]
-	numGateways := args[2]
-	// prefix := args[3]
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, args := os.Args[1], os.Args[2:]
+
+	// an interrupt stops the running solvers, which still report and save their best solution
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch cmd {
+	case experiment.SolverSA, experiment.SolverTS, experiment.SolverGA, experiment.SolverGRASP, experiment.SolverBB, experiment.SolverPSO, experiment.SolverVNS, experiment.SolverACO, experiment.SolverNSGA2:
+		err = runSolver(ctx, cmd, args)
+	case "run":
+		err = runConfig(ctx, args)
+	case "batch":
+		err = runBatch(ctx, args)
+	case "tune":
+		err = runTune(ctx, args)
+	case "validate":
+		err = runValidate(args)
+	case "export":
+		err = runExport(args)
+	case "indicators":
+		err = runIndicators(args)
+	case "analyze":
+		err = runAnalyze(args)
+	case "help", "-h", "-help", "--help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", cmd)
+		usage()
+		os.Exit(2)
+	}
 
-	// ---------- Files path names
-	cwd, err := os.Getwd()
 	if err != nil {
-		panic(err)
+		stop()
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
+}
 
-	devicePositionFile := cwd + "/data/endDevices_LNM_Placement_" + seed + "s+" + numDevices + "d.dat"
-	sliceAssociationFile := cwd + "/data/skl_" + seed + "s_" + numGateways + "x1Gv_" + numDevices + "D.dat"
-	gatewayPositionFile := cwd + "/data/equidistantPlacement_" + numGateways + ".dat"
+func runSolver(ctx context.Context, name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
 
-	// ---------- Load Data
-	deviceList := device.ReadDeviceList(devicePositionFile, sliceAssociationFile)
-	fmt.Printf("Successfully loaded %d devices\n", deviceList.Count())
+	cfg := experiment.DefaultConfig()
+	cfg.Solver = experiment.DefaultSolverConfig(name)
+	bindInstanceFlags(fs, &cfg.Instance)
+	bindSolverFlags(fs, &cfg.Solver)
 
-	candidatePosList := gateway.ReadCandidatePositionList(gatewayPositionFile)
-	fmt.Printf("Successfully loaded %d candidate positions\n", candidatePosList.Count())
+	fs.StringVar(&cfg.Output.Dir, "output", cfg.Output.Dir, "directory where results are written")
+	fs.StringVar(&cfg.Output.Prefix, "prefix", strings.ToUpper(name), "prefix of the result file names")
+	fs.DurationVar((*time.Duration)(&cfg.Output.Progress), "progress", time.Duration(cfg.Output.Progress), "interval between progress updates, 0 disables them")
+	fs.Int64Var(&cfg.RngSeed, "rng-seed", 0, "seed of the random number generator, 0 picks one from the clock")
+	fs.StringVar(&cfg.WarmStart, "warm-start", "", "solution file (.json or .csv) of a previous run to start from")
+	fs.Float64Var(&cfg.Bound, "bound", 0, "lower bound on the optimal cost used to report the gap, 0 disables it")
 
-	gw := &gateway.Gateway{}
-	gw.SetSensitivity(map[int16]float32{7: -130.0, 8: -132.5, 9: -135.0, 10: -137.5, 11: -140.0, 12: -142.5})
-	for slice := range deviceList.Slices() {
-		gw.AddSlice(int32(slice), 125000.0, 15197.75390625)
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
-
-	// ---------- Create problem instance
-	instance, err := problem.CreateUAVProblemInstance(100.0, 1.0, 0.0, 0.0, deviceList, candidatePosList, gw)
-	if err != nil {
-		panic(err)
+	if err := checkRequired(fs, "devices", "slices", "positions"); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
 	}
 
-	// ---------- Solve problem
-	//SASolve(instance)
-	//TSSolve(instance, seed, numDevices, numGateways, prefix)
+	return runExperiment(ctx, cfg)
+}
 
-	//GRASPSolve(deviceList, candidatePosList, gw, seed, strconv.Itoa(numDevs), numGateways)
+func runConfig(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	configFile := fs.String("config", "", "experiment config file (.json, .yaml or .yml)")
+	validateOnly := fs.Bool("validate-only", false, "validate the config and exit without solving")
 
-	//for i := 0; i < 30; i++ {
-	//	//prefix := fmt.Sprintf("random%d", i)
-	//	iterations := 25000
-	//	population := 50
-	//	//crossRate := 0.1
-	//	mutationRate := 0.0001
-	//
-	//	GASolve(instance, iterations, population, 0.7, mutationRate, fmt.Sprintf("ga+grasp%d", i), seed, numGateways, numDevices)
-	//}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkRequired(fs, "config"); err != nil {
+		return err
+	}
 
-	filePrefix := "GA"
-	iterations := 15000
-	population := 50
-	crossRate := 0.6
-	mutationRate := 0.0001
+	cfg, err := experiment.LoadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config %s:\n%v", *configFile, err)
+	}
 
-	GASolve(instance, iterations, population, crossRate, mutationRate, filePrefix, seed, numGateways, numDevices)
+	if *validateOnly {
+		fmt.Printf("%s is valid\n", *configFile)
+		return nil
+	}
 
+	return runExperiment(ctx, cfg)
 }
 
-func GASolve(instance *problem.UAVProblem, it, population int, crossRate, mutationRate float64, prefix, seed, numGateways, numDevices string) {
-	cwd, err := os.Getwd()
-	if err != nil {
-		panic(err)
+func runBatch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	configFile := fs.String("config", "", "batch config file (.json, .yaml or .yml)")
+	workers := fs.Int("workers", 0, "number of runs executed in parallel, overrides the config file")
+	output := fs.String("output", "", "CSV file receiving one row per run, overrides the config file")
+	rngSeed := fs.Int64("rng-seed", 0, "base seed of the per run random number generators, overrides the config file")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkRequired(fs, "config"); err != nil {
+		return err
 	}
 
-	//logFile := cwd + "/output/" + prefix + "_perf_solutions.dat"
-	//file, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//defer file.Close()
+	cfg, err := experiment.LoadBatchConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if *workers > 0 {
+		cfg.Workers = *workers
+	}
+	if *output != "" {
+		cfg.Output = *output
+	}
+	if *rngSeed != 0 {
+		cfg.RngSeed = *rngSeed
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config %s:\n%v", *configFile, err)
+	}
 
-	// file.WriteString("seed,numVirtualPositions,numDevices,numGens,objective\n")
-	s := solver.CreateGASolver(instance, it, population, 100, crossRate, mutationRate)
-	s.Solve()
+	if err := os.MkdirAll(filepath.Dir(cfg.Output), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(cfg.Output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
 
-	//file.WriteString(fmt.Sprintf("%s,%s,%s,%d,%f,%f\n", seed, numGateways, numDevices, s.GetCurrentIteration(), s.GetBestCostTime(), sol.GetCost()))
+	fmt.Printf("Running %d runs on %d workers, results in %s\n", len(cfg.Runs()), cfg.Workers, cfg.Output)
+	return experiment.RunBatch(ctx, cfg, file)
+}
 
-	// ---------- Save Result
+func runTune(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("tune", flag.ExitOnError)
+	configFile := fs.String("config", "", "tuning config file (.json, .yaml or .yml)")
+	workers := fs.Int("workers", 0, "number of runs executed in parallel, overrides the config file")
+	output := fs.String("output", "", "CSV file receiving one row per run, overrides the config file")
+	rngSeed := fs.Int64("rng-seed", 0, "seed of the sampling and of the per run random number generators, overrides the config file")
 
-	logFile := cwd + "/output/" + prefix + "_log_" + seed + "s_" + numGateways + "g_" + numDevices + "d.dat"
-	placementFile := cwd + "/output/" + prefix + "_Placement_" + seed + "s_" + numGateways + "x1Gv_" + numDevices + "D.dat"
-	configurationFile := cwd + "/output/" + prefix + "_DevicesConfigurations_" + seed + "s_" + numGateways + "x1Gv_" + numDevices + "D.dat"
-	ExportResults(s, instance, logFile, placementFile, configurationFile)
-}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkRequired(fs, "config"); err != nil {
+		return err
+	}
 
-func GRASPSolve(deviceList *device.DeviceList, candidatePosList *gateway.CandidatePositionList, gw *gateway.Gateway, seed, numDevices, numGateways string) {
-	cwd, err := os.Getwd()
+	cfg, err := experiment.LoadTuneConfig(*configFile)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	if *workers > 0 {
+		cfg.Workers = *workers
+	}
+	if *output != "" {
+		cfg.Output = *output
+	}
+	if *rngSeed != 0 {
+		cfg.RngSeed = *rngSeed
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config %s:\n%v", *configFile, err)
 	}
 
-	logFile := cwd + "/output/GRASP_perf_solutions.dat"
-	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err := os.MkdirAll(filepath.Dir(cfg.Output), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(cfg.Output)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	defer file.Close()
 
-	for i := 0; i < 30; i++ {
-		instance, err := problem.CreateUAVProblemInstance(100.0, 1.0, 0.75, 0.001, deviceList, candidatePosList, gw)
-		if err != nil {
-			panic(err)
+	fmt.Printf("Tuning %s with %d runs on %d workers, runs in %s\n", cfg.Solver.GetLabel(), cfg.MaxRuns, cfg.Workers, cfg.Output)
+	elites, err := experiment.Tune(ctx, cfg, file)
+	if len(elites) > 0 {
+		fmt.Printf("\nElite configurations:\n")
+		for i, elite := range elites {
+			fmt.Printf("%d. candidate %d, mean cost %f over %d runs: %s\n", i+1, elite.ID, elite.MeanCost, elite.Runs, elite.Params)
 		}
+		fmt.Printf("\nBest %s parameters: %s\n", elites[0].Solver.Name, elites[0].Solver.Params())
+	}
+	return err
+}
 
-		s := solver.CreateGRASPSolver(instance)
-		sol := s.Solve()
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	instanceCfg := experiment.DefaultInstanceConfig()
+	bindInstanceFlags(fs, &instanceCfg)
+	configFile := fs.String("config", "", "experiment config file whose instance is used instead of the instance flags")
+	solutionFile := fs.String("solution", "", "solution file (.json or .csv) to validate")
 
-		file.WriteString(fmt.Sprintf("%s,%s,%s,%d,%f,%d,%f\n", seed, numGateways, numDevices, s.GetCurrentIteration(), s.GetBestCostTime(), len(sol.GetDeployedUavs()), sol.GetCost()))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkRequired(fs, "solution"); err != nil {
+		return err
 	}
 
-	// ---------- Save Result
+	instance, err := loadInstance(fs, instanceCfg, *configFile)
+	if err != nil {
+		return err
+	}
 
-	// prefix := fmt.Sprintf("grasp%d", i)
+	record, err := problem.ReadSolutionFile(*solutionFile)
+	if err != nil {
+		return err
+	}
+	if fingerprint := instance.Fingerprint(); record.Fingerprint != fingerprint {
+		fmt.Printf("warning: solution fingerprint %s does not match the instance %s\n", record.Fingerprint, fingerprint)
+	}
 
-	// logFile := cwd + "/output/" + prefix + "_log_" + seed + "s_" + numGateways + "g_" + numDevices + "d.dat"
-	// placementFile := cwd + "/output/" + prefix + "_Placement_" + seed + "s_" + numGateways + "x1Gv_" + numDevices + "D.dat"
-	// configurationFile := cwd + "/output/" + prefix + "_DevicesConfigurations_" + seed + "s_" + numGateways + "x1Gv_" + numDevices + "D.dat"
-	// ExportResults(s, instance, logFile, placementFile, configurationFile)
-	// mainWG.Done()
-}
+	sol, err := instance.BuildSolution(record)
+	if err != nil {
+		return fmt.Errorf("%s: %v", *solutionFile, err)
+	}
 
-func TSSolve(instance *problem.UAVProblem, seed, numDevices, numGateways, prefix string) {
-	maxIterations := 150000
-	tabuListSize := 40
-	batchSize := 20
-	maxIterationsWithoutEnhancement := 10000
-	tabuUavRatio := float32(0.25)
+	report := instance.Validate(sol)
+	fmt.Print(report)
+	if !report.Valid() {
+		return fmt.Errorf("%s is not a valid solution", *solutionFile)
+	}
+	return nil
+}
 
-	s := solver.CreateTSSolver(maxIterations, batchSize, tabuListSize, maxIterationsWithoutEnhancement, tabuUavRatio, instance)
-	s.Solve()
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	instanceCfg := experiment.DefaultInstanceConfig()
+	bindInstanceFlags(fs, &instanceCfg)
+	configFile := fs.String("config", "", "experiment config file whose instance is used instead of the instance flags")
+	lpFile := fs.String("lp", "", "file receiving the model in CPLEX LP format")
+	mpsFile := fs.String("mps", "", "file receiving the model in free MPS format")
 
-	// ---------- Save Result
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *lpFile == "" && *mpsFile == "" {
+		return fmt.Errorf("missing required flag -lp or -mps")
+	}
 
-	cwd, err := os.Getwd()
+	instance, err := loadInstance(fs, instanceCfg, *configFile)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	logFile := cwd + "/output/" + prefix + "_log_" + seed + "s_" + numGateways + "g_" + numDevices + "d.dat"
-	placementFile := cwd + "/output/" + prefix + "_Placement_" + seed + "s_" + numGateways + "x1Gv_" + numDevices + "D.dat"
-	configurationFile := cwd + "/output/" + prefix + "_DevicesConfigurations_" + seed + "s_" + numGateways + "x1Gv_" + numDevices + "D.dat"
-	ExportResults(s, instance, logFile, placementFile, configurationFile)
+	model := instance.BuildMILP()
+	fmt.Printf("Model has %d variables and %d constraints\n", len(model.Variables), len(model.Constraints))
+	for _, path := range []string{*lpFile, *mpsFile} {
+		if path == "" {
+			continue
+		}
+		if err := model.WriteFile(path); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+	return nil
 }
 
-func SASolve(instance *problem.UAVProblem) {
-	initialTemp := 250.0
-	coolingRate := 0.99985
-	iterationsPerTemp := 20
-	maxIterations := 1000000
-	minDistance := 5
-	maxDistance := 50
+func runIndicators(args []string) error {
+	fs := flag.NewFlagSet("indicators", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s indicators -ref value,... [flags] front.csv...\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	var ref []float64
+	fs.Var(float64ListFlag{&ref}, "ref", "hypervolume reference point, one value per objective column in natural units")
+	referenceFile := fs.String("reference", "", "reference front file for IGD, IGD+, spread and epsilon, the non-dominated union of the fronts by default")
 
-	s := solver.CreateSASolver(initialTemp, coolingRate, iterationsPerTemp, maxIterations, minDistance, maxDistance, instance)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(ref) == 0 {
+		return fmt.Errorf("missing required flag -ref")
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("no front files given")
+	}
 
-	start := time.Now()
-	s.Solve()
-	end := time.Now()
+	var objectives []problem.Objective
+	fronts := make([]indicator.Front, fs.NArg())
+	for i, path := range fs.Args() {
+		frontObjectives, front, err := indicator.ReadFront(path)
+		if err != nil {
+			return err
+		}
+		if objectives == nil {
+			objectives = frontObjectives
+		} else if !sameObjectives(objectives, frontObjectives) {
+			return fmt.Errorf("%s: objectives differ from %s", path, fs.Arg(0))
+		}
+		fronts[i] = front
+	}
+	if len(ref) != len(objectives) {
+		return fmt.Errorf("-ref has %d values for %d objectives", len(ref), len(objectives))
+	}
 
-	fmt.Printf("Solving time: %v\n", end.Sub(start))
+	reference := indicator.NonDominated(fronts...)
+	if *referenceFile != "" {
+		referenceObjectives, front, err := indicator.ReadFront(*referenceFile)
+		if err != nil {
+			return err
+		}
+		if !sameObjectives(objectives, referenceObjectives) {
+			return fmt.Errorf("%s: objectives differ from %s", *referenceFile, fs.Arg(0))
+		}
+		reference = indicator.NonDominated(front)
+	}
+	refPoint := indicator.Minimised(objectives, ref)
+
+	fmt.Printf("%-40s %6s %14s %12s %12s %10s %12s\n", "front", "points", "hypervolume", "igd", "igd+", "spread", "epsilon")
+	for i, front := range fronts {
+		front = indicator.NonDominated(front)
+		fmt.Printf("%-40s %6d %14.6g %12.6g %12.6g %10.4f %12.6g\n", fs.Arg(i), len(front),
+			indicator.Hypervolume(front, refPoint), indicator.IGD(front, reference), indicator.IGDPlus(front, reference),
+			indicator.Spread(front, reference), indicator.Epsilon(front, reference))
+	}
+	return nil
+}
 
-	// ---------- Save Result
+func runAnalyze(args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s analyze [flags] batch.csv...\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	cfg := experiment.DefaultAnalysisConfig()
+	fs.StringVar(&cfg.Metric, "metric", cfg.Metric, "column of the batch results compared, lower being better")
+	fs.Float64Var(&cfg.Confidence, "confidence", cfg.Confidence, "confidence level of the intervals of the mean")
+	fs.Float64Var(&cfg.Alpha, "alpha", cfg.Alpha, "significance level of the tests, 0.05 or 0.1")
+	format := fs.String("format", stats.FormatMarkdown, "table format: "+strings.Join(stats.Formats, ", "))
+	output := fs.String("output", "", "file receiving the tables, stdout by default")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("no batch results files given")
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	rows, err := experiment.ReadBatchResults(fs.Args()...)
+	if err != nil {
+		return err
+	}
+	tables, err := experiment.Analyze(rows, cfg)
+	if err != nil {
+		return err
+	}
 
-	//logFile := cwd + "/output/" + prefix + "_log_" + seed + "s_" + numGateways + "g_" + numDevices + "d.dat"
-	//placementFile := cwd + "/output/" + prefix + "_Placement_" + seed + "s_" + numGateways + "x1Gv_" + numDevices + "D.dat"
-	//configurationFile := cwd + "/output/" + prefix + "_DevicesConfigurations_" + seed + "s_" + numGateways + "x1Gv_" + numDevices + "D.dat"
-	//ExportResults(s, instance, logFile, placementFile, configurationFile)
+	rendered := make([]string, len(tables))
+	for i, table := range tables {
+		if rendered[i], err = table.Render(*format); err != nil {
+			return err
+		}
+	}
+	text := strings.Join(rendered, "\n")
+
+	if *output == "" {
+		fmt.Print(text)
+		return nil
+	}
+	return os.WriteFile(*output, []byte(text), 0644)
 }
 
-func ExportResults(s solver.Solver, instance *problem.UAVProblem, logFile, placementFile, configurationFile string) {
-	// Log
-	file, err := os.Create(logFile)
-	if err != nil {
-		panic(err)
+func sameObjectives(a, b []problem.Objective) bool {
+	return slices.EqualFunc(a, b, func(x, y problem.Objective) bool { return x.Name == y.Name })
+}
+
+// loadInstance loads the instance of configFile when one is given, and the one described by the
+// instance flags otherwise.
+func loadInstance(fs *flag.FlagSet, instanceCfg experiment.InstanceConfig, configFile string) (*problem.UAVProblem, error) {
+	if configFile != "" {
+		cfg, err := experiment.LoadConfig(configFile)
+		if err != nil {
+			return nil, err
+		}
+		instanceCfg = cfg.Instance
+	} else if err := checkRequired(fs, "devices", "slices", "positions"); err != nil {
+		return nil, err
+	}
+	if err := instanceCfg.Validate(); err != nil {
+		return nil, err
 	}
 
-	_, err = file.WriteString(s.GetLog())
+	return instanceCfg.Load()
+}
+
+func runExperiment(ctx context.Context, cfg experiment.Config) error {
+	instance, err := cfg.Instance.Load()
 	if err != nil {
-		panic(err)
+		return err
+	}
+	fmt.Printf("Successfully loaded %d devices\n", len(instance.GetDeviceIds()))
+	fmt.Printf("Successfully loaded %d candidate positions\n", len(instance.GetUAVIds()))
+	fmt.Printf("Path loss: %s\n", instance.GetPathLoss())
+	fmt.Printf("Feasible links: %d, %d link profiles\n", instance.GetLinkBudget().NumLinks(), instance.GetLinkBudget().NumProfiles())
+
+	rng, seed := experiment.NewRand(cfg.RngSeed)
+	instance.SetRand(rng)
+	fmt.Printf("RNG seed: %d\n", seed)
+
+	var s solver.Solver
+	var continuous *problem.ContinuousProblem
+	if cfg.Instance.Continuous.Enabled {
+		continuous = cfg.Instance.Continuous.Create(instance)
+		area := continuous.GetArea()
+		fmt.Printf("Continuous positioning in (%g, %g, %g)-(%g, %g, %g)\n", area.Min.X, area.Min.Y, area.Min.Z, area.Max.X, area.Max.Y, area.Max.Z)
+		s, err = cfg.Solver.CreateContinuous(continuous)
+	} else {
+		s, err = cfg.Solver.Create(instance)
+	}
+	if err != nil {
+		return err
 	}
 
-	err = file.Sync()
+	if cfg.WarmStart != "" {
+		record, err := problem.ReadSolutionFile(cfg.WarmStart)
+		if err != nil {
+			return err
+		}
+		sol, err := instance.LoadSolution(record)
+		if err != nil {
+			return fmt.Errorf("%s: %v", cfg.WarmStart, err)
+		}
+		s.WarmStart(sol)
+		fmt.Printf("Warm start from %s (cost %f)\n", cfg.WarmStart, sol.GetCost())
+	}
+
+	// ---------- Stream the iteration log
+	if err := os.MkdirAll(cfg.Output.Dir, 0755); err != nil {
+		return err
+	}
+
+	logFile, err := os.Create(filepath.Join(cfg.Output.Dir, cfg.Output.Prefix+"_log.dat"))
 	if err != nil {
-		panic(err)
+		return err
 	}
-	err = file.Close()
+	defer logFile.Close()
+
+	var progress solver.Observer
+	if cfg.Output.Progress > 0 {
+		progress = solver.CreateProgressObserver(os.Stdout, time.Duration(cfg.Output.Progress), time.Duration(cfg.Solver.Budget.WallTime))
+	}
+	observer := solver.Observers(solver.CreateCSVObserver(logFile), progress)
+	s.SetObserver(observer)
+
+	result := s.Solve(ctx)
+	if err := solver.Flush(observer); err != nil {
+		return err
+	}
+	fmt.Printf("Solving time: %v (stopped by %s)\n", result.Elapsed, result.StopReason)
+	fmt.Printf("Best cost: %f (UAVs: %d, found after %v)\n", result.Cost, result.NumUavs, result.TimeToBest)
+	fmt.Printf("Iterations: %d, evaluations: %d\n", result.Iterations, result.Evaluations)
+	if cfg.Bound > 0 {
+		fmt.Printf("Gap to bound %f: %.2f%%\n", cfg.Bound, 100*solver.Gap(result.Cost, cfg.Bound))
+	}
+	if result.Best == nil {
+		return fmt.Errorf("%s found no feasible solution", cfg.Solver.Name)
+	}
+	validate := instance.Validate
+	if continuous != nil {
+		validate = continuous.Validate
+	}
+	if report := validate(result.Best); !report.Valid() {
+		fmt.Fprintf(os.Stderr, "Warning: the best solution does not pass validation\n%s", report)
+	}
+
+	// ---------- Save Result
+	placementFile := filepath.Join(cfg.Output.Dir, cfg.Output.Prefix+"_Placement.dat")
+	configurationFile := filepath.Join(cfg.Output.Dir, cfg.Output.Prefix+"_DevicesConfigurations.dat")
+	ExportResults(result.Best.(placement), placementFile, configurationFile)
+
+	// solution files refer to candidate positions, so continuous solutions only have the placement
+	if sol, ok := result.Best.(*problem.UAVSolution); ok {
+		record := sol.Record()
+		for _, ext := range []string{".json", ".csv"} {
+			if err := record.WriteFile(filepath.Join(cfg.Output.Dir, cfg.Output.Prefix+"_Solution"+ext)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if frontSolver, ok := s.(solver.FrontSolver); ok {
+		frontPath := filepath.Join(cfg.Output.Dir, cfg.Output.Prefix+"_Front.csv")
+		if err := writeFront(frontSolver, frontPath); err != nil {
+			return err
+		}
+		fmt.Printf("Front: %d non-dominated solutions written to %s\n", len(frontSolver.Front()), frontPath)
+	}
+
+	return logFile.Close()
+}
+
+func writeFront(frontSolver solver.FrontSolver, path string) error {
+	file, err := os.Create(path)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	defer file.Close()
+
+	if err := frontSolver.WriteFront(file); err != nil {
+		return err
 	}
+	return file.Close()
+}
+
+type placement interface {
+	OutputGatewayPositions() string
+	OutputConfigurations() string
+}
 
+func ExportResults(solution placement, placementFile, configurationFile string) {
 	// Gateway Placement
-	file, err = os.Create(placementFile)
+	file, err := os.Create(placementFile)
 	if err != nil {
 		panic(err)
 	}
 
-	_, err = file.WriteString(instance.GetBestSolution().(*problem.UAVSolution).OutputGatewayPositions())
+	_, err = file.WriteString(solution.OutputGatewayPositions())
 	if err != nil {
 		panic(err)
 	}
@@ -234,7 +594,7 @@ func ExportResults(s solver.Solver, instance *problem.UAVProblem, logFile, place
 		panic(err)
 	}
 
-	_, err = file.WriteString(instance.GetBestSolution().(*problem.UAVSolution).OutputConfigurations())
+	_, err = file.WriteString(solution.OutputConfigurations())
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,744 @@
+package problem
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"sync/atomic"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/utils"
+)
+
+const (
+	// DirectionPosition moves a whole UAV, DeviceId being the first of its devices
+	DirectionPosition int = 4
+
+	// Chances of the continuous neighbour moves shifting a UAV and closing one. The remaining moves
+	// reassign a single device, following the changeUav and newUavChance of the instance.
+	continuousShiftChance = 0.4
+	continuousCloseChance = 0.1
+)
+
+// Area is the box UAVs may occupy in continuous mode. A flat box, with Min.Z equal to Max.Z, keeps
+// every UAV at that altitude.
+type Area struct {
+	Min utils.Position
+	Max utils.Position
+}
+
+func (area Area) Contains(pos utils.Position) bool {
+	return pos.X >= area.Min.X && pos.X <= area.Max.X &&
+		pos.Y >= area.Min.Y && pos.Y <= area.Max.Y &&
+		pos.Z >= area.Min.Z && pos.Z <= area.Max.Z
+}
+
+func (area Area) Clamp(pos utils.Position) utils.Position {
+	return utils.Position{
+		X: min(max(pos.X, area.Min.X), area.Max.X),
+		Y: min(max(pos.Y, area.Min.Y), area.Max.Y),
+		Z: min(max(pos.Z, area.Min.Z), area.Max.Z),
+	}
+}
+
+// GetArea is the bounding box of the candidate positions.
+func (problem *UAVProblem) GetArea() Area {
+	ids := problem.uavPositions.GetCandidatePositionIdList()
+	first := problem.uavPositions.GetCandidatePosition(ids[0])
+	area := Area{first, first}
+	for _, uavId := range ids {
+		pos := problem.uavPositions.GetCandidatePosition(uavId)
+		area.Min = utils.Position{X: min(area.Min.X, pos.X), Y: min(area.Min.Y, pos.Y), Z: min(area.Min.Z, pos.Z)}
+		area.Max = utils.Position{X: max(area.Max.X, pos.X), Y: max(area.Max.Y, pos.Y), Z: max(area.Max.Z, pos.Z)}
+	}
+	return area
+}
+
+// ContinuousProblem places UAVs anywhere in an area instead of on the candidate positions of the
+// underlying instance, which only seed the initial solutions. There is one UAV slot per device,
+// enough for any solution, and the UAV ids of its solutions are slot indices.
+//
+// Reach checks are incremental: the link budget of every device and configuration meeting the QoS
+// bound is computed once, so moving a UAV costs one path loss evaluation per device it serves.
+type ContinuousProblem struct {
+	base            *UAVProblem
+	area            Area
+	step            float64
+	budgets         [][]float64 // budgets[deviceId][configId] -> maximum path loss, -Inf below QoS
+	currentSolution *ContinuousSolution
+	bestSolution    *ContinuousSolution
+}
+
+// CreateContinuousProblem moves UAVs in steps drawn from a normal distribution with a standard
+// deviation of step metres along each free axis of area.
+func CreateContinuousProblem(base *UAVProblem, area Area, step float64) *ContinuousProblem {
+	budgets := make([][]float64, base.devices.Count())
+	for _, deviceId := range base.devices.GetDeviceIds() {
+		budgets[deviceId] = make([]float64, device.GetNumConfigurations())
+		for configId := range budgets[deviceId] {
+			config := base.configurations[int32(configId)]
+			budgets[deviceId][configId] = math.Inf(-1)
+			if base.checkQoSFeasibility(deviceId, int32(configId)) {
+				budgets[deviceId][configId] = float64(config.Tp) - float64(base.gateway.GetSensitivityForSf(config.Sf))
+			}
+		}
+	}
+
+	return &ContinuousProblem{
+		base:    base,
+		area:    area,
+		step:    step,
+		budgets: budgets,
+	}
+}
+
+func (problem *ContinuousProblem) GetArea() Area {
+	return problem.area
+}
+
+func (problem *ContinuousProblem) GetBase() *UAVProblem {
+	return problem.base
+}
+
+// configFor picks the configuration of sf with the lowest transmission power closing a link with
+// the given loss, or any configuration closing it when sf cannot, trying the lowest SF first.
+// Configuration ids grow with the SF and then the transmission power.
+func (problem *ContinuousProblem) configFor(deviceId device.DeviceId, loss float64, sf int16) (int32, bool) {
+	for tp := device.MinTP; tp <= device.MaxTP; tp += device.StepTP {
+		configId := device.GetConfigID(int(sf), tp)
+		if problem.budgets[deviceId][configId] >= loss {
+			return configId, true
+		}
+	}
+	for configId, budget := range problem.budgets[deviceId] {
+		if budget >= loss {
+			return int32(configId), true
+		}
+	}
+	return 0, false
+}
+
+func (problem *ContinuousProblem) reaches(deviceId device.DeviceId, configId int32, loss float64) bool {
+	return problem.budgets[deviceId][configId] >= loss
+}
+
+func (problem *ContinuousProblem) loss(deviceId device.DeviceId, pos utils.Position) float64 {
+	return problem.base.pathLoss.PathLoss(problem.base.devices.GetDevice(deviceId).GetPosition(), pos)
+}
+
+// FromDiscrete places one UAV at the candidate position of every UAV deployed by sol, keeping its
+// associations.
+func (problem *ContinuousProblem) FromDiscrete(sol *UAVSolution) *ContinuousSolution {
+	cont := problem.createEmptySolution()
+	uavs := slices.Clone(sol.deployedUavs)
+	slices.Sort(uavs)
+	for slot, uavId := range uavs {
+		cont.positions[slot] = problem.base.uavPositions.GetCandidatePosition(uavId)
+		for _, deviceId := range sol.GetDevicesAssignedTo(uavId) {
+			cont.assign(deviceId, int32(slot), sol.GetAssignedConfigId(deviceId))
+		}
+	}
+	return cont
+}
+
+func (problem *ContinuousProblem) createEmptySolution() *ContinuousSolution {
+	numDevices := problem.base.devices.Count()
+	sol := &ContinuousSolution{
+		id:                atomic.AddInt64(&globalIdx, 1) - 1,
+		positions:         make([]utils.Position, numDevices),
+		deviceAssociation: make([]uavConfigurationAssociation, numDevices),
+		uavDevices:        make([][]device.DeviceId, numDevices),
+		uavDatarate:       make(map[uavSliceKey]float32),
+		sfCount:           make(map[int16]int32, device.MaxSF-device.MinSF+1),
+		problem:           problem,
+	}
+	for i := range sol.deviceAssociation {
+		sol.deviceAssociation[i] = uavConfigurationAssociation{-1, -1}
+	}
+	return sol
+}
+
+func (problem *ContinuousProblem) GetRandomSolution() (Solution, error) {
+	sol, err := GetRandomUAVSolution(problem.base)
+	if err != nil {
+		return nil, err
+	}
+	return problem.FromDiscrete(sol), nil
+}
+
+func (problem *ContinuousProblem) GetCurrentSolution() Solution {
+	if problem.currentSolution == nil {
+		problem.currentSolution = problem.FromDiscrete(problem.base.GetCurrentSolution().(*UAVSolution))
+	}
+	return problem.currentSolution
+}
+
+// SetCurrentSolution also takes a discrete solution of the underlying instance, such as a warm
+// start, which is converted with FromDiscrete.
+func (problem *ContinuousProblem) SetCurrentSolution(sol Solution) {
+	problem.currentSolution = problem.toContinuous(sol)
+}
+
+func (problem *ContinuousProblem) GetBestSolution() Solution {
+	return problem.bestSolution
+}
+
+func (problem *ContinuousProblem) SetBestSolution(sol Solution) {
+	problem.bestSolution = problem.toContinuous(sol)
+}
+
+func (problem *ContinuousProblem) toContinuous(sol Solution) *ContinuousSolution {
+	if discrete, ok := sol.(*UAVSolution); ok {
+		return problem.FromDiscrete(discrete)
+	}
+	return sol.(*ContinuousSolution)
+}
+
+func (problem *ContinuousProblem) GetDeviceIds() []device.DeviceId {
+	return problem.base.GetDeviceIds()
+}
+
+func (problem *ContinuousProblem) GetUAVIds() []int32 {
+	ids := make([]int32, problem.base.devices.Count())
+	for i := range ids {
+		ids[i] = int32(i)
+	}
+	return ids
+}
+
+// GetPossibleUavs, GetPossibleConfigs and GetCoverage answer for the UAV positions of the current
+// solution.
+func (problem *ContinuousProblem) GetPossibleUavs(deviceId device.DeviceId) []int32 {
+	sol := problem.GetCurrentSolution().(*ContinuousSolution)
+	uavs := make([]int32, 0)
+	for _, slot := range sol.GetDeployedUavs() {
+		if len(problem.GetPossibleConfigs(deviceId, slot)) > 0 {
+			uavs = append(uavs, slot)
+		}
+	}
+	return uavs
+}
+
+func (problem *ContinuousProblem) GetPossibleConfigs(deviceId device.DeviceId, uavId int32) []int32 {
+	sol := problem.GetCurrentSolution().(*ContinuousSolution)
+	return problem.GetPossibleConfigsAt(deviceId, sol.positions[uavId])
+}
+
+// GetPossibleConfigsAt lists the configurations with which deviceId reaches a UAV at pos.
+func (problem *ContinuousProblem) GetPossibleConfigsAt(deviceId device.DeviceId, pos utils.Position) []int32 {
+	loss := problem.loss(deviceId, pos)
+	configs := make([]int32, 0)
+	for configId, budget := range problem.budgets[deviceId] {
+		if budget >= loss {
+			configs = append(configs, int32(configId))
+		}
+	}
+	return configs
+}
+
+func (problem *ContinuousProblem) GetCoverage(uavId int32) []device.DeviceId {
+	coverage := make([]device.DeviceId, 0)
+	for _, deviceId := range problem.GetDeviceIds() {
+		if len(problem.GetPossibleConfigs(deviceId, uavId)) > 0 {
+			coverage = append(coverage, deviceId)
+		}
+	}
+	return coverage
+}
+
+func (problem *ContinuousProblem) GetDatarate(sf int16, slice int32) float32 {
+	return problem.base.GetDatarate(sf, slice)
+}
+
+func (problem *ContinuousProblem) GetMaxDatarate(slice int32) float32 {
+	return problem.base.GetMaxDatarate(slice)
+}
+
+func (problem *ContinuousProblem) GetSlice(deviceId device.DeviceId) int32 {
+	return problem.base.GetSlice(deviceId)
+}
+
+func (problem *ContinuousProblem) GetRand() *rand.Rand {
+	return problem.base.rng
+}
+
+func (problem *ContinuousProblem) SetRand(rng *rand.Rand) {
+	problem.base.SetRand(rng)
+}
+
+func (problem *ContinuousProblem) Copy() Problem {
+	problemCopy := *problem
+	problemCopy.base = problem.base.copy()
+	problemCopy.currentSolution = nil
+	problemCopy.bestSolution = nil
+	return &problemCopy
+}
+
+// Validate re-checks sol like UAVProblem.Validate, with reach computed at the UAV positions of sol,
+// which must lie within the area.
+func (problem *ContinuousProblem) Validate(solution Solution) ValidationReport {
+	report := ValidationReport{ReportedCost: solution.GetCost()}
+	sol, ok := solution.(*ContinuousSolution)
+	if !ok {
+		report.add(ViolationState, "not a continuous solution")
+		return report
+	}
+
+	uavs := make(map[int32]bool)
+	sfCount := make(map[int16]int)
+	datarate := make(map[uavSliceKey]float64)
+	for _, deviceId := range problem.GetDeviceIds() {
+		association := sol.deviceAssociation[deviceId]
+		if association.uavId < 0 {
+			report.add(ViolationUnassigned, "device %d is not assigned to any UAV", deviceId)
+			continue
+		}
+
+		config := problem.base.configurations[association.configId]
+		pos := sol.positions[association.uavId]
+		if !problem.reaches(deviceId, association.configId, problem.loss(deviceId, pos)) {
+			report.add(ViolationReach, "device %d cannot reach UAV %d at (%g, %g, %g) with SF%d/%ddBm", deviceId, association.uavId, pos.X, pos.Y, pos.Z, config.Sf, config.Tp)
+		}
+		if !problem.base.checkQoSFeasibility(deviceId, association.configId) {
+			report.add(ViolationQoS, "device %d has QoS %f with SF%d, the bound is %f", deviceId, problem.base.GetQoS(deviceId, association.configId), config.Sf, QoSBound)
+		}
+
+		slice := problem.GetSlice(deviceId)
+		uavs[association.uavId] = true
+		sfCount[config.Sf]++
+		datarate[uavSliceKey{association.uavId, slice}] += float64(problem.GetDatarate(config.Sf, slice))
+	}
+
+	for slot := range uavs {
+		if pos := sol.positions[slot]; !problem.area.Contains(pos) {
+			report.add(ViolationArea, "UAV %d at (%g, %g, %g) lies outside the area", slot, pos.X, pos.Y, pos.Z)
+		}
+	}
+	for key, load := range datarate {
+		if maxDatarate := float64(problem.GetMaxDatarate(key.slice)); load > maxDatarate*(1+1e-6) {
+			report.add(ViolationCapacity, "UAV %d slice %d carries %f, the maximum is %f", key.uavId, key.slice, load, maxDatarate)
+		}
+	}
+
+	maxSfCount := 0
+	for _, count := range sfCount {
+		maxSfCount = max(maxSfCount, count)
+	}
+	report.NumUavs = len(uavs)
+	report.CostA = float64(len(uavs)) * problem.base.alpha
+	report.CostB = float64(maxSfCount) * problem.base.beta
+	report.Cost = report.CostA + report.CostB
+	if !costEqual(report.Cost, report.ReportedCost) {
+		report.add(ViolationCost, "reported cost %f differs from the recomputed cost %f", report.ReportedCost, report.Cost)
+	}
+	if sol.numDeployed != len(uavs) {
+		report.add(ViolationState, "%d UAVs are counted as deployed, %d are in use", sol.numDeployed, len(uavs))
+	}
+
+	return report
+}
+
+// ContinuousSolution assigns every device to a UAV slot with a real-valued position. The datarate
+// per UAV slice, the device count per SF and the number of deployed UAVs are kept up to date by
+// every move, so costs and capacity checks never scan the whole solution.
+type ContinuousSolution struct {
+	id                int64
+	positions         []utils.Position
+	deviceAssociation []uavConfigurationAssociation
+	uavDevices        [][]device.DeviceId
+	uavDatarate       map[uavSliceKey]float32
+	sfCount           map[int16]int32
+	numDeployed       int
+	generatingMove    Move
+	problem           *ContinuousProblem
+}
+
+func (sol *ContinuousSolution) copy() *ContinuousSolution {
+	uavDevices := make([][]device.DeviceId, len(sol.uavDevices))
+	for slot, devices := range sol.uavDevices {
+		uavDevices[slot] = slices.Clone(devices)
+	}
+
+	return &ContinuousSolution{
+		id:                atomic.AddInt64(&globalIdx, 1) - 1,
+		positions:         slices.Clone(sol.positions),
+		deviceAssociation: slices.Clone(sol.deviceAssociation),
+		uavDevices:        uavDevices,
+		uavDatarate:       maps.Clone(sol.uavDatarate),
+		sfCount:           maps.Clone(sol.sfCount),
+		numDeployed:       sol.numDeployed,
+		problem:           sol.problem,
+	}
+}
+
+func (sol *ContinuousSolution) Copy() Solution {
+	return sol.copy()
+}
+
+// assign moves deviceId to slot with configId, keeping the cached state in step.
+func (sol *ContinuousSolution) assign(deviceId device.DeviceId, slot, configId int32) {
+	base := sol.problem.base
+	slice := base.devices.GetDevice(deviceId).Slice()
+
+	if prev := sol.deviceAssociation[deviceId]; prev.uavId >= 0 {
+		sfPrev := base.configurations[prev.configId].Sf
+		sol.uavDatarate[uavSliceKey{prev.uavId, slice}] -= base.gateway.GetDatarate(sfPrev, slice)
+		sol.sfCount[sfPrev]--
+
+		devices := sol.uavDevices[prev.uavId]
+		idx := slices.Index(devices, deviceId)
+		devices[idx] = devices[len(devices)-1]
+		sol.uavDevices[prev.uavId] = devices[:len(devices)-1]
+		if len(sol.uavDevices[prev.uavId]) == 0 {
+			sol.numDeployed--
+		}
+	}
+
+	sf := base.configurations[configId].Sf
+	sol.uavDatarate[uavSliceKey{slot, slice}] += base.gateway.GetDatarate(sf, slice)
+	sol.sfCount[sf]++
+	if len(sol.uavDevices[slot]) == 0 {
+		sol.numDeployed++
+	}
+	sol.uavDevices[slot] = append(sol.uavDevices[slot], deviceId)
+	sol.deviceAssociation[deviceId] = uavConfigurationAssociation{slot, configId}
+}
+
+// fits tells whether slot can take the extra datarate on the slice of deviceId.
+func (sol *ContinuousSolution) fits(deviceId device.DeviceId, slot int32, extra float32) bool {
+	slice := sol.problem.GetSlice(deviceId)
+	return sol.uavDatarate[uavSliceKey{slot, slice}]+extra <= sol.problem.GetMaxDatarate(slice)
+}
+
+func (sol *ContinuousSolution) datarate(deviceId device.DeviceId, configId int32) float32 {
+	return sol.problem.GetDatarate(sol.problem.base.configurations[configId].Sf, sol.problem.GetSlice(deviceId))
+}
+
+func (sol *ContinuousSolution) GetPosition(uavId int32) utils.Position {
+	return sol.positions[uavId]
+}
+
+func (sol *ContinuousSolution) GetDeployedUavs() []int32 {
+	uavs := make([]int32, 0, sol.numDeployed)
+	for slot, devices := range sol.uavDevices {
+		if len(devices) > 0 {
+			uavs = append(uavs, int32(slot))
+		}
+	}
+	return uavs
+}
+
+func (sol *ContinuousSolution) freeSlot() int32 {
+	for slot, devices := range sol.uavDevices {
+		if len(devices) == 0 {
+			return int32(slot)
+		}
+	}
+	return -1
+}
+
+func (sol *ContinuousSolution) GetCostA() float64 {
+	return float64(sol.numDeployed) * sol.problem.base.alpha
+}
+
+func (sol *ContinuousSolution) GetCostB() float64 {
+	maxSfCount := int32(0)
+	for _, count := range sol.sfCount {
+		maxSfCount = max(maxSfCount, count)
+	}
+	return float64(maxSfCount) * sol.problem.base.beta
+}
+
+func (sol *ContinuousSolution) GetCost() float64 {
+	return sol.GetCostA() + sol.GetCostB()
+}
+
+func (sol *ContinuousSolution) GetInverseCost() float64 {
+	base := sol.problem.base
+	maxCost := float64(base.devices.Count()) * (base.alpha + base.beta)
+	return maxCost - sol.GetCost()
+}
+
+func (sol *ContinuousSolution) GetGeneratingMove() Move {
+	return sol.generatingMove
+}
+
+func (sol *ContinuousSolution) GetAssociations() []Association {
+	associations := make([]Association, 0, len(sol.deviceAssociation))
+	for deviceId, association := range sol.deviceAssociation {
+		associations = append(associations, Association{device.DeviceId(deviceId), association.uavId, association.configId})
+	}
+	return associations
+}
+
+func (sol *ContinuousSolution) GetUavTabuRatio(tabu []int32) float32 {
+	deployed := sol.GetDeployedUavs()
+	return float32(len(utils.Intersection(deployed, tabu))) / float32(len(deployed))
+}
+
+func (sol *ContinuousSolution) String() string {
+	t := fmt.Sprintf("id: %d, cost: %f, move: %+v\n", sol.id, sol.GetCost(), sol.generatingMove)
+	for _, association := range sol.deviceAssociation {
+		pos := sol.positions[association.uavId]
+		t += fmt.Sprintf(" (%d@%.1f/%.1f/%.1f,%d),", association.uavId, pos.X, pos.Y, pos.Z, association.configId)
+	}
+	return t
+}
+
+// FlipAssociation applies the association when its UAV reaches the device with enough capacity
+// left, and reassigns the device at random otherwise.
+func (sol *ContinuousSolution) FlipAssociation(association Association) {
+	deviceId := association.Device
+	current := sol.deviceAssociation[deviceId]
+	target := uavConfigurationAssociation{association.Uav, association.Config}
+	if current != target && len(sol.uavDevices[target.uavId]) > 0 &&
+		sol.problem.reaches(deviceId, target.configId, sol.problem.loss(deviceId, sol.positions[target.uavId])) {
+		extra := sol.datarate(deviceId, target.configId)
+		if current.uavId == target.uavId {
+			extra -= sol.datarate(deviceId, current.configId)
+		}
+		if sol.fits(deviceId, target.uavId, extra) {
+			sol.assign(deviceId, target.uavId, target.configId)
+			return
+		}
+	}
+
+	var move Move
+	sol.neighbourDevice(&move)
+}
+
+func (sol *ContinuousSolution) GetNeighbourSA(minDistance, maxDistance int) Solution {
+	distance := sol.problem.GetRand().Int31n(int32(maxDistance)-int32(minDistance)) + int32(minDistance)
+	newSol := sol.copy()
+	for i := int32(0); i < distance; i++ {
+		newSol = newSol.GetNeighbour()
+	}
+	return newSol
+}
+
+func (sol *ContinuousSolution) GetNeighbourRandom(minDistance, maxDistance int) Solution {
+	return sol.GetNeighbourSA(minDistance, maxDistance)
+}
+
+func (sol *ContinuousSolution) GetNeighbourList(size int) []Solution {
+	solutions := make([]Solution, size)
+	for i := 0; i < size; i++ {
+		solutions[i] = sol.GetNeighbour()
+	}
+	return solutions
+}
+
+// GetNeighbour shifts a UAV, closes one or reassigns a single device. Moves that would leave a
+// device out of reach or overload a UAV slice are dropped and another one is drawn.
+func (sol *ContinuousSolution) GetNeighbour() *ContinuousSolution {
+	maxTies := 50
+
+	neighbour := sol.copy()
+	var move Move
+
+	for maxTies > 0 {
+		random := utils.GetRandomProbability(sol.problem.GetRand())
+
+		var moved bool
+		switch {
+		case random < continuousShiftChance:
+			moved = neighbour.neighbourShift(&move)
+		case random < continuousShiftChance+continuousCloseChance:
+			moved = neighbour.neighbourClose(&move)
+		default:
+			moved = neighbour.neighbourDevice(&move)
+		}
+
+		if moved {
+			break
+		}
+		maxTies--
+	}
+
+	if maxTies == 0 {
+		fmt.Fprintf(os.Stderr, "No valid movement found\n")
+	}
+
+	neighbour.generatingMove = move
+	return neighbour
+}
+
+func (sol *ContinuousSolution) randomDeployedUav() int32 {
+	deployed := sol.GetDeployedUavs()
+	return deployed[sol.problem.GetRand().Intn(len(deployed))]
+}
+
+// neighbourShift moves a random UAV by a Gaussian step. Its devices keep their configurations
+// where they still reach it and otherwise take the cheapest one that does, preferably on the same
+// SF.
+func (sol *ContinuousSolution) neighbourShift(move *Move) bool {
+	rng := sol.problem.GetRand()
+	area := sol.problem.area
+	slot := sol.randomDeployedUav()
+
+	prev := sol.positions[slot]
+	pos := prev
+	pos.X += float32(rng.NormFloat64() * sol.problem.step)
+	pos.Y += float32(rng.NormFloat64() * sol.problem.step)
+	if area.Max.Z > area.Min.Z {
+		pos.Z += float32(rng.NormFloat64() * sol.problem.step)
+	}
+	pos = area.Clamp(pos)
+
+	devices := sol.uavDevices[slot]
+	configs := make([]int32, len(devices))
+	load := make(map[int32]float32)
+	for i, deviceId := range devices {
+		current := sol.deviceAssociation[deviceId].configId
+		loss := sol.problem.loss(deviceId, pos)
+		configs[i] = current
+		if !sol.problem.reaches(deviceId, current, loss) {
+			configId, found := sol.problem.configFor(deviceId, loss, sol.problem.base.configurations[current].Sf)
+			if !found {
+				return false
+			}
+			configs[i] = configId
+		}
+		load[sol.problem.GetSlice(deviceId)] += sol.datarate(deviceId, configs[i])
+	}
+	for slice, datarate := range load {
+		if datarate > sol.problem.GetMaxDatarate(slice) {
+			return false
+		}
+	}
+
+	sol.positions[slot] = pos
+	for i, deviceId := range slices.Clone(devices) {
+		if i == 0 {
+			*move = Move{deviceId, DirectionPosition, sol.deviceAssociation[deviceId].configId, slot, configs[i], slot}
+		}
+		if configs[i] != sol.deviceAssociation[deviceId].configId {
+			sol.assign(deviceId, slot, configs[i])
+		}
+	}
+	return true
+}
+
+// neighbourClose withdraws a random UAV, handing each of its devices to another deployed UAV that
+// reaches it and has room for it.
+func (sol *ContinuousSolution) neighbourClose(move *Move) bool {
+	if sol.numDeployed < 2 {
+		return false
+	}
+
+	rng := sol.problem.GetRand()
+	slot := sol.randomDeployedUav()
+	others := slices.DeleteFunc(sol.GetDeployedUavs(), func(uavId int32) bool { return uavId == slot })
+
+	devices := slices.Clone(sol.uavDevices[slot])
+	targets := make([]uavConfigurationAssociation, len(devices))
+	extra := make(map[uavSliceKey]float32)
+	for i, deviceId := range devices {
+		rng.Shuffle(len(others), func(a, b int) { others[a], others[b] = others[b], others[a] })
+		sf := sol.problem.base.configurations[sol.deviceAssociation[deviceId].configId].Sf
+		found := false
+		for _, uavId := range others {
+			configId, reached := sol.problem.configFor(deviceId, sol.problem.loss(deviceId, sol.positions[uavId]), sf)
+			key := uavSliceKey{uavId, sol.problem.GetSlice(deviceId)}
+			datarate := sol.datarate(deviceId, configId)
+			if reached && sol.fits(deviceId, uavId, extra[key]+datarate) {
+				targets[i] = uavConfigurationAssociation{uavId, configId}
+				extra[key] += datarate
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for i, deviceId := range devices {
+		if i == 0 {
+			*move = Move{deviceId, DirectionUAV, sol.deviceAssociation[deviceId].configId, slot, targets[i].configId, targets[i].uavId}
+		}
+		sol.assign(deviceId, targets[i].uavId, targets[i].configId)
+	}
+	return true
+}
+
+// neighbourDevice changes the UAV or the configuration of a random device. A UAV change picks a
+// new UAV placed around the device with the newUavChance of the instance, and a deployed one
+// otherwise.
+func (sol *ContinuousSolution) neighbourDevice(move *Move) bool {
+	rng := sol.problem.GetRand()
+	base := sol.problem.base
+	deviceId := base.devices.GetRandomDevice(rng).GetId()
+	current := sol.deviceAssociation[deviceId]
+	sf := base.configurations[current.configId].Sf
+
+	target := current
+	if utils.GetRandomProbability(rng) <= base.changeUav {
+		if utils.GetRandomProbability(rng) < base.newUavChance {
+			slot := sol.freeSlot()
+			if slot < 0 {
+				return false
+			}
+			pos := base.devices.GetDevice(deviceId).GetPosition()
+			pos.X += float32(rng.NormFloat64() * sol.problem.step)
+			pos.Y += float32(rng.NormFloat64() * sol.problem.step)
+			pos.Z = sol.problem.area.Min.Z + rng.Float32()*(sol.problem.area.Max.Z-sol.problem.area.Min.Z)
+			pos = sol.problem.area.Clamp(pos)
+
+			configId, reached := sol.problem.configFor(deviceId, sol.problem.loss(deviceId, pos), sf)
+			if !reached {
+				return false
+			}
+			sol.positions[slot] = pos
+			target = uavConfigurationAssociation{slot, configId}
+		} else {
+			uavId := sol.randomDeployedUav()
+			if uavId == current.uavId {
+				return false
+			}
+			configId, reached := sol.problem.configFor(deviceId, sol.problem.loss(deviceId, sol.positions[uavId]), sf)
+			if !reached || !sol.fits(deviceId, uavId, sol.datarate(deviceId, configId)) {
+				return false
+			}
+			target = uavConfigurationAssociation{uavId, configId}
+		}
+	} else {
+		configs := sol.problem.GetPossibleConfigsAt(deviceId, sol.positions[current.uavId])
+		configId := configs[rng.Intn(len(configs))]
+		if configId == current.configId {
+			return false
+		}
+		if !sol.fits(deviceId, current.uavId, sol.datarate(deviceId, configId)-sol.datarate(deviceId, current.configId)) {
+			return false
+		}
+		target.configId = configId
+	}
+
+	*move = Move{deviceId, DirectionUAV, current.configId, current.uavId, target.configId, target.uavId}
+	if target.uavId == current.uavId {
+		move.Direction = DirectionConfig
+	}
+	sol.assign(deviceId, target.uavId, target.configId)
+	return true
+}
+
+func (sol *ContinuousSolution) OutputGatewayPositions() string {
+	output := "id,x,y,z\n"
+	for _, slot := range sol.GetDeployedUavs() {
+		pos := sol.positions[slot]
+		output += fmt.Sprintf("%d,%f,%f,%f\n", slot, pos.X, pos.Y, pos.Z)
+	}
+	return output
+}
+
+func (sol *ContinuousSolution) OutputConfigurations() string {
+	output := "device,sf,tp\n"
+	for deviceId, association := range sol.deviceAssociation {
+		config := sol.problem.base.configurations[association.configId]
+		output += fmt.Sprintf("%d,%d,%d\n", deviceId, config.Sf, config.Tp)
+	}
+	return output
+}
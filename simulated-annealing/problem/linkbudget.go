@@ -0,0 +1,325 @@
+package problem
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/utils"
+)
+
+const (
+	numSFs = device.MaxSF - device.MinSF + 1
+	numTPs = (device.MaxTP-device.MinTP)/device.StepTP + 1
+)
+
+// LinkBudget holds what the reach and QoS checks say about every device and candidate position. It
+// is computed once, when the problem is created, so that solvers only look links up. The feasible
+// pairs, the links, are kept in compressed sparse row form in both directions: the UAVs each device
+// reaches and the devices each UAV covers.
+//
+// When the path loss model bounds its reach, the candidate positions of each device are taken from a
+// spatial index within the farthest distance any configuration reaches, rather than from all of
+// them, so that construction grows with the number of pairs in reach.
+//
+// A link is described by its profile, the lowest transmission power closing it on each SF. Few
+// distinct profiles occur, so links share them along with the configurations and SFs they allow.
+type LinkBudget struct {
+	numUavs     int32
+	reach       float64   // in m, +Inf when the path loss model does not bound it
+	uavStart    []int32   // the links of device d are uavStart[d] to uavStart[d+1]
+	uavs        []int32   // per link, increasing for each device
+	pathLoss    []float32 // per link, in dB
+	profile     []int32   // per link, an index into profiles
+	profiles    []linkProfile
+	deviceStart []int32 // the devices covered by UAV u are devices[deviceStart[u]:deviceStart[u+1]]
+	devices     []device.DeviceId
+}
+
+type linkProfile struct {
+	minTp   [numSFs]int16 // 0 where the SF does not close the link or misses the QoS bound
+	configs []int32
+	sfs     []int
+}
+
+// deviceLinks are the links found by one worker for a contiguous range of devices, with the packed
+// profile of each link, see profileKey.
+type deviceLinks struct {
+	count    []int32
+	uavs     []int32
+	pathLoss []float32
+	keys     []int32
+}
+
+// createLinkBudget spreads the devices over one worker per core. Each worker owns a contiguous range
+// of devices, so the result does not depend on the number of workers.
+func createLinkBudget(problem *UAVProblem) (*LinkBudget, error) {
+	numDevices, numUavs := problem.devices.Count(), problem.uavPositions.Count()
+	links := &LinkBudget{
+		numUavs:  numUavs,
+		reach:    math.Inf(1),
+		uavStart: make([]int32, numDevices+1),
+	}
+
+	// The candidate positions are read from a map, so copy them once
+	positions := make([]utils.Position, numUavs)
+	for uavId := range positions {
+		positions[uavId] = problem.uavPositions.GetCandidatePosition(int32(uavId))
+	}
+
+	// No link loses more than the maximum transmission power over the best sensitivity. A metre of
+	// slack absorbs the rounding of the distances.
+	var grid *utils.Grid
+	if model, ok := problem.pathLoss.(ReachBounded); ok {
+		bestSensitivity := math.Inf(1)
+		for sf := device.MinSF; sf <= device.MaxSF; sf++ {
+			bestSensitivity = min(bestSensitivity, float64(problem.gateway.GetSensitivityForSf(int16(sf))))
+		}
+		links.reach = model.MaxDistance(device.MaxTP-bestSensitivity) + 1
+		grid = utils.CreateGrid(positions, float32(links.reach/2))
+	}
+
+	workers := min(int32(runtime.GOMAXPROCS(0)), numDevices)
+	found := make([]deviceLinks, workers)
+	wg := &sync.WaitGroup{}
+	for w := int32(0); w < workers; w++ {
+		wg.Add(1)
+		go func(w int32) {
+			defer wg.Done()
+			first, last := numDevices*w/workers, numDevices*(w+1)/workers
+			found[w] = links.computeDevices(problem, positions, grid, device.DeviceId(first), device.DeviceId(last))
+		}(w)
+	}
+	wg.Wait()
+
+	// Concatenate the ranges in device order
+	numLinks := 0
+	for _, devices := range found {
+		numLinks += len(devices.uavs)
+	}
+	links.uavs = make([]int32, 0, numLinks)
+	links.pathLoss = make([]float32, 0, numLinks)
+	keys := make([]int32, 0, numLinks)
+	deviceId := int32(0)
+	for _, devices := range found {
+		for _, count := range devices.count {
+			if count == 0 {
+				return nil, fmt.Errorf("unfeasibility: none candidate position is able to reach device %d", deviceId)
+			}
+			links.uavStart[deviceId+1] = links.uavStart[deviceId] + count
+			deviceId++
+		}
+		links.uavs = append(links.uavs, devices.uavs...)
+		links.pathLoss = append(links.pathLoss, devices.pathLoss...)
+		keys = append(keys, devices.keys...)
+	}
+
+	links.internProfiles(keys)
+	links.computeCoverage(numDevices)
+	return links, nil
+}
+
+// computeDevices returns the links of the devices from first to last, excluded, checking the
+// candidate positions the grid finds in reach, or all of them without a grid.
+func (links *LinkBudget) computeDevices(problem *UAVProblem, positions []utils.Position, grid *utils.Grid, first, last device.DeviceId) deviceLinks {
+	var sensitivity [numSFs]float64
+	for sf := device.MinSF; sf <= device.MaxSF; sf++ {
+		sensitivity[sf-device.MinSF] = float64(problem.gateway.GetSensitivityForSf(int16(sf)))
+	}
+
+	found := deviceLinks{count: make([]int32, 0, last-first)}
+	candidates := make([]int32, 0, links.numUavs)
+	if grid == nil {
+		for uavId := int32(0); uavId < links.numUavs; uavId++ {
+			candidates = append(candidates, uavId)
+		}
+	}
+	for deviceId := first; deviceId < last; deviceId++ {
+		// The QoS only depends on the SF and the slice of the device
+		var qos [numSFs]bool
+		for sf := device.MinSF; sf <= device.MaxSF; sf++ {
+			qos[sf-device.MinSF] = problem.checkQoSFeasibility(deviceId, device.GetConfigID(sf, device.MinTP))
+		}
+
+		devicePos := problem.devices.GetDevice(deviceId).GetPosition()
+		if grid != nil {
+			candidates = grid.Within(devicePos, float32(links.reach), candidates[:0])
+			slices.Sort(candidates)
+		}
+
+		count := int32(0)
+		for _, uavId := range candidates {
+			pathLoss := problem.pathLoss.PathLoss(devicePos, positions[uavId])
+
+			var minTp [numSFs]int16
+			feasible := false
+			for i := range minTp {
+				if !qos[i] {
+					continue
+				}
+				for tp := device.MinTP; tp <= device.MaxTP; tp += device.StepTP {
+					if float64(tp)-pathLoss >= sensitivity[i] {
+						minTp[i] = int16(tp)
+						feasible = true
+						break
+					}
+				}
+			}
+
+			if feasible {
+				found.uavs = append(found.uavs, uavId)
+				found.pathLoss = append(found.pathLoss, float32(pathLoss))
+				found.keys = append(found.keys, profileKey(minTp))
+				count++
+			}
+		}
+		found.count = append(found.count, count)
+	}
+	return found
+}
+
+// profileKey packs the minimum transmission powers of a link into a number below
+// (numTPs+1)^numSFs, with a digit per SF that is numTPs where the SF is infeasible.
+func profileKey(minTp [numSFs]int16) int32 {
+	key := int32(0)
+	for i := numSFs - 1; i >= 0; i-- {
+		digit := int32(numTPs)
+		if minTp[i] > 0 {
+			digit = int32(minTp[i]-device.MinTP) / device.StepTP
+		}
+		key = key*(numTPs+1) + digit
+	}
+	return key
+}
+
+func (links *LinkBudget) internProfiles(keys []int32) {
+	index := make(map[int32]int32)
+	links.profile = make([]int32, len(keys))
+	for link, key := range keys {
+		profileId, found := index[key]
+		if !found {
+			profileId = int32(len(links.profiles))
+			index[key] = profileId
+			links.profiles = append(links.profiles, createLinkProfile(key))
+		}
+		links.profile[link] = profileId
+	}
+}
+
+func createLinkProfile(key int32) linkProfile {
+	profile := linkProfile{}
+	for i := 0; i < numSFs; i++ {
+		digit := key % (numTPs + 1)
+		key /= numTPs + 1
+		if digit == numTPs {
+			continue
+		}
+
+		sf := device.MinSF + i
+		profile.minTp[i] = int16(device.MinTP + int(digit)*device.StepTP)
+		profile.sfs = append(profile.sfs, sf)
+		for tp := int(profile.minTp[i]); tp <= device.MaxTP; tp += device.StepTP {
+			profile.configs = append(profile.configs, device.GetConfigID(sf, tp))
+		}
+	}
+	return profile
+}
+
+// computeCoverage transposes the links, keeping the devices of each UAV in increasing order.
+func (links *LinkBudget) computeCoverage(numDevices int32) {
+	links.deviceStart = make([]int32, links.numUavs+1)
+	for _, uavId := range links.uavs {
+		links.deviceStart[uavId+1]++
+	}
+	for uavId := int32(0); uavId < links.numUavs; uavId++ {
+		links.deviceStart[uavId+1] += links.deviceStart[uavId]
+	}
+
+	next := slices.Clone(links.deviceStart[:links.numUavs])
+	links.devices = make([]device.DeviceId, len(links.uavs))
+	for deviceId := int32(0); deviceId < numDevices; deviceId++ {
+		for _, uavId := range links.uavs[links.uavStart[deviceId]:links.uavStart[deviceId+1]] {
+			links.devices[next[uavId]] = device.DeviceId(deviceId)
+			next[uavId]++
+		}
+	}
+}
+
+// link finds the link between a device and a UAV, if there is one.
+func (links *LinkBudget) link(deviceId device.DeviceId, uavId int32) (int32, bool) {
+	first := links.uavStart[deviceId]
+	idx, found := slices.BinarySearch(links.uavs[first:links.uavStart[deviceId+1]], uavId)
+	return first + int32(idx), found
+}
+
+// PathLoss is the loss of the link between the device and the UAV in dB, if there is one.
+func (links *LinkBudget) PathLoss(deviceId device.DeviceId, uavId int32) (float32, bool) {
+	link, found := links.link(deviceId, uavId)
+	if !found {
+		return 0, false
+	}
+	return links.pathLoss[link], true
+}
+
+// Reach is the farthest a device can be from a UAV it reaches, in m, or +Inf when the path loss
+// model does not bound it.
+func (links *LinkBudget) Reach() float64 {
+	return links.reach
+}
+
+func (links *LinkBudget) NumLinks() int {
+	return len(links.uavs)
+}
+
+func (links *LinkBudget) NumProfiles() int {
+	return len(links.profiles)
+}
+
+// Uavs lists the UAVs the device reaches, in increasing order. The slice is shared and must not be
+// modified.
+func (links *LinkBudget) Uavs(deviceId device.DeviceId) []int32 {
+	first, last := links.uavStart[deviceId], links.uavStart[deviceId+1]
+	return links.uavs[first:last:last]
+}
+
+// Devices lists the devices the UAV covers, in increasing order. The slice is shared and must not
+// be modified.
+func (links *LinkBudget) Devices(uavId int32) []device.DeviceId {
+	first, last := links.deviceStart[uavId], links.deviceStart[uavId+1]
+	return links.devices[first:last:last]
+}
+
+// Configs lists the configurations with which the device reaches the UAV and meets the QoS bound,
+// in increasing order, or nil without a link. The slice is shared and must not be modified.
+func (links *LinkBudget) Configs(deviceId device.DeviceId, uavId int32) []int32 {
+	link, found := links.link(deviceId, uavId)
+	if !found {
+		return nil
+	}
+	configs := links.profiles[links.profile[link]].configs
+	return configs[:len(configs):len(configs)]
+}
+
+// SFs lists the SFs of Configs, in increasing order. The slice is shared and must not be modified.
+func (links *LinkBudget) SFs(deviceId device.DeviceId, uavId int32) []int {
+	link, found := links.link(deviceId, uavId)
+	if !found {
+		return nil
+	}
+	sfs := links.profiles[links.profile[link]].sfs
+	return sfs[:len(sfs):len(sfs)]
+}
+
+// MinTp is the lowest transmission power with which the device reaches the UAV on sf, if any meets
+// the QoS bound and closes the link.
+func (links *LinkBudget) MinTp(deviceId device.DeviceId, uavId int32, sf int16) (int16, bool) {
+	link, found := links.link(deviceId, uavId)
+	if !found || sf < device.MinSF || sf > device.MaxSF {
+		return 0, false
+	}
+	tp := links.profiles[links.profile[link]].minTp[sf-device.MinSF]
+	return tp, tp > 0
+}
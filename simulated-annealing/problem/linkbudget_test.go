@@ -0,0 +1,86 @@
+package problem
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+)
+
+// naiveConfigs checks every configuration of every device against every candidate position, as
+// the problem did before the link budget, and returns the feasible ones by device and UAV.
+func naiveConfigs(problem *UAVProblem) [][][]int32 {
+	configs := make([][][]int32, problem.devices.Count())
+	for deviceId := range configs {
+		configs[deviceId] = make([][]int32, problem.uavPositions.Count())
+		devicePos := problem.devices.GetDevice(device.DeviceId(deviceId)).GetPosition()
+		for uavId := range configs[deviceId] {
+			pathLoss := problem.pathLoss.PathLoss(devicePos, problem.uavPositions.GetCandidatePosition(int32(uavId)))
+			for sf := device.MinSF; sf <= device.MaxSF; sf++ {
+				for tp := device.MinTP; tp <= device.MaxTP; tp += device.StepTP {
+					configId := device.GetConfigID(sf, tp)
+					sensitivity := float64(problem.gateway.GetSensitivityForSf(int16(sf)))
+					if problem.checkQoSFeasibility(device.DeviceId(deviceId), configId) && float64(tp)-pathLoss >= sensitivity {
+						configs[deviceId][uavId] = append(configs[deviceId][uavId], configId)
+					}
+				}
+			}
+			slices.Sort(configs[deviceId][uavId])
+		}
+	}
+	return configs
+}
+
+func TestLinkBudgetMatchesAllPairs(t *testing.T) {
+	tests := []struct {
+		name      string
+		seed      string
+		altitudes []float32
+		pathLoss  PathLossModel
+	}{
+		{"log-distance", "1", nil, DefaultPathLoss()},
+		{"log-distance steep", "2", nil, LogDistance{ReferenceLoss: 10, ReferenceDistance: 1, Exponent: 4.2}},
+		{"log-distance altitudes", "5", []float32{30, 60, 120}, DefaultPathLoss()},
+		{"free-space", "10", nil, FreeSpace{Frequency: 868}},
+		{"okumura-hata", "1", nil, OkumuraHata{Frequency: 868, Environment: HataUrban}},
+		{"air-to-ground", "2", []float32{50, 300}, AirToGround{Frequency: 868, A: 9.61, B: 0.16, LoSLoss: 1, NLoSLoss: 20, Reliability: 0.9}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deviceList, candidatePosList, gw := loadTestData(t, test.seed, test.altitudes)
+			problem, err := CreateUAVProblemInstanceWithPathLoss(100.0, 1.0, 0.5, 0.05, test.pathLoss, deviceList, candidatePosList, gw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			links := problem.GetLinkBudget()
+
+			numLinks, numConfigs, gotConfigs := 0, 0, 0
+			for deviceId, byUav := range naiveConfigs(problem) {
+				reached := make([]int32, 0)
+				for uavId, configs := range byUav {
+					got := links.Configs(device.DeviceId(deviceId), int32(uavId))
+					gotConfigs += len(got)
+					if !slices.Equal(got, configs) {
+						t.Errorf("device %d, UAV %d: configurations %v, want %v", deviceId, uavId, got, configs)
+					}
+					if len(configs) > 0 {
+						reached = append(reached, int32(uavId))
+						numLinks++
+						numConfigs += len(configs)
+					}
+				}
+				if got := links.Uavs(device.DeviceId(deviceId)); !slices.Equal(got, reached) {
+					t.Errorf("device %d: UAVs %v, want %v", deviceId, got, reached)
+				}
+			}
+
+			if links.NumLinks() != numLinks || gotConfigs != numConfigs {
+				t.Errorf("%d links with %d configurations, want %d with %d", links.NumLinks(), gotConfigs, numLinks, numConfigs)
+			}
+			if numLinks == 0 {
+				t.Errorf("no links, the instance does not exercise the link budget")
+			}
+		})
+	}
+}
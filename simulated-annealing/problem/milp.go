@@ -0,0 +1,305 @@
+package problem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+)
+
+type VariableType int
+
+const (
+	Continuous VariableType = iota
+	Binary
+)
+
+type Sense byte
+
+const (
+	LessEqual    Sense = 'L'
+	GreaterEqual Sense = 'G'
+	Equal        Sense = 'E'
+)
+
+type Term struct {
+	Variable int
+	Coef     float64
+}
+
+type Variable struct {
+	Name string
+	Type VariableType
+	Cost float64
+}
+
+type Constraint struct {
+	Name  string
+	Terms []Term
+	Sense Sense
+	Rhs   float64
+}
+
+// MILPModel is a minimization problem over non-negative variables, where binary variables are
+// bounded by 1.
+type MILPModel struct {
+	Name        string
+	Variables   []Variable
+	Constraints []Constraint
+}
+
+func (model *MILPModel) addVariable(name string, varType VariableType, cost float64) int {
+	model.Variables = append(model.Variables, Variable{name, varType, cost})
+	return len(model.Variables) - 1
+}
+
+func (model *MILPModel) addConstraint(name string, terms []Term, sense Sense, rhs float64) {
+	model.Constraints = append(model.Constraints, Constraint{name, terms, sense, rhs})
+}
+
+// BuildMILP formulates the instance exactly, using the precomputed feasible associations:
+//
+//	min   alpha * sum_u y_u + beta * z
+//	s.t.  sum_{u,c} x_duc = 1                           for every device d
+//	      sum_c x_duc <= y_u                            for every feasible device/UAV pair
+//	      sum_{d in s,c} datarate(c,s) x_duc <= max(s) y_u  for every UAV u and slice s
+//	      sum_{d,u,c with SF(c) = sf} x_duc <= z        for every SF
+//
+// y_u deploys candidate position u and x_duc assigns device d to u with configuration c. z is left
+// continuous since it settles on the integer maximum SF count at any optimum.
+func (problem *UAVProblem) BuildMILP() *MILPModel {
+	model := &MILPModel{Name: "uav"}
+
+	numUavs := problem.uavPositions.Count()
+	deploy := make([]int, numUavs)
+	for uavId := int32(0); uavId < numUavs; uavId++ {
+		deploy[uavId] = model.addVariable(fmt.Sprintf("y_%d", uavId), Binary, problem.alpha)
+	}
+	maxSfCount := model.addVariable("z", Continuous, problem.beta)
+
+	capacity := make(map[uavSliceKey][]Term)
+	sfCount := make(map[int16][]Term)
+
+	for _, deviceId := range problem.devices.GetDeviceIds() {
+		slice := problem.devices.GetDevice(deviceId).Slice()
+		assign := make([]Term, 0)
+
+		for _, uavId := range problem.links.Uavs(deviceId) {
+			link := make([]Term, 0)
+			for _, configId := range problem.links.Configs(deviceId, uavId) {
+				sf := problem.configurations[configId].Sf
+				x := model.addVariable(fmt.Sprintf("x_%d_%d_%d", deviceId, uavId, configId), Binary, 0)
+
+				assign = append(assign, Term{x, 1})
+				link = append(link, Term{x, 1})
+				key := uavSliceKey{uavId, slice}
+				capacity[key] = append(capacity[key], Term{x, float64(problem.gateway.GetDatarate(sf, slice))})
+				sfCount[sf] = append(sfCount[sf], Term{x, 1})
+			}
+
+			link = append(link, Term{deploy[uavId], -1})
+			model.addConstraint(fmt.Sprintf("link_%d_%d", deviceId, uavId), link, LessEqual, 0)
+		}
+
+		model.addConstraint(fmt.Sprintf("assign_%d", deviceId), assign, Equal, 1)
+	}
+
+	keys := make([]uavSliceKey, 0, len(capacity))
+	for key := range capacity {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b uavSliceKey) int {
+		if a.uavId != b.uavId {
+			return int(a.uavId - b.uavId)
+		}
+		return int(a.slice - b.slice)
+	})
+	for _, key := range keys {
+		terms := append(capacity[key], Term{deploy[key.uavId], -float64(problem.gateway.GetMaxDatarate(key.slice))})
+		model.addConstraint(fmt.Sprintf("cap_%d_%d", key.uavId, key.slice), terms, LessEqual, 0)
+	}
+
+	for sf := int16(device.MinSF); sf <= device.MaxSF; sf++ {
+		if len(sfCount[sf]) == 0 {
+			continue
+		}
+		terms := append(sfCount[sf], Term{maxSfCount, -1})
+		model.addConstraint(fmt.Sprintf("sf_%d", sf), terms, LessEqual, 0)
+	}
+
+	return model
+}
+
+// WriteLP writes the model in CPLEX LP format.
+func (model *MILPModel) WriteLP(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+
+	fmt.Fprintf(writer, "\\ %s: %d variables, %d constraints\n", model.Name, len(model.Variables), len(model.Constraints))
+	writer.WriteString("Minimize\n")
+	objective := make([]Term, 0)
+	for i, variable := range model.Variables {
+		if variable.Cost != 0 {
+			objective = append(objective, Term{i, variable.Cost})
+		}
+	}
+	model.writeLPExpression(writer, " obj:", objective)
+	writer.WriteString("\n")
+
+	writer.WriteString("Subject To\n")
+	for _, constraint := range model.Constraints {
+		model.writeLPExpression(writer, " "+constraint.Name+":", constraint.Terms)
+		fmt.Fprintf(writer, " %s %s\n", lpSense(constraint.Sense), formatCoef(constraint.Rhs))
+	}
+
+	binaries := make([]string, 0)
+	for _, variable := range model.Variables {
+		if variable.Type == Binary {
+			binaries = append(binaries, variable.Name)
+		}
+	}
+	if len(binaries) > 0 {
+		writer.WriteString("Binaries\n")
+		for start := 0; start < len(binaries); start += 10 {
+			fmt.Fprintf(writer, " %s\n", strings.Join(binaries[start:min(start+10, len(binaries))], " "))
+		}
+	}
+
+	writer.WriteString("End\n")
+	return writer.Flush()
+}
+
+// writeLPExpression keeps lines short, since LP readers limit their length.
+func (model *MILPModel) writeLPExpression(w *bufio.Writer, label string, terms []Term) {
+	w.WriteString(label)
+	for i, term := range terms {
+		if i > 0 && i%8 == 0 {
+			w.WriteString("\n  ")
+		}
+
+		sign := "+"
+		coef := term.Coef
+		if coef < 0 {
+			sign = "-"
+			coef = -coef
+		}
+		if i == 0 && sign == "+" {
+			fmt.Fprintf(w, " %s %s", formatCoef(coef), model.Variables[term.Variable].Name)
+		} else {
+			fmt.Fprintf(w, " %s %s %s", sign, formatCoef(coef), model.Variables[term.Variable].Name)
+		}
+	}
+	if len(terms) == 0 {
+		w.WriteString(" 0")
+	}
+}
+
+func lpSense(sense Sense) string {
+	switch sense {
+	case LessEqual:
+		return "<="
+	case GreaterEqual:
+		return ">="
+	default:
+		return "="
+	}
+}
+
+// WriteMPS writes the model in free MPS format. Binary variables are marked as integers bounded
+// by 1, which every MPS reader understands.
+func (model *MILPModel) WriteMPS(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+
+	type entry struct {
+		row  int
+		coef float64
+	}
+	columns := make([][]entry, len(model.Variables))
+	for row, constraint := range model.Constraints {
+		for _, term := range constraint.Terms {
+			columns[term.Variable] = append(columns[term.Variable], entry{row, term.Coef})
+		}
+	}
+
+	fmt.Fprintf(writer, "NAME %s\n", model.Name)
+	writer.WriteString("ROWS\n")
+	writer.WriteString(" N obj\n")
+	for _, constraint := range model.Constraints {
+		fmt.Fprintf(writer, " %c %s\n", constraint.Sense, constraint.Name)
+	}
+
+	writer.WriteString("COLUMNS\n")
+	integer := false
+	for i, variable := range model.Variables {
+		if (variable.Type == Binary) != integer {
+			integer = !integer
+			if integer {
+				writer.WriteString(" MARKER 'MARKER' 'INTORG'\n")
+			} else {
+				writer.WriteString(" MARKER 'MARKER' 'INTEND'\n")
+			}
+		}
+
+		if variable.Cost != 0 {
+			fmt.Fprintf(writer, " %s obj %s\n", variable.Name, formatCoef(variable.Cost))
+		}
+		for _, entry := range columns[i] {
+			fmt.Fprintf(writer, " %s %s %s\n", variable.Name, model.Constraints[entry.row].Name, formatCoef(entry.coef))
+		}
+		if variable.Cost == 0 && len(columns[i]) == 0 {
+			fmt.Fprintf(writer, " %s obj 0\n", variable.Name)
+		}
+	}
+	if integer {
+		writer.WriteString(" MARKER 'MARKER' 'INTEND'\n")
+	}
+
+	writer.WriteString("RHS\n")
+	for _, constraint := range model.Constraints {
+		if constraint.Rhs != 0 {
+			fmt.Fprintf(writer, " RHS %s %s\n", constraint.Name, formatCoef(constraint.Rhs))
+		}
+	}
+
+	writer.WriteString("BOUNDS\n")
+	for _, variable := range model.Variables {
+		if variable.Type == Binary {
+			fmt.Fprintf(writer, " UP BND %s 1\n", variable.Name)
+		}
+	}
+
+	writer.WriteString("ENDATA\n")
+	return writer.Flush()
+}
+
+// WriteFile picks the format from the extension of path: .lp or .mps.
+func (model *MILPModel) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".lp":
+		err = model.WriteLP(file)
+	case ".mps":
+		err = model.WriteMPS(file)
+	default:
+		return fmt.Errorf("%s: unsupported model format %q, use .lp or .mps", path, ext)
+	}
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+func formatCoef(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
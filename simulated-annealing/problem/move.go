@@ -0,0 +1,328 @@
+package problem
+
+import (
+	"slices"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/utils"
+)
+
+// DrawMove draws a random move of sol, as GetNeighbourSmarter does, without applying it: with the
+// altitude chance of the instance, a deployed UAV rises or descends with all its devices, and
+// otherwise a random device changes its UAV or its configuration. It returns false when no valid
+// move was found within a bounded number of tries.
+func (sol *UAVSolution) DrawMove() (Move, bool) {
+	for maxTies := 50; maxTies > 0; maxTies-- {
+		// Altitude moves draw no random number unless enabled, keeping seeded runs reproducible
+		if sol.problem.altitudeChance > 0 && utils.GetRandomProbability(sol.problem.rng) < sol.problem.altitudeChance {
+			if move, found := sol.drawAltitudeMove(); found {
+				return move, true
+			}
+			continue
+		}
+
+		deviceId := sol.problem.devices.GetRandomDevice(sol.problem.rng).GetId()
+		changeUav := utils.GetRandomProbability(sol.problem.rng) <= sol.problem.GetChanceOfChangingUAV()
+		if move, found := sol.drawDeviceMove(deviceId, changeUav); found {
+			return move, true
+		}
+	}
+
+	return Move{}, false
+}
+
+// drawDeviceMove draws another UAV or another configuration for deviceId. It returns false when
+// the neighbour chosen is the current one.
+func (sol *UAVSolution) drawDeviceMove(deviceId device.DeviceId, changeUav bool) (Move, bool) {
+	uavId := sol.GetAssignedUavId(deviceId)
+	configId := sol.GetAssignedConfigId(deviceId)
+
+	if changeUav {
+		ass := sol.neighbourUAVSmarter(deviceId, uavId, configId)
+		return Move{deviceId, DirectionUAV, configId, uavId, ass.configId, ass.uavId}, ass.uavId != uavId
+	}
+
+	ass := sol.neighbourConfig(deviceId, uavId, configId)
+	return Move{deviceId, DirectionConfig, configId, uavId, ass.configId, ass.uavId}, ass.configId != configId
+}
+
+// drawAltitudeMove picks a random deployed UAV and another free candidate over the same ground
+// point, which all its devices can reach. The move names the first of these devices. It returns
+// false when no deployed UAV has such a candidate.
+func (sol *UAVSolution) drawAltitudeMove() (Move, bool) {
+	uavs := slices.Clone(sol.deployedUavs)
+	sol.problem.rng.Shuffle(len(uavs), func(i, j int) {
+		uavs[i], uavs[j] = uavs[j], uavs[i]
+	})
+
+	for _, uavId := range uavs {
+		devices := sol.GetDevicesAssignedTo(uavId)
+		candidates := make([]int32, 0)
+		for _, altitudeId := range sol.problem.GetAltitudes(uavId) {
+			if altitudeId == uavId || sol.uavCount[altitudeId] > 0 {
+				continue
+			}
+
+			reachable := true
+			for _, deviceId := range devices {
+				if len(sol.problem.links.Configs(deviceId, altitudeId)) == 0 {
+					reachable = false
+					break
+				}
+			}
+			if reachable {
+				candidates = append(candidates, altitudeId)
+			}
+		}
+
+		if len(candidates) == 0 {
+			continue
+		}
+
+		newUav := candidates[sol.problem.rng.Intn(len(candidates))]
+		configId := sol.GetAssignedConfigId(devices[0])
+		return Move{devices[0], DirectionAltitude, configId, uavId, configId, newUav}, true
+	}
+
+	return Move{}, false
+}
+
+// Delta is the change in cost that applying move to sol would bring, read from the deployed UAVs
+// and the device count per SF without building the neighbour. It is only known for the moves of a
+// single device, DirectionUAV and DirectionConfig, that fit in the capacity of their new UAV slice:
+// for the others, which change several devices or trigger a capacity repair, it returns false and
+// the cost must be read after an Apply.
+func (sol *UAVSolution) Delta(move Move) (float64, bool) {
+	if move.Direction != DirectionUAV && move.Direction != DirectionConfig {
+		return 0, false
+	}
+
+	problem := sol.problem
+	slice := problem.GetSlice(move.DeviceId)
+	sfPrev := problem.configurations[move.PrevConfig].Sf
+	sfNew := problem.configurations[move.NewConfig].Sf
+
+	// same order of operations as updateDeviceAssociation, so that the float32 load matches
+	load := sol.uavDatarate[sol.key(move.NewUAV, slice)]
+	if move.NewUAV == move.PrevUAV {
+		load -= problem.gateway.GetDatarate(sfPrev, slice)
+	}
+	load += problem.gateway.GetDatarate(sfNew, slice)
+	if load > problem.gateway.GetMaxDatarate(slice) {
+		return 0, false
+	}
+
+	delta := 0.0
+	if move.NewUAV != move.PrevUAV {
+		if sol.uavCount[move.PrevUAV] == 1 {
+			delta -= problem.alpha
+		}
+		if sol.uavCount[move.NewUAV] == 0 {
+			delta += problem.alpha
+		}
+	}
+
+	if sfNew != sfPrev {
+		before, after := int32(0), sol.sfCount[sfNew-device.MinSF]+1
+		for i, count := range sol.sfCount {
+			sf := int16(i + device.MinSF)
+			before = max(before, count)
+			if sf == sfPrev {
+				count--
+			}
+			if sf != sfNew {
+				after = max(after, count)
+			}
+		}
+		delta += float64(after-before) * problem.beta
+	}
+
+	return delta, true
+}
+
+// GetNeighbourFor builds the neighbour of sol reached by move, drawn by DrawMove or DrawMoveIn, and
+// restores the capacity of any UAV slice it overloads.
+func (sol *UAVSolution) GetNeighbourFor(move Move) *UAVSolution {
+	neighbour := sol.copy()
+	neighbour.moveTo(move)
+	return neighbour
+}
+
+// change is the association of a device, and the loads of the UAV slices it leaves and joins, as
+// they were before an Apply reassigned it.
+type change struct {
+	deviceId    device.DeviceId
+	association uavConfigurationAssociation
+	loadPrev    float32
+	loadNew     float32
+}
+
+// appliedMove marks where the changes of an Apply start in the journal, with the generating move
+// it replaced and whether the solution still had its reported cost.
+type appliedMove struct {
+	start          int
+	generatingMove Move
+	hasReported    bool
+}
+
+// Apply applies move to sol in place, as GetNeighbourFor does on a copy, and records every device
+// it reassigns so that Undo can revert it. A solver applies a candidate, reads its cost, and
+// either keeps it with Commit or reverts it with Undo, copying sol only to keep a best solution.
+func (sol *UAVSolution) Apply(move Move) {
+	sol.begin()
+	sol.moveTo(move)
+}
+
+// ApplyIn draws a random move of sol in the given neighbourhood and applies it as Apply does. A
+// close or swap move that turns out invalid is undone before another is drawn. It returns false,
+// leaving sol unchanged, when no move was found within a bounded number of tries.
+func (sol *UAVSolution) ApplyIn(neighbourhood Neighbourhood) (Move, bool) {
+	if neighbourhood == NeighbourhoodConfig || neighbourhood == NeighbourhoodUAV {
+		move, found := sol.DrawMoveIn(neighbourhood)
+		if !found {
+			return Move{}, false
+		}
+		sol.Apply(move)
+		return sol.generatingMove, true
+	}
+
+	for maxTies := 50; maxTies > 0; maxTies-- {
+		sol.begin()
+		var move Move
+
+		var moved bool
+		switch neighbourhood {
+		case NeighbourhoodClose:
+			moved = sol.neighbourClose(&move)
+		case NeighbourhoodSwap:
+			moved = sol.neighbourSwap(&move)
+		}
+
+		if moved {
+			sol.generatingMove = move
+			return move, true
+		}
+		sol.Undo()
+	}
+
+	return Move{}, false
+}
+
+// Undo reverts the last Apply that was neither committed nor undone, restoring the loads it
+// changed exactly. It returns false when there is none.
+func (sol *UAVSolution) Undo() bool {
+	if len(sol.applied) == 0 {
+		return false
+	}
+
+	last := sol.applied[len(sol.applied)-1]
+	sol.applied = sol.applied[:len(sol.applied)-1]
+	for i := len(sol.journal) - 1; i >= last.start; i-- {
+		entry := sol.journal[i]
+		slice := sol.problem.GetSlice(entry.deviceId)
+		uavNew := sol.GetAssignedUavId(entry.deviceId)
+		sol.assign(entry.deviceId, entry.association)
+
+		// float32 loads do not come back exactly by adding and subtracting datarates
+		sol.uavDatarate[sol.key(uavNew, slice)] = entry.loadNew
+		sol.uavDatarate[sol.key(entry.association.uavId, slice)] = entry.loadPrev
+	}
+	sol.journal = sol.journal[:last.start]
+	sol.generatingMove = last.generatingMove
+	sol.hasReported = last.hasReported
+
+	return true
+}
+
+// Commit keeps every move applied since the last Commit, which can no longer be undone.
+func (sol *UAVSolution) Commit() {
+	sol.journal = sol.journal[:0]
+	sol.applied = sol.applied[:0]
+}
+
+func (sol *UAVSolution) begin() {
+	sol.applied = append(sol.applied, appliedMove{len(sol.journal), sol.generatingMove, sol.hasReported})
+}
+
+func (sol *UAVSolution) record(deviceId device.DeviceId, association uavConfigurationAssociation) {
+	prev := sol.association(deviceId)
+	slice := sol.problem.GetSlice(deviceId)
+	sol.journal = append(sol.journal, change{
+		deviceId:    deviceId,
+		association: prev,
+		loadPrev:    sol.uavDatarate[sol.key(prev.uavId, slice)],
+		loadNew:     sol.uavDatarate[sol.key(association.uavId, slice)],
+	})
+}
+
+// MoveIterator yields candidate moves of a solution. Each move is drawn when Next is called, from
+// the solution as it stands then, so moves may be applied or undone between calls.
+type MoveIterator struct {
+	remaining int
+	draw      func() (Move, bool)
+	move      Move
+}
+
+// Candidates iterates over up to count moves of sol drawn with DrawMove.
+func (sol *UAVSolution) Candidates(count int) *MoveIterator {
+	return &MoveIterator{remaining: count, draw: sol.DrawMove}
+}
+
+// CandidatesIn iterates over up to count moves of sol drawn with DrawMoveIn, none for the close
+// and swap neighbourhoods.
+func (sol *UAVSolution) CandidatesIn(neighbourhood Neighbourhood, count int) *MoveIterator {
+	return &MoveIterator{remaining: count, draw: func() (Move, bool) { return sol.DrawMoveIn(neighbourhood) }}
+}
+
+// Next draws the next move. It returns false once count moves were drawn, or as soon as a draw
+// finds no valid move.
+func (it *MoveIterator) Next() bool {
+	if it.remaining <= 0 {
+		return false
+	}
+	it.remaining--
+
+	move, found := it.draw()
+	if !found {
+		it.remaining = 0
+		return false
+	}
+	it.move = move
+	return true
+}
+
+// Move is the move drawn by the last call to Next.
+func (it *MoveIterator) Move() Move {
+	return it.move
+}
+
+// moveTo applies move to sol in place and restores the capacity of the UAV it loads, the only one
+// a move can overload when sol was feasible.
+func (sol *UAVSolution) moveTo(move Move) {
+	sol.generatingMove = sol.applyMove(move)
+	numSlices := int32(len(sol.problem.devices.Slices()))
+	for slice := int32(0); slice < numSlices; slice++ {
+		if sol.uavDatarate[sol.key(move.NewUAV, slice)] > sol.problem.gateway.GetMaxDatarate(slice) && !sol.unloadGateway(uavSliceKey{move.NewUAV, slice}) {
+			panic("Impossible to fix Gateway")
+		}
+	}
+}
+
+// applyMove consolidates move on sol and returns it. Devices following an altitude move keep their
+// SF where the new position allows it, and the move returned holds the configuration of the first.
+func (sol *UAVSolution) applyMove(move Move) Move {
+	if move.Direction != DirectionAltitude {
+		sol.updateDeviceAssociation(move.DeviceId, uavConfigurationAssociation{move.NewUAV, move.NewConfig})
+		return move
+	}
+
+	for _, deviceId := range sol.GetDevicesAssignedTo(move.PrevUAV) {
+		sf := sol.problem.configurations[sol.GetAssignedConfigId(deviceId)].Sf
+		ass := sol.problem.getConfigurationForUAV(deviceId, move.NewUAV, sf)
+		if deviceId == move.DeviceId {
+			move.NewConfig = ass.configId
+		}
+		sol.updateDeviceAssociation(deviceId, ass)
+	}
+	return move
+}
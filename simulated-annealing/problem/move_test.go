@@ -0,0 +1,152 @@
+package problem
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/gateway"
+)
+
+// loadTestData reads the instance of the given seed with 50 devices and 64 candidate positions, at
+// the given altitudes when there are any.
+func loadTestData(t *testing.T, seed string, altitudes []float32) (*device.DeviceList, *gateway.CandidatePositionList, *gateway.Gateway) {
+	deviceList, err := device.ReadDeviceList("../data/endDevices_LNM_Placement_"+seed+"s+50d.dat", "../data/skl_"+seed+"s_64x1Gv_50D.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidatePosList, err := gateway.ReadCandidatePositionList("../data/equidistantPlacement_64.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(altitudes) > 0 {
+		candidatePosList = candidatePosList.WithAltitudes(altitudes)
+	}
+
+	gw := &gateway.Gateway{}
+	gw.SetSensitivity(map[int16]float32{7: -130.0, 8: -132.5, 9: -135.0, 10: -137.5, 11: -140.0, 12: -142.5})
+	for _, slice := range deviceList.Slices() {
+		gw.AddSlice(slice, 125000.0, 15197.75390625)
+	}
+	return deviceList, candidatePosList, gw
+}
+
+// loadTestInstance loads the instance of loadTestData with a fixed random seed.
+func loadTestInstance(t *testing.T, seed string, altitudes []float32) *UAVProblem {
+	deviceList, candidatePosList, gw := loadTestData(t, seed, altitudes)
+	instance, err := CreateUAVProblemInstance(100.0, 1.0, 0.5, 0.05, deviceList, candidatePosList, gw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance.SetRand(rand.New(rand.NewSource(1)))
+	if len(altitudes) > 0 {
+		instance.SetAltitudeChance(0.2)
+	}
+	return instance
+}
+
+var testInstances = []struct {
+	seed      string
+	altitudes []float32
+}{
+	{"1", nil},
+	{"3", nil},
+	{"3", []float32{30, 45, 60}},
+}
+
+func TestDeltaMatchesApply(t *testing.T) {
+	for _, test := range testInstances {
+		instance := loadTestInstance(t, test.seed, test.altitudes)
+		sol, err := GetRandomUAVSolution(instance)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		scored := 0
+		for i := 0; i < 5000; i++ {
+			var move Move
+			var found bool
+			switch i % 3 {
+			case 0:
+				move, found = sol.DrawMove()
+			case 1:
+				move, found = sol.DrawMoveIn(NeighbourhoodConfig)
+			case 2:
+				move, found = sol.DrawMoveIn(NeighbourhoodUAV)
+			}
+			if !found {
+				continue
+			}
+
+			before := sol.GetCost()
+			delta, known := sol.Delta(move)
+			sol.Apply(move)
+			if known {
+				scored++
+				if !costEqual(sol.GetCost()-before, delta) {
+					t.Fatalf("seed %s, move %d %+v: Delta is %f, applying it changed the cost by %f", test.seed, i, move, delta, sol.GetCost()-before)
+				}
+			}
+			sol.Commit()
+		}
+
+		if scored == 0 {
+			t.Errorf("seed %s: no move had a known delta", test.seed)
+		}
+		if report := instance.Validate(sol); !report.Valid() {
+			t.Fatalf("seed %s: %s", test.seed, report)
+		}
+	}
+}
+
+func TestUndoRestoresSolution(t *testing.T) {
+	for _, test := range testInstances {
+		instance := loadTestInstance(t, test.seed, test.altitudes)
+		sol, err := GetRandomUAVSolution(instance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rng := rand.New(rand.NewSource(2))
+
+		for i := 0; i < 2000; i++ {
+			hash, cost, loads := sol.Hash(), sol.GetCost(), slices.Clone(sol.uavDatarate)
+
+			// a few moves on top of each other, as SA applies them, from every neighbourhood
+			applied := 0
+			for n := 1 + rng.Intn(3); n > 0; n-- {
+				if neighbourhood := rng.Intn(len(Neighbourhoods) + 1); neighbourhood < len(Neighbourhoods) {
+					if _, found := sol.ApplyIn(Neighbourhoods[neighbourhood]); found {
+						applied++
+					}
+				} else if move, found := sol.DrawMove(); found {
+					sol.Apply(move)
+					applied++
+				}
+			}
+			if report := instance.Validate(sol); !report.Valid() {
+				t.Fatalf("seed %s, step %d, after %d moves: %s", test.seed, i, applied, report)
+			}
+
+			if rng.Intn(4) == 0 {
+				sol.Commit()
+				continue
+			}
+			for sol.Undo() {
+				applied--
+			}
+			if applied != 0 {
+				t.Fatalf("seed %s, step %d: %d moves were not undone", test.seed, i, applied)
+			}
+			if sol.Hash() != hash || !costEqual(sol.GetCost(), cost) {
+				t.Fatalf("seed %s, step %d: undo left hash %x and cost %f, want %x and %f", test.seed, i, sol.Hash(), sol.GetCost(), hash, cost)
+			}
+			if !slices.Equal(sol.uavDatarate, loads) {
+				t.Fatalf("seed %s, step %d: undo did not restore the UAV slice loads exactly", test.seed, i)
+			}
+			if report := instance.Validate(sol); !report.Valid() {
+				t.Fatalf("seed %s, step %d, after undo: %s", test.seed, i, report)
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package problem
+
+import (
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+)
+
+const (
+	DirectionClose int = 5
+	DirectionSwap  int = 6
+)
+
+// Neighbourhood names a neighbourhood structure of UAVSolution, from the smallest change to the
+// largest.
+type Neighbourhood int
+
+const (
+	// NeighbourhoodConfig changes the configuration of one device on its UAV
+	NeighbourhoodConfig Neighbourhood = iota
+	// NeighbourhoodUAV moves one device to another UAV
+	NeighbourhoodUAV
+	// NeighbourhoodClose withdraws a UAV, reassigning its devices to the other deployed UAVs
+	NeighbourhoodClose
+	// NeighbourhoodSwap exchanges the UAVs of two devices
+	NeighbourhoodSwap
+)
+
+var Neighbourhoods = []Neighbourhood{NeighbourhoodConfig, NeighbourhoodUAV, NeighbourhoodClose, NeighbourhoodSwap}
+
+func (neighbourhood Neighbourhood) String() string {
+	switch neighbourhood {
+	case NeighbourhoodConfig:
+		return "config"
+	case NeighbourhoodUAV:
+		return "uav"
+	case NeighbourhoodClose:
+		return "close"
+	case NeighbourhoodSwap:
+		return "swap"
+	default:
+		return "unknown"
+	}
+}
+
+// GetNeighbourIn draws a random neighbour of sol in the given neighbourhood. It returns false when
+// no move was found within a bounded number of tries.
+func (sol *UAVSolution) GetNeighbourIn(neighbourhood Neighbourhood) (*UAVSolution, bool) {
+	neighbour := sol.copy()
+	if _, found := neighbour.ApplyIn(neighbourhood); !found {
+		return sol, false
+	}
+	neighbour.Commit()
+	return neighbour, true
+}
+
+// DrawMoveIn draws a random move of one device in the config or uav neighbourhood without applying
+// it, so that it can be scored with Delta. It returns false for the other neighbourhoods, whose
+// moves change several devices, and when no move was found within a bounded number of tries.
+func (sol *UAVSolution) DrawMoveIn(neighbourhood Neighbourhood) (Move, bool) {
+	if neighbourhood != NeighbourhoodConfig && neighbourhood != NeighbourhoodUAV {
+		return Move{}, false
+	}
+
+	for maxTies := 50; maxTies > 0; maxTies-- {
+		deviceId := sol.problem.devices.GetRandomDevice(sol.problem.rng).GetId()
+		if move, found := sol.drawDeviceMove(deviceId, neighbourhood == NeighbourhoodUAV); found {
+			return move, true
+		}
+	}
+
+	return Move{}, false
+}
+
+// neighbourClose withdraws a random deployed UAV. Each of its devices moves, keeping its SF where
+// possible, to a random deployed UAV it reaches with capacity to spare. The solution is left half
+// changed when some device finds none, for ApplyIn to undo.
+func (sol *UAVSolution) neighbourClose(move *Move) bool {
+	if len(sol.deployedUavs) < 2 {
+		return false
+	}
+
+	rng := sol.problem.rng
+	uavId := sol.deployedUavs[rng.Intn(len(sol.deployedUavs))]
+	devices := sol.GetDevicesAssignedTo(uavId)
+	for i, deviceId := range devices {
+		configId := sol.GetAssignedConfigId(deviceId)
+		sf := sol.problem.configurations[configId].Sf
+
+		candidates := make([]int32, 0)
+		for _, candidate := range sol.problem.links.Uavs(deviceId) {
+			if candidate != uavId && sol.uavCount[candidate] > 0 {
+				candidates = append(candidates, candidate)
+			}
+		}
+		rng.Shuffle(len(candidates), func(a, b int) { candidates[a], candidates[b] = candidates[b], candidates[a] })
+
+		moved := false
+		for _, candidate := range candidates {
+			ass := sol.problem.getConfigurationForUAV(deviceId, candidate, sf)
+			if sol.hasRoomFor(deviceId, ass) {
+				if i == 0 {
+					*move = Move{deviceId, DirectionClose, configId, uavId, ass.configId, ass.uavId}
+				}
+				sol.updateDeviceAssociation(deviceId, ass)
+				moved = true
+				break
+			}
+		}
+		if !moved {
+			return false
+		}
+	}
+
+	return true
+}
+
+// neighbourSwap exchanges the UAVs of two random devices served by different UAVs, each keeping
+// its SF where possible. The solution is left half changed when the swap overloads a UAV, for
+// ApplyIn to undo.
+func (sol *UAVSolution) neighbourSwap(move *Move) bool {
+	if len(sol.deployedUavs) < 2 {
+		return false
+	}
+
+	rng := sol.problem.rng
+	device1 := sol.problem.devices.GetRandomDevice(rng).GetId()
+	device2 := sol.problem.devices.GetRandomDevice(rng).GetId()
+	uav1 := sol.GetAssignedUavId(device1)
+	uav2 := sol.GetAssignedUavId(device2)
+	if uav1 == uav2 {
+		return false
+	}
+	if len(sol.problem.links.Configs(device1, uav2)) == 0 ||
+		len(sol.problem.links.Configs(device2, uav1)) == 0 {
+		return false
+	}
+
+	config1 := sol.GetAssignedConfigId(device1)
+	config2 := sol.GetAssignedConfigId(device2)
+	ass1 := sol.problem.getConfigurationForUAV(device1, uav2, sol.problem.configurations[config1].Sf)
+	ass2 := sol.problem.getConfigurationForUAV(device2, uav1, sol.problem.configurations[config2].Sf)
+	sol.updateDeviceAssociation(device1, ass1)
+	sol.updateDeviceAssociation(device2, ass2)
+
+	for _, key := range []uavSliceKey{
+		{uav1, sol.problem.GetSlice(device1)}, {uav2, sol.problem.GetSlice(device1)},
+		{uav1, sol.problem.GetSlice(device2)}, {uav2, sol.problem.GetSlice(device2)},
+	} {
+		if sol.uavDatarate[sol.key(key.uavId, key.slice)] > sol.problem.gateway.GetMaxDatarate(key.slice) {
+			return false
+		}
+	}
+
+	*move = Move{device1, DirectionSwap, config1, uav1, ass1.configId, uav2}
+	return true
+}
+
+// hasRoomFor tells whether the UAV of association can take deviceId on top of its current load.
+func (sol *UAVSolution) hasRoomFor(deviceId device.DeviceId, association uavConfigurationAssociation) bool {
+	slice := sol.problem.GetSlice(deviceId)
+	sf := sol.problem.configurations[association.configId].Sf
+	key := sol.key(association.uavId, slice)
+	return sol.uavDatarate[key]+sol.problem.gateway.GetDatarate(sf, slice) <= sol.problem.gateway.GetMaxDatarate(slice)
+}
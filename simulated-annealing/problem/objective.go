@@ -0,0 +1,90 @@
+package problem
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+)
+
+// Objective is one of the quantities the weighted cost of a solution mixes, or another one worth
+// trading against them, for multi-objective solvers. Values are in natural units; Maximise tells
+// which way is better.
+type Objective struct {
+	Name     string
+	Maximise bool
+	value    func(sol *UAVSolution) float64
+}
+
+const (
+	ObjectiveUavs   = "uavs"
+	ObjectiveSfLoad = "sf-load"
+	ObjectivePower  = "power"
+	ObjectiveQoS    = "qos"
+)
+
+var ObjectiveNames = []string{ObjectiveUavs, ObjectiveSfLoad, ObjectivePower, ObjectiveQoS}
+
+var objectives = map[string]Objective{
+	ObjectiveUavs:   {Name: ObjectiveUavs, value: func(sol *UAVSolution) float64 { return float64(len(sol.deployedUavs)) }},
+	ObjectiveSfLoad: {Name: ObjectiveSfLoad, value: func(sol *UAVSolution) float64 { return float64(sol.GetMaxSfCount()) }},
+	ObjectivePower:  {Name: ObjectivePower, value: (*UAVSolution).GetTotalPower},
+	ObjectiveQoS:    {Name: ObjectiveQoS, Maximise: true, value: (*UAVSolution).GetMeanQoS},
+}
+
+// GetObjective looks an objective up by name: uavs is the number of deployed UAVs, sf-load the
+// number of devices on the most used SF, power the total transmit power in mW and qos the mean QoS
+// of the devices.
+func GetObjective(name string) (Objective, error) {
+	objective, found := objectives[name]
+	if !found {
+		return Objective{}, fmt.Errorf("unknown objective %q, valid objectives are %s", name, strings.Join(ObjectiveNames, ", "))
+	}
+	return objective, nil
+}
+
+func (objective Objective) Value(sol *UAVSolution) float64 {
+	return objective.value(sol)
+}
+
+// Minimised is the value negated for objectives to maximise, so that lower is always better.
+func (objective Objective) Minimised(sol *UAVSolution) float64 {
+	if objective.Maximise {
+		return -objective.value(sol)
+	}
+	return objective.value(sol)
+}
+
+func (sol *UAVSolution) GetMaxSfCount() int32 {
+	maxSfCount := int32(0)
+	for _, count := range sol.sfCount {
+		maxSfCount = max(maxSfCount, count)
+	}
+	return maxSfCount
+}
+
+// GetTotalPower sums the transmit power of the devices in mW.
+func (sol *UAVSolution) GetTotalPower() float64 {
+	power := 0.0
+	for deviceId, uavId := range sol.assignedUav {
+		if uavId >= 0 {
+			power += math.Pow(10, float64(sol.problem.configurations[sol.assignedConfig[deviceId]].Tp)/10)
+		}
+	}
+	return power
+}
+
+func (sol *UAVSolution) GetMeanQoS() float64 {
+	qos, assigned := 0.0, 0
+	for deviceId, uavId := range sol.assignedUav {
+		if uavId >= 0 {
+			qos += float64(sol.problem.GetQoS(device.DeviceId(deviceId), sol.assignedConfig[deviceId]))
+			assigned++
+		}
+	}
+	if assigned == 0 {
+		return 0
+	}
+	return qos / float64(assigned)
+}
@@ -0,0 +1,199 @@
+package problem
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/utils"
+)
+
+// speed of light in m/s
+const lightSpeed = 299792458.0
+
+// PathLossModel gives the attenuation in dB of the link between a device and a UAV. String
+// describes the model and its parameters, and is part of the instance fingerprint.
+type PathLossModel interface {
+	PathLoss(devicePos, uavPos utils.Position) float64
+	String() string
+}
+
+// ReachBounded is implemented by the path loss models that can bound how far a link with a given
+// loss reaches: beyond MaxDistance(loss) every link loses more. Problem construction then skips the
+// candidate positions farther from a device than any configuration reaches.
+type ReachBounded interface {
+	MaxDistance(loss float64) float64
+}
+
+// LogDistance is PL(d) = ReferenceLoss + 10 * Exponent * log10(d / ReferenceDistance), with no
+// attenuation beyond the reference loss closer than the reference distance.
+type LogDistance struct {
+	ReferenceLoss     float64
+	ReferenceDistance float64
+	Exponent          float64
+}
+
+// DefaultPathLoss is the log-distance model the instances were designed with.
+func DefaultPathLoss() LogDistance {
+	return LogDistance{
+		ReferenceLoss:     ReferencePrx,
+		ReferenceDistance: ReferenceDistance,
+		Exponent:          AttenuationExponent,
+	}
+}
+
+func (model LogDistance) PathLoss(devicePos, uavPos utils.Position) float64 {
+	distance := float64(uavPos.DistanceFrom(devicePos))
+	if distance <= model.ReferenceDistance {
+		return model.ReferenceLoss
+	}
+	return model.ReferenceLoss + 10*model.Exponent*math.Log10(distance/model.ReferenceDistance)
+}
+
+func (model LogDistance) MaxDistance(loss float64) float64 {
+	if model.Exponent <= 0 {
+		return math.Inf(1)
+	}
+	if loss < model.ReferenceLoss {
+		return 0
+	}
+	return model.ReferenceDistance * math.Pow(10, (loss-model.ReferenceLoss)/(10*model.Exponent))
+}
+
+func (model LogDistance) String() string {
+	return fmt.Sprintf("log-distance(referenceLoss=%g,referenceDistance=%g,exponent=%g)", model.ReferenceLoss, model.ReferenceDistance, model.Exponent)
+}
+
+// FreeSpace is the Friis free-space path loss at Frequency, in MHz.
+type FreeSpace struct {
+	Frequency float64
+}
+
+func (model FreeSpace) PathLoss(devicePos, uavPos utils.Position) float64 {
+	return freeSpaceLoss(float64(uavPos.DistanceFrom(devicePos)), model.Frequency)
+}
+
+func (model FreeSpace) MaxDistance(loss float64) float64 {
+	return maxFreeSpaceDistance(loss, model.Frequency)
+}
+
+func (model FreeSpace) String() string {
+	return fmt.Sprintf("free-space(frequency=%g)", model.Frequency)
+}
+
+// freeSpaceLoss takes the distance in m and the frequency in MHz. Distances under 1 m are taken as
+// 1 m, where the far field approximation stops making sense anyway.
+func freeSpaceLoss(distance, frequency float64) float64 {
+	distance = max(distance, 1)
+	return 20*math.Log10(distance) + 20*math.Log10(frequency*1e6) + 20*math.Log10(4*math.Pi/lightSpeed)
+}
+
+// maxFreeSpaceDistance inverts freeSpaceLoss.
+func maxFreeSpaceDistance(loss, frequency float64) float64 {
+	exponent := (loss - 20*math.Log10(frequency*1e6) - 20*math.Log10(4*math.Pi/lightSpeed)) / 20
+	if exponent < 0 {
+		return 0
+	}
+	return math.Pow(10, exponent)
+}
+
+type HataEnvironment string
+
+const (
+	HataUrban    HataEnvironment = "urban"
+	HataSuburban HataEnvironment = "suburban"
+)
+
+// OkumuraHata is the Hata model for small and medium cities at Frequency, in MHz, with the UAV as
+// the base station. Heights are taken from the Z coordinates, clamped to at least 1 m, and
+// distances under 10 m are taken as 10 m. The loss falls with the height of the device, which is not
+// bounded, so the model does not bound its reach.
+type OkumuraHata struct {
+	Frequency   float64
+	Environment HataEnvironment
+}
+
+func (model OkumuraHata) PathLoss(devicePos, uavPos utils.Position) float64 {
+	distance := max(float64(uavPos.DistanceFrom(devicePos)), 10) / 1000
+	baseHeight := max(float64(uavPos.Z), 1)
+	mobileHeight := max(float64(devicePos.Z), 1)
+	logFrequency := math.Log10(model.Frequency)
+
+	mobileCorrection := (1.1*logFrequency-0.7)*mobileHeight - (1.56*logFrequency - 0.8)
+	loss := 69.55 + 26.16*logFrequency - 13.82*math.Log10(baseHeight) - mobileCorrection +
+		(44.9-6.55*math.Log10(baseHeight))*math.Log10(distance)
+
+	if model.Environment == HataSuburban {
+		loss -= 2*math.Pow(math.Log10(model.Frequency/28), 2) + 5.4
+	}
+	return loss
+}
+
+func (model OkumuraHata) String() string {
+	return fmt.Sprintf("okumura-hata(frequency=%g,environment=%s)", model.Frequency, model.Environment)
+}
+
+// AirToGround is the Al-Hourani model: free-space loss plus an excess loss averaged over the
+// probability of line of sight, which grows with the elevation angle of the UAV as seen from the
+// device. A and B shape that probability and LoSLoss and NLoSLoss are the excess losses in dB.
+//
+// With a positive Reliability the link must close with at least that probability rather than on
+// average: the LoS excess loss applies where the LoS probability reaches Reliability, and the NLoS
+// one elsewhere. Raising a UAV then trades distance for a steeper, more reliable link.
+type AirToGround struct {
+	Frequency   float64
+	A           float64
+	B           float64
+	LoSLoss     float64
+	NLoSLoss    float64
+	Reliability float64
+}
+
+// AirToGroundEnvironments holds the parameters fitted by Al-Hourani et al. for each environment.
+var AirToGroundEnvironments = map[string]AirToGround{
+	"suburban":    {A: 4.88, B: 0.43, LoSLoss: 0.1, NLoSLoss: 21},
+	"urban":       {A: 9.61, B: 0.16, LoSLoss: 1, NLoSLoss: 20},
+	"dense-urban": {A: 12.08, B: 0.11, LoSLoss: 1.6, NLoSLoss: 23},
+	"highrise":    {A: 27.23, B: 0.08, LoSLoss: 2.3, NLoSLoss: 34},
+}
+
+// LoSProbability takes the elevation angle in degrees.
+func (model AirToGround) LoSProbability(elevation float64) float64 {
+	return 1 / (1 + model.A*math.Exp(-model.B*(elevation-model.A)))
+}
+
+func (model AirToGround) PathLoss(devicePos, uavPos utils.Position) float64 {
+	distance := float64(uavPos.DistanceFrom(devicePos))
+	probability := model.LoSProbability(elevationAngle(devicePos, uavPos))
+	if model.Reliability > 0 {
+		if probability >= model.Reliability {
+			return freeSpaceLoss(distance, model.Frequency) + model.LoSLoss
+		}
+		return freeSpaceLoss(distance, model.Frequency) + model.NLoSLoss
+	}
+	return freeSpaceLoss(distance, model.Frequency) + probability*model.LoSLoss + (1-probability)*model.NLoSLoss
+}
+
+// MaxDistance bounds the excess loss by the lower of the LoS and NLoS ones, which holds whatever the
+// elevation angle.
+func (model AirToGround) MaxDistance(loss float64) float64 {
+	return maxFreeSpaceDistance(loss-min(model.LoSLoss, model.NLoSLoss), model.Frequency)
+}
+
+func (model AirToGround) String() string {
+	description := fmt.Sprintf("air-to-ground(frequency=%g,a=%g,b=%g,losLoss=%g,nlosLoss=%g", model.Frequency, model.A, model.B, model.LoSLoss, model.NLoSLoss)
+	if model.Reliability > 0 {
+		description += fmt.Sprintf(",reliability=%g", model.Reliability)
+	}
+	return description + ")"
+}
+
+// elevationAngle is the angle in degrees between the ground plane and the line from the device to
+// the UAV.
+func elevationAngle(devicePos, uavPos utils.Position) float64 {
+	distance := float64(uavPos.DistanceFrom(devicePos))
+	if distance == 0 {
+		return 90
+	}
+	height := float64(uavPos.Z) - float64(devicePos.Z)
+	return math.Asin(max(min(height/distance, 1), -1)) * 180 / math.Pi
+}
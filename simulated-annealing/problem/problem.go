@@ -10,13 +10,14 @@ import (
 	"math"
 	"math/rand"
 	"slices"
+	"time"
 )
 
 const (
-	// Path Loss Parameters
-	ReferencePrx        float32 = 10.0
-	ReferenceDistance   float32 = 1.0
-	AttenuationExponent float32 = 3.76
+	// Parameters of the default log-distance path loss model
+	ReferencePrx        float64 = 10.0
+	ReferenceDistance   float64 = 1.0
+	AttenuationExponent float64 = 3.76
 
 	// Quality of Service Parameters
 	CondingRate float32 = 4.0 / 5.0
@@ -41,57 +42,50 @@ type Problem interface {
 	GetDatarate(sf int16, slice int32) float32
 	GetMaxDatarate(slice int32) float32
 	GetSlice(deviceId device.DeviceId) int32
+	GetRand() *rand.Rand
 	Copy() Problem
 }
 
-type deviceGatewayAssociation struct {
-	deviceId device.DeviceId
-	uavId    int32
-}
-
 type uavConfigurationAssociation struct {
 	uavId    int32
 	configId int32
 }
 
 type UAVProblem struct {
-	gateway                *gateway.Gateway
-	devices                *device.DeviceList
-	uavPositions           *gateway.CandidatePositionList
-	configurations         map[int32]*device.Configuration
-	possibleConfigurations map[deviceGatewayAssociation][]int32
-	possibleSFs            map[deviceGatewayAssociation][]int
-	possibleUavs           map[device.DeviceId][]int32
-	coverageMap            map[int32][]device.DeviceId
-	alpha                  float64
-	beta                   float64
-	changeUav              float64
-	newUavChance           float64
-	currentSolution        *UAVSolution
-	bestSolution           *UAVSolution
+	gateway         *gateway.Gateway
+	devices         *device.DeviceList
+	uavPositions    *gateway.CandidatePositionList
+	configurations  map[int32]*device.Configuration
+	links           *LinkBudget
+	pathLoss        PathLossModel
+	alpha           float64
+	beta            float64
+	changeUav       float64
+	newUavChance    float64
+	altitudeChance  float64
+	currentSolution *UAVSolution
+	bestSolution    *UAVSolution
+	rng             *rand.Rand
 }
 
 func (problem *UAVProblem) copy() *UAVProblem {
 
 	problemCopy := &UAVProblem{
-		gateway:                problem.gateway.Copy(),
-		devices:                problem.devices.Copy(),
-		uavPositions:           problem.uavPositions.Copy(),
-		configurations:         make(map[int32]*device.Configuration, 0),
-		possibleConfigurations: make(map[deviceGatewayAssociation][]int32, 0),
-		possibleUavs:           make(map[device.DeviceId][]int32),
-		coverageMap:            make(map[int32][]device.DeviceId),
-		alpha:                  problem.alpha,
-		beta:                   problem.beta,
-		changeUav:              problem.changeUav,
-		newUavChance:           problem.newUavChance,
+		gateway:        problem.gateway.Copy(),
+		devices:        problem.devices.Copy(),
+		uavPositions:   problem.uavPositions.Copy(),
+		configurations: make(map[int32]*device.Configuration, 0),
+		links:          problem.links,
+		pathLoss:       problem.pathLoss,
+		alpha:          problem.alpha,
+		beta:           problem.beta,
+		changeUav:      problem.changeUav,
+		newUavChance:   problem.newUavChance,
+		altitudeChance: problem.altitudeChance,
+		rng:            rand.New(rand.NewSource(problem.rng.Int63())),
 	}
 
 	problemCopy.configurations = maps.Clone(problem.configurations)
-	problemCopy.possibleConfigurations = maps.Clone(problem.possibleConfigurations)
-	problemCopy.possibleSFs = maps.Clone(problem.possibleSFs)
-	problemCopy.possibleUavs = maps.Clone(problem.possibleUavs)
-	problemCopy.coverageMap = maps.Clone(problem.coverageMap)
 
 	return problemCopy
 }
@@ -100,6 +94,22 @@ func (problem *UAVProblem) Copy() Problem {
 	return problem.copy()
 }
 
+// withRand returns a view of the problem sharing all its data but drawing random numbers from rng,
+// so that solutions can be built concurrently without contending on a single source.
+func (problem *UAVProblem) withRand(rng *rand.Rand) *UAVProblem {
+	view := *problem
+	view.rng = rng
+	return &view
+}
+
+func (problem *UAVProblem) SetRand(rng *rand.Rand) {
+	problem.rng = rng
+}
+
+func (problem *UAVProblem) GetRand() *rand.Rand {
+	return problem.rng
+}
+
 func (problem *UAVProblem) GetDeviceIds() []device.DeviceId {
 	return problem.devices.GetDeviceIds()
 }
@@ -109,19 +119,19 @@ func (problem *UAVProblem) GetUAVIds() []int32 {
 }
 
 func (problem *UAVProblem) GetPossibleUavs(deviceId device.DeviceId) []int32 {
-	return problem.possibleUavs[deviceId]
+	return problem.links.Uavs(deviceId)
 }
 
 func (problem *UAVProblem) GetPossibleConfigs(deviceId device.DeviceId, uavId int32) []int32 {
-	return problem.possibleConfigurations[deviceGatewayAssociation{deviceId, uavId}]
+	return problem.links.Configs(deviceId, uavId)
 }
 
 func (problem *UAVProblem) GetPossibleSFs(devId device.DeviceId, uavId int32) []int {
-	return slices.Clone(problem.possibleSFs[deviceGatewayAssociation{devId, uavId}])
+	return slices.Clone(problem.links.SFs(devId, uavId))
 }
 
 func (problem *UAVProblem) GetCoverage(uavId int32) []device.DeviceId {
-	return slices.Clone(problem.coverageMap[uavId])
+	return slices.Clone(problem.links.Devices(uavId))
 }
 
 func (problem *UAVProblem) GetDatarate(sf int16, slice int32) float32 {
@@ -137,23 +147,22 @@ func (problem *UAVProblem) GetSlice(deviceId device.DeviceId) int32 {
 }
 
 func (problem *UAVProblem) checkReachFeasibility(deviceId device.DeviceId, uavId, configId int32) bool {
-	tp := float32(problem.configurations[configId].Tp)
+	tp := float64(problem.configurations[configId].Tp)
 	sf := problem.configurations[configId].Sf
 
 	devicePos := problem.devices.GetDevice(deviceId).GetPosition()
 	candidatePos := problem.uavPositions.GetCandidatePosition(uavId)
-	distance := candidatePos.DistanceFrom(devicePos) / ReferenceDistance
+	pathloss := problem.pathLoss.PathLoss(devicePos, candidatePos)
 
-	pathloss := float32(0.0)
-	if distance > ReferenceDistance {
-		pathloss = 10.0 * AttenuationExponent * float32(math.Log10(float64(distance/ReferenceDistance)))
-	}
+	return tp-pathloss >= float64(problem.gateway.GetSensitivityForSf(sf))
+}
 
-	if tp-ReferencePrx-pathloss >= problem.gateway.GetSensitivityForSf(sf) {
-		return true
-	} else {
-		return false
-	}
+func (problem *UAVProblem) GetPathLoss() PathLossModel {
+	return problem.pathLoss
+}
+
+func (problem *UAVProblem) GetLinkBudget() *LinkBudget {
+	return problem.links
 }
 
 func (problem *UAVProblem) checkQoSFeasibility(deviceId device.DeviceId, configId int32) bool {
@@ -204,112 +213,72 @@ func (problem *UAVProblem) GetChanceOfChangingUAV() float64 {
 	return problem.changeUav
 }
 
-func (problem *UAVProblem) SetBestSolution(sol Solution) {
-	problem.bestSolution = sol.(*UAVSolution)
+// SetAltitudeChance sets the probability of a neighbour move raising or lowering a deployed UAV,
+// with all its devices, to another candidate over the same ground point.
+func (problem *UAVProblem) SetAltitudeChance(altitudeChance float64) {
+	problem.altitudeChance = altitudeChance
 }
 
-func (problem *UAVProblem) SetCurrentSolution(sol Solution) {
-	problem.currentSolution = sol.(*UAVSolution)
+func (problem *UAVProblem) GetAltitudeChance() float64 {
+	return problem.altitudeChance
 }
 
-func (problem *UAVProblem) GetBestSolution() Solution {
-	return problem.bestSolution
+func (problem *UAVProblem) GetAltitudes(uavId int32) []int32 {
+	return problem.uavPositions.GetAltitudes(uavId)
 }
 
-func (problem *UAVProblem) processPossibleConfigurationPerDevice() error {
-	numDevices := problem.devices.Count()
-	numCombinations := numDevices * problem.uavPositions.Count()
-	problem.possibleConfigurations = make(map[deviceGatewayAssociation][]int32, numCombinations)
-	problem.possibleSFs = make(map[deviceGatewayAssociation][]int, numCombinations)
-	problem.possibleUavs = make(map[device.DeviceId][]int32, numDevices)
-	problem.coverageMap = make(map[int32][]device.DeviceId, 1)
-
-	// for each device/candidate position association, check which configurations are feasible
-	for deviceId := device.DeviceId(0); deviceId < device.DeviceId(problem.devices.Count()); deviceId++ {
-		isFeasible := false
-		for uavId := int32(0); uavId < problem.uavPositions.Count(); uavId++ {
-			configs := make([]int32, 0)
-			sfs := make([]int, 0)
-			for configId := range problem.configurations {
-				sf := device.GetSF(configId)
-				// verify whether the configuration satisfy the qos bound for the device
-				if !problem.checkQoSFeasibility(deviceId, configId) {
-					continue
-				}
-
-				// verify whether the device can reach the candidate position given configuration
-				if !problem.checkReachFeasibility(deviceId, uavId, configId) {
-					continue
-				}
-
-				// feasible association
-				configs = append(configs, configId)
-				if !slices.Contains(sfs, sf) {
-					sfs = append(sfs, sf)
-				}
-			}
-
-			// if at least one configuration was found for the association, the problem is feasible
-			// for this device
-			if len(configs) > 0 {
-				isFeasible = true
-				association := deviceGatewayAssociation{deviceId, uavId}
-				slices.Sort(configs)
-				slices.Sort(sfs)
-				problem.possibleConfigurations[association] = configs
-				problem.possibleSFs[association] = sfs
-				problem.possibleUavs[deviceId] = append(problem.possibleUavs[deviceId], uavId)
-				problem.coverageMap[uavId] = append(problem.coverageMap[uavId], deviceId)
-			}
-		}
-
-		if !isFeasible {
-			return fmt.Errorf("unfeasibility: none candidate position is able to reach device %d", deviceId)
-		}
-	}
+func (problem *UAVProblem) GetAlpha() float64 {
+	return problem.alpha
+}
 
-	// !!!! DEBUG: print possible configurations !!!!
+func (problem *UAVProblem) GetBeta() float64 {
+	return problem.beta
+}
 
-	// for key, value := range problem.possibleConfigurations {
-	// 	fmt.Printf("%+v: %+v\n", key, value)
-	// }
+func (problem *UAVProblem) SetBestSolution(sol Solution) {
+	problem.bestSolution = sol.(*UAVSolution)
+}
 
-	// !!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!
+func (problem *UAVProblem) SetCurrentSolution(sol Solution) {
+	problem.currentSolution = sol.(*UAVSolution)
+}
 
-	return nil
+func (problem *UAVProblem) GetBestSolution() Solution {
+	return problem.bestSolution
 }
 
 func (problem *UAVProblem) getRandomUavConfiguration(deviceId device.DeviceId) uavConfigurationAssociation {
-	uavRandIdx := rand.Int31n(int32(len(problem.possibleUavs[deviceId])))
-	uavRandId := problem.possibleUavs[deviceId][uavRandIdx]
+	possibleUavs := problem.links.Uavs(deviceId)
+	uavRandIdx := problem.rng.Int31n(int32(len(possibleUavs)))
+	uavRandId := possibleUavs[uavRandIdx]
 
-	association := deviceGatewayAssociation{deviceId, uavRandId}
-	numPossibleConfigs := len(problem.possibleConfigurations[association])
+	configs := problem.links.Configs(deviceId, uavRandId)
+	numPossibleConfigs := len(configs)
 
-	configRandIdx := rand.Int31n(int32(numPossibleConfigs))
-	configRandId := problem.possibleConfigurations[association][configRandIdx]
+	configRandIdx := problem.rng.Int31n(int32(numPossibleConfigs))
+	configRandId := configs[configRandIdx]
 
 	return uavConfigurationAssociation{uavRandId, configRandId}
 }
 
 func (problem *UAVProblem) getConfigurationForUAV(deviceId device.DeviceId, uavId int32, SF int16) uavConfigurationAssociation {
-	association := deviceGatewayAssociation{deviceId, uavId}
-	numPossibleConfigs := len(problem.possibleConfigurations[association])
+	configs := problem.links.Configs(deviceId, uavId)
+	numPossibleConfigs := len(configs)
 
-	for _, configId := range problem.possibleConfigurations[association] {
+	for _, configId := range configs {
 		if problem.configurations[configId].Sf == SF {
 			return uavConfigurationAssociation{uavId, configId}
 		}
 	}
 
-	configRandIdx := rand.Int31n(int32(numPossibleConfigs))
-	configRandId := problem.possibleConfigurations[association][configRandIdx]
+	configRandIdx := problem.rng.Int31n(int32(numPossibleConfigs))
+	configRandId := configs[configRandIdx]
 
 	return uavConfigurationAssociation{uavId, configRandId}
 }
 
 func (problem *UAVProblem) getRandomUavConfigurationTabu(deviceId device.DeviceId, used, tabu []int32, tabuRatio, currTabuRatio float32) uavConfigurationAssociation {
-	possibleUavs := problem.possibleUavs[deviceId]
+	possibleUavs := problem.links.Uavs(deviceId)
 
 	if currTabuRatio >= tabuRatio {
 		usedTabuUavs := utils.Intersection(tabu, used)
@@ -320,14 +289,14 @@ func (problem *UAVProblem) getRandomUavConfigurationTabu(deviceId device.DeviceI
 		possibleUavs = append(possibleUavs, possibleUsedTabuUavs...)
 	}
 
-	uavRandIdx := rand.Int31n(int32(len(possibleUavs)))
+	uavRandIdx := problem.rng.Int31n(int32(len(possibleUavs)))
 	uavRandId := possibleUavs[uavRandIdx]
 
-	association := deviceGatewayAssociation{deviceId, uavRandId}
-	numPossibleConfigs := len(problem.possibleConfigurations[association])
+	configs := problem.links.Configs(deviceId, uavRandId)
+	numPossibleConfigs := len(configs)
 
-	configRandIdx := rand.Int31n(int32(numPossibleConfigs))
-	configRandId := problem.possibleConfigurations[association][configRandIdx]
+	configRandIdx := problem.rng.Int31n(int32(numPossibleConfigs))
+	configRandId := configs[configRandIdx]
 
 	return uavConfigurationAssociation{uavRandId, configRandId}
 }
@@ -355,8 +324,8 @@ func (problem *UAVProblem) neighbourUav(deviceId device.DeviceId, uavId, configI
 	uavNewId := (*possibleUavs)[uavNewIdx]
 
 	// Adjust Configuration if needed
-	devGwAss := deviceGatewayAssociation{deviceId, uavNewId}
-	_, found = slices.BinarySearch(problem.possibleConfigurations[devGwAss], configId)
+	configs := problem.links.Configs(deviceId, uavNewId)
+	_, found = slices.BinarySearch(configs, configId)
 
 	if found {
 		return uavConfigurationAssociation{uavNewId, configId}
@@ -421,8 +390,8 @@ func (problem *UAVProblem) neighbourUavTabu(deviceId device.DeviceId, uavId, con
 	uavNewId := (*possibleUavs)[uavNewIdx]
 
 	// Adjust Configuration if needed
-	devGwAss := deviceGatewayAssociation{deviceId, uavNewId}
-	_, found = slices.BinarySearch(problem.possibleConfigurations[devGwAss], configId)
+	configs := problem.links.Configs(deviceId, uavNewId)
+	_, found = slices.BinarySearch(configs, configId)
 
 	if found {
 		return uavConfigurationAssociation{uavNewId, configId}
@@ -432,20 +401,20 @@ func (problem *UAVProblem) neighbourUavTabu(deviceId device.DeviceId, uavId, con
 }
 
 func (problem *UAVProblem) nextUav(deviceId device.DeviceId, uavId, configId int32) uavConfigurationAssociation {
-	possibleUavs := make([]int32, len(problem.possibleUavs[deviceId]))
-	copy(possibleUavs, problem.possibleUavs[deviceId])
+	possibleUavs := make([]int32, len(problem.links.Uavs(deviceId)))
+	copy(possibleUavs, problem.links.Uavs(deviceId))
 	return problem.neighbourUav(deviceId, uavId, configId, &possibleUavs, true)
 }
 
 func (problem *UAVProblem) previousUav(deviceId device.DeviceId, uavId, configId int32) uavConfigurationAssociation {
-	possibleUavs := make([]int32, len(problem.possibleUavs[deviceId]))
-	copy(possibleUavs, problem.possibleUavs[deviceId])
+	possibleUavs := make([]int32, len(problem.links.Uavs(deviceId)))
+	copy(possibleUavs, problem.links.Uavs(deviceId))
 	return problem.neighbourUav(deviceId, uavId, configId, &possibleUavs, false)
 }
 
 func (problem *UAVProblem) nextDeployedUav(deviceId device.DeviceId, uavId, configId int32, sol *UAVSolution, tabu []int32) uavConfigurationAssociation {
 	// Possible UAVs are the intersection between those available for the device and the deployed ones
-	possibleUavs := utils.Intersection(problem.possibleUavs[deviceId], sol.deployedUavs)
+	possibleUavs := sol.filterUavs(problem.links.Uavs(deviceId), func(uav int32) bool { return sol.uavCount[uav] > 0 })
 
 	if len(tabu) > 0 {
 		return problem.neighbourUavTabu(deviceId, uavId, configId, &possibleUavs, tabu, true)
@@ -455,7 +424,7 @@ func (problem *UAVProblem) nextDeployedUav(deviceId device.DeviceId, uavId, conf
 
 func (problem *UAVProblem) previousDeployedUav(deviceId device.DeviceId, uavId, configId int32, sol *UAVSolution, tabu []int32) uavConfigurationAssociation {
 	// Possible UAVs are the intersection between those available for the device and the deployed ones
-	possibleUavs := utils.Intersection(problem.possibleUavs[deviceId], sol.deployedUavs)
+	possibleUavs := sol.filterUavs(problem.links.Uavs(deviceId), func(uav int32) bool { return sol.uavCount[uav] > 0 })
 
 	if len(tabu) > 0 {
 		return problem.neighbourUavTabu(deviceId, uavId, configId, &possibleUavs, tabu, false)
@@ -465,9 +434,7 @@ func (problem *UAVProblem) previousDeployedUav(deviceId device.DeviceId, uavId,
 
 func (problem *UAVProblem) nextNewUav(deviceId device.DeviceId, uavId, configId int32, sol *UAVSolution, tabu []int32, percentage float32) uavConfigurationAssociation {
 	// Possible UAVs are the intersection between those available for the device and the deployed ones
-	complement := []int32{uavId}
-	complement = append(complement, utils.Complement(sol.deployedUavs, problem.uavPositions.GetCandidatePositionIdList())...)
-	possibleUavs := utils.Intersection(problem.possibleUavs[deviceId], complement)
+	possibleUavs := sol.filterUavs(problem.links.Uavs(deviceId), func(uav int32) bool { return uav == uavId || sol.uavCount[uav] == 0 })
 
 	if len(tabu) > 0 {
 		deployedTabuUAVS := utils.Intersection(sol.deployedUavs, tabu)
@@ -482,9 +449,7 @@ func (problem *UAVProblem) nextNewUav(deviceId device.DeviceId, uavId, configId
 
 func (problem *UAVProblem) previousNewUav(deviceId device.DeviceId, uavId, configId int32, sol *UAVSolution, tabu []int32, percentage float32) uavConfigurationAssociation {
 	// Possible UAVs are the intersection between those available for the device and the deployed ones
-	complement := []int32{uavId}
-	complement = append(complement, utils.Complement(sol.deployedUavs, problem.uavPositions.GetCandidatePositionIdList())...)
-	possibleUavs := utils.Intersection(problem.possibleUavs[deviceId], complement)
+	possibleUavs := sol.filterUavs(problem.links.Uavs(deviceId), func(uav int32) bool { return uav == uavId || sol.uavCount[uav] == 0 })
 
 	if len(tabu) > 0 {
 		deployedTabuUAVS := utils.Intersection(sol.deployedUavs, tabu)
@@ -498,9 +463,9 @@ func (problem *UAVProblem) previousNewUav(deviceId device.DeviceId, uavId, confi
 }
 
 func (problem *UAVProblem) nextConfig(deviceId device.DeviceId, uavId, configId int32) uavConfigurationAssociation {
-	devGwAss := deviceGatewayAssociation{deviceId, uavId}
-	configIdx, found := slices.BinarySearch(problem.possibleConfigurations[devGwAss], configId)
-	numPossibleConfigs := len(problem.possibleConfigurations[devGwAss])
+	configs := problem.links.Configs(deviceId, uavId)
+	configIdx, found := slices.BinarySearch(configs, configId)
+	numPossibleConfigs := len(configs)
 
 	if found {
 		// iterate config
@@ -510,22 +475,22 @@ func (problem *UAVProblem) nextConfig(deviceId device.DeviceId, uavId, configId
 		configIdx = int(math.Mod(float64(configIdx), float64(numPossibleConfigs)))
 	}
 
-	configNextId := problem.possibleConfigurations[devGwAss][configIdx]
+	configNextId := configs[configIdx]
 
 	return uavConfigurationAssociation{uavId, configNextId}
 }
 
 func (problem *UAVProblem) previousConfig(deviceId device.DeviceId, uavId, configId int32) uavConfigurationAssociation {
-	devGwAss := deviceGatewayAssociation{deviceId, uavId}
-	configIdx, _ := slices.BinarySearch(problem.possibleConfigurations[devGwAss], configId)
-	numPossibleConfigs := len(problem.possibleConfigurations[devGwAss])
+	configs := problem.links.Configs(deviceId, uavId)
+	configIdx, _ := slices.BinarySearch(configs, configId)
+	numPossibleConfigs := len(configs)
 
 	configIdx = configIdx - 1
 	if configIdx < 0 {
 		configIdx = numPossibleConfigs - 1
 	}
 
-	configPrevId := problem.possibleConfigurations[devGwAss][configIdx]
+	configPrevId := configs[configIdx]
 
 	return uavConfigurationAssociation{uavId, configPrevId}
 }
@@ -533,11 +498,15 @@ func (problem *UAVProblem) previousConfig(deviceId device.DeviceId, uavId, confi
 func (problem *UAVProblem) PrintCurrentSolution() {
 	numDevices := problem.devices.Count()
 	for key := 0; key < int(numDevices); key++ {
-		fmt.Printf("Device %d -> %+v\n", key, problem.currentSolution.deviceAssociation[device.DeviceId(key)])
+		fmt.Printf("Device %d -> %+v\n", key, problem.currentSolution.association(device.DeviceId(key)))
 	}
 }
 
 func CreateUAVProblemInstance(alpha, beta, changeUav, newUavChance float64, devices *device.DeviceList, uavPositions *gateway.CandidatePositionList, gateway *gateway.Gateway) (*UAVProblem, error) {
+	return CreateUAVProblemInstanceWithPathLoss(alpha, beta, changeUav, newUavChance, DefaultPathLoss(), devices, uavPositions, gateway)
+}
+
+func CreateUAVProblemInstanceWithPathLoss(alpha, beta, changeUav, newUavChance float64, pathLoss PathLossModel, devices *device.DeviceList, uavPositions *gateway.CandidatePositionList, gateway *gateway.Gateway) (*UAVProblem, error) {
 	configs := make(map[int32]*device.Configuration, device.GetNumConfigurations())
 
 	for sf := device.MinSF; sf <= device.MaxSF; sf++ {
@@ -554,13 +523,16 @@ func CreateUAVProblemInstance(alpha, beta, changeUav, newUavChance float64, devi
 		devices:        devices,
 		uavPositions:   uavPositions,
 		configurations: configs,
+		pathLoss:       pathLoss,
 		alpha:          alpha,
 		beta:           beta,
 		changeUav:      changeUav,
 		newUavChance:   newUavChance,
+		rng:            rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 
-	err := problem.processPossibleConfigurationPerDevice()
+	var err error
+	problem.links, err = createLinkBudget(problem)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,327 @@
+package problem
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+)
+
+const SolutionFormatVersion = 1
+
+// SolutionRecord is the serialized form of a UAVSolution. The fingerprint ties it to the instance it
+// was computed on and the costs are the ones reported when it was written.
+type SolutionRecord struct {
+	Version     int                `json:"version"`
+	Fingerprint string             `json:"fingerprint"`
+	Cost        float64            `json:"cost"`
+	CostA       float64            `json:"costA"`
+	CostB       float64            `json:"costB"`
+	Devices     []DeviceAssignment `json:"devices"`
+}
+
+// DeviceAssignment holds the UAV and configuration of a device. Sf and Tp are redundant with Config
+// and only there to keep the files readable.
+type DeviceAssignment struct {
+	Device int32 `json:"device"`
+	Uav    int32 `json:"uav"`
+	Config int32 `json:"config"`
+	Sf     int16 `json:"sf"`
+	Tp     int16 `json:"tp"`
+}
+
+var recordHeader = []string{"device", "uav", "config", "sf", "tp"}
+
+// Fingerprint hashes everything the cost and feasibility of a solution depend on: device positions
+// and slices, candidate positions, gateway parameters, path loss model and cost weights. The default
+// path loss model is left out, so that instances using it keep the fingerprint they had before
+// models could be chosen.
+func (problem *UAVProblem) Fingerprint() string {
+	hash := sha256.New()
+	write := func(values ...any) {
+		for _, value := range values {
+			binary.Write(hash, binary.LittleEndian, value)
+		}
+	}
+
+	write(problem.devices.Count())
+	for _, deviceId := range problem.devices.GetDeviceIds() {
+		dev := problem.devices.GetDevice(deviceId)
+		pos := dev.GetPosition()
+		write(int32(deviceId), pos.X, pos.Y, pos.Z, dev.Slice())
+	}
+
+	write(problem.uavPositions.Count())
+	for _, uavId := range problem.uavPositions.GetCandidatePositionIdList() {
+		pos := problem.uavPositions.GetCandidatePosition(uavId)
+		write(uavId, pos.X, pos.Y, pos.Z)
+	}
+
+	slicesIds := slices.Clone(problem.devices.Slices())
+	slices.Sort(slicesIds)
+	for _, slice := range slicesIds {
+		write(slice, problem.gateway.GetBandwidth(slice), problem.gateway.GetMaxDatarate(slice))
+	}
+	for sf := int16(device.MinSF); sf <= device.MaxSF; sf++ {
+		write(sf, problem.gateway.GetSensitivityForSf(sf))
+	}
+
+	if description := problem.pathLoss.String(); description != DefaultPathLoss().String() {
+		hash.Write([]byte(description))
+	}
+
+	write(problem.alpha, problem.beta)
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+func (sol *UAVSolution) Record() SolutionRecord {
+	record := SolutionRecord{
+		Version:     SolutionFormatVersion,
+		Fingerprint: sol.problem.Fingerprint(),
+		Cost:        sol.GetCost(),
+		CostA:       sol.GetCostA(),
+		CostB:       sol.GetCostB(),
+		Devices:     make([]DeviceAssignment, 0, len(sol.assignedUav)),
+	}
+
+	for deviceId := device.DeviceId(0); deviceId < device.DeviceId(sol.problem.devices.Count()); deviceId++ {
+		association := sol.association(deviceId)
+		config := sol.problem.configurations[association.configId]
+		record.Devices = append(record.Devices, DeviceAssignment{
+			Device: int32(deviceId),
+			Uav:    association.uavId,
+			Config: association.configId,
+			Sf:     config.Sf,
+			Tp:     config.Tp,
+		})
+	}
+
+	return record
+}
+
+func (record SolutionRecord) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(record)
+}
+
+// WriteCSV writes the metadata as "# key: value" lines followed by one row per device.
+func (record SolutionRecord) WriteCSV(w io.Writer) error {
+	fmt.Fprintf(w, "# version: %d\n", record.Version)
+	fmt.Fprintf(w, "# fingerprint: %s\n", record.Fingerprint)
+	fmt.Fprintf(w, "# cost: %s\n", strconv.FormatFloat(record.Cost, 'f', -1, 64))
+	fmt.Fprintf(w, "# costA: %s\n", strconv.FormatFloat(record.CostA, 'f', -1, 64))
+	fmt.Fprintf(w, "# costB: %s\n", strconv.FormatFloat(record.CostB, 'f', -1, 64))
+
+	writer := csv.NewWriter(w)
+	writer.Write(recordHeader)
+	for _, assignment := range record.Devices {
+		writer.Write([]string{
+			strconv.Itoa(int(assignment.Device)),
+			strconv.Itoa(int(assignment.Uav)),
+			strconv.Itoa(int(assignment.Config)),
+			strconv.Itoa(int(assignment.Sf)),
+			strconv.Itoa(int(assignment.Tp)),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteFile picks the format from the extension of path: .json or .csv.
+func (record SolutionRecord) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = record.WriteJSON(file)
+	case ".csv":
+		err = record.WriteCSV(file)
+	default:
+		return fmt.Errorf("%s: unsupported solution format %q, use .json or .csv", path, ext)
+	}
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+func ReadSolutionJSON(r io.Reader) (SolutionRecord, error) {
+	record := SolutionRecord{}
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&record); err != nil {
+		return SolutionRecord{}, err
+	}
+	return record, nil
+}
+
+func ReadSolutionCSV(r io.Reader) (SolutionRecord, error) {
+	record := SolutionRecord{}
+	reader := bufio.NewReader(r)
+
+	// ---------- Metadata
+	for {
+		peek, err := reader.Peek(1)
+		if err != nil || peek[0] != '#' {
+			break
+		}
+
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return SolutionRecord{}, err
+		}
+
+		key, value, found := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "#")), ":")
+		if !found {
+			return SolutionRecord{}, fmt.Errorf("invalid metadata line %q", strings.TrimSpace(line))
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "version":
+			record.Version, err = strconv.Atoi(value)
+		case "fingerprint":
+			record.Fingerprint = value
+		case "cost":
+			record.Cost, err = strconv.ParseFloat(value, 64)
+		case "costA":
+			record.CostA, err = strconv.ParseFloat(value, 64)
+		case "costB":
+			record.CostB, err = strconv.ParseFloat(value, 64)
+		default:
+			return SolutionRecord{}, fmt.Errorf("unknown metadata %q", key)
+		}
+		if err != nil {
+			return SolutionRecord{}, fmt.Errorf("invalid %s %q: %v", key, value, err)
+		}
+	}
+
+	// ---------- Assignments
+	rows, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return SolutionRecord{}, err
+	}
+	if len(rows) == 0 || !slices.Equal(rows[0], recordHeader) {
+		return SolutionRecord{}, fmt.Errorf("missing header %q", strings.Join(recordHeader, ","))
+	}
+
+	for i, row := range rows[1:] {
+		values := make([]int64, len(row))
+		for j, field := range row {
+			values[j], err = strconv.ParseInt(field, 10, 32)
+			if err != nil {
+				return SolutionRecord{}, fmt.Errorf("row %d: invalid %s %q", i+2, recordHeader[j], field)
+			}
+		}
+		record.Devices = append(record.Devices, DeviceAssignment{
+			Device: int32(values[0]),
+			Uav:    int32(values[1]),
+			Config: int32(values[2]),
+			Sf:     int16(values[3]),
+			Tp:     int16(values[4]),
+		})
+	}
+
+	return record, nil
+}
+
+// ReadSolutionFile picks the format from the extension of path: .json or .csv.
+func ReadSolutionFile(path string) (SolutionRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return SolutionRecord{}, err
+	}
+	defer file.Close()
+
+	var record SolutionRecord
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		record, err = ReadSolutionJSON(file)
+	case ".csv":
+		record, err = ReadSolutionCSV(file)
+	default:
+		return SolutionRecord{}, fmt.Errorf("%s: unsupported solution format %q, use .json or .csv", path, ext)
+	}
+	if err != nil {
+		return SolutionRecord{}, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return record, nil
+}
+
+// BuildSolution rebuilds the solution described by record without judging it, so that it can be
+// handed to Validate. Only assignments that cannot be represented at all are rejected. The reported
+// cost of the record becomes the cached cost of the solution.
+func (problem *UAVProblem) BuildSolution(record SolutionRecord) (*UAVSolution, error) {
+	if record.Version != SolutionFormatVersion {
+		return nil, fmt.Errorf("unsupported solution format version %d, expected %d", record.Version, SolutionFormatVersion)
+	}
+
+	numDevices := problem.devices.Count()
+	sol := createEmptyUAVSolution(problem)
+	assigned := make([]bool, numDevices)
+	for _, assignment := range record.Devices {
+		deviceId := device.DeviceId(assignment.Device)
+		if assignment.Device < 0 || assignment.Device >= numDevices {
+			return nil, fmt.Errorf("device %d does not exist", assignment.Device)
+		}
+		if assigned[deviceId] {
+			return nil, fmt.Errorf("device %d is assigned more than once", assignment.Device)
+		}
+		assigned[deviceId] = true
+
+		if assignment.Uav < 0 || assignment.Uav >= problem.uavPositions.Count() {
+			return nil, fmt.Errorf("device %d: UAV %d does not exist", assignment.Device, assignment.Uav)
+		}
+		config, found := problem.configurations[assignment.Config]
+		if !found {
+			return nil, fmt.Errorf("device %d: configuration %d does not exist", assignment.Device, assignment.Config)
+		}
+		if config.Sf != assignment.Sf || config.Tp != assignment.Tp {
+			return nil, fmt.Errorf("device %d: configuration %d is SF%d/%ddBm, not SF%d/%ddBm", assignment.Device, assignment.Config, config.Sf, config.Tp, assignment.Sf, assignment.Tp)
+		}
+
+		sol.updateDeviceAssociation(deviceId, uavConfigurationAssociation{assignment.Uav, assignment.Config})
+	}
+
+	sol.reportedCost, sol.hasReported = record.Cost, true
+	return sol, nil
+}
+
+// LoadSolution rebuilds a solution from record and accepts it only if it comes from this instance
+// and passes Validate.
+func (problem *UAVProblem) LoadSolution(record SolutionRecord) (*UAVSolution, error) {
+	if fingerprint := problem.Fingerprint(); record.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("solution was computed on another instance (fingerprint %s, expected %s)", record.Fingerprint, fingerprint)
+	}
+
+	sol, err := problem.BuildSolution(record)
+	if err != nil {
+		return nil, err
+	}
+
+	if report := problem.Validate(sol); !report.Valid() {
+		return nil, fmt.Errorf("invalid solution:\n%s", report)
+	}
+
+	return sol, nil
+}
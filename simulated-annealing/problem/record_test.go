@@ -0,0 +1,52 @@
+package problem
+
+import "testing"
+
+// TestLoadSolutionChecksReportedCost loads records of a random solution claiming their own cost, and
+// checks that the solution cost is recomputed from the assignment while Validate compares the claim.
+func TestLoadSolutionChecksReportedCost(t *testing.T) {
+	instance := loadTestInstance(t, "1", nil)
+	sol, err := GetRandomUAVSolution(instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cost := sol.GetCost()
+
+	tests := []struct {
+		name  string
+		claim float64
+		valid bool
+	}{
+		{"true cost", cost, true},
+		{"lower cost", cost - 1, false},
+		{"zero cost", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			record := sol.Record()
+			record.Cost = test.claim
+
+			built, err := instance.BuildSolution(record)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := built.GetCost(); got != cost {
+				t.Errorf("GetCost() = %f, want the recomputed %f", got, cost)
+			}
+			if reported, ok := built.GetReportedCost(); !ok || reported != test.claim {
+				t.Errorf("GetReportedCost() = %f, %t, want %f, true", reported, ok, test.claim)
+			}
+
+			report := instance.Validate(built)
+			if report.Valid() != test.valid || report.ReportedCost != test.claim {
+				t.Errorf("Validate() = %s, want valid %t with reported cost %f", report, test.valid, test.claim)
+			}
+
+			_, err = instance.LoadSolution(record)
+			if (err == nil) != test.valid {
+				t.Errorf("LoadSolution() error = %v, want valid %t", err, test.valid)
+			}
+		})
+	}
+}
@@ -5,15 +5,17 @@ import (
 	"fmt"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/utils"
-	"maps"
 	"math/rand"
+	"os"
 	"slices"
+	"sync/atomic"
 )
 
 const (
-	debug           bool = false
-	DirectionUAV    int  = 1
-	DirectionConfig int  = 2
+	debug             bool = false
+	DirectionUAV      int  = 1
+	DirectionConfig   int  = 2
+	DirectionAltitude int  = 3
 )
 
 var globalIdx int64 = 0
@@ -55,22 +57,80 @@ type Association struct {
 	Config int32
 }
 
+// UAVSolution keeps its state in flat arrays indexed by the dense device, UAV and slice ids, so
+// that copying it takes a handful of memory copies. The devices of a UAV slice form a doubly
+// linked list threaded through next and prev, which adds or removes a device in constant time.
 type UAVSolution struct {
-	id                int64
-	deviceAssociation map[device.DeviceId]uavConfigurationAssociation
-	uavSliceDevices   map[uavSliceKey][]device.DeviceId
-	uavDevices        map[int32][]device.DeviceId
-	uavDatarate       map[uavSliceKey]float32 // uavDatarate[gwID][sliceID] -> datarate
-	deployedUavs      []int32
-	generatingMove    Move
-	cost              float64
-	problem           *UAVProblem
+	id             int64
+	assignedUav    []int32   // assignedUav[deviceId] -> uavId, -1 while unassigned
+	assignedConfig []int32   // assignedConfig[deviceId] -> configId
+	uavDatarate    []float32 // uavDatarate[uavId*numSlices+slice] -> datarate
+	sliceHead      []int32   // sliceHead[uavId*numSlices+slice] -> first device, -1 when empty
+	next           []int32   // next[deviceId] -> next device on the same UAV slice, -1 for the last
+	prev           []int32   // prev[deviceId] -> previous device on the same UAV slice, -1 for the first
+	uavCount       []int32   // uavCount[uavId] -> number of devices
+	deployedUavs   []int32
+	sfCount        [device.MaxSF - device.MinSF + 1]int32 // sfCount[sf-MinSF] -> number of devices
+	numSlices      int32
+	generatingMove Move
+	reportedCost   float64 // cost claimed by the record the solution was read from, see GetReportedCost
+	hasReported    bool
+	journal        []change
+	applied        []appliedMove
+	problem        *UAVProblem
+}
+
+// createEmptyUAVSolution builds a solution of problem with no device assigned.
+func createEmptyUAVSolution(problem *UAVProblem) *UAVSolution {
+	numDevices := problem.devices.Count()
+	numSlices := int32(len(problem.devices.Slices()))
+	numUavs := problem.uavPositions.Count()
+	sol := &UAVSolution{
+		id:             atomic.AddInt64(&globalIdx, 1) - 1,
+		assignedUav:    make([]int32, numDevices),
+		assignedConfig: make([]int32, numDevices),
+		uavDatarate:    make([]float32, numUavs*numSlices),
+		sliceHead:      make([]int32, numUavs*numSlices),
+		next:           make([]int32, numDevices),
+		prev:           make([]int32, numDevices),
+		uavCount:       make([]int32, numUavs),
+		deployedUavs:   make([]int32, 0),
+		numSlices:      numSlices,
+		problem:        problem,
+	}
+	for i := range sol.assignedUav {
+		sol.assignedUav[i] = -1
+		sol.assignedConfig[i] = -1
+	}
+	for i := range sol.sliceHead {
+		sol.sliceHead[i] = -1
+	}
+	return sol
+}
+
+// key is the index of a UAV slice in uavDatarate and sliceHead.
+func (sol *UAVSolution) key(uavId, slice int32) int32 {
+	return uavId*sol.numSlices + slice
+}
+
+func (sol *UAVSolution) association(deviceId device.DeviceId) uavConfigurationAssociation {
+	return uavConfigurationAssociation{sol.assignedUav[deviceId], sol.assignedConfig[deviceId]}
+}
+
+// appendSliceDevices appends the devices of the UAV slice at key to devices.
+func (sol *UAVSolution) appendSliceDevices(devices []device.DeviceId, key int32) []device.DeviceId {
+	for deviceId := sol.sliceHead[key]; deviceId >= 0; deviceId = sol.next[deviceId] {
+		devices = append(devices, device.DeviceId(deviceId))
+	}
+	return devices
 }
 
 func (sol *UAVSolution) GetAssociations() []Association {
-	associations := make([]Association, 0)
-	for key, value := range sol.deviceAssociation {
-		associations = append(associations, Association{key, value.uavId, value.configId})
+	associations := make([]Association, 0, len(sol.assignedUav))
+	for deviceId, uavId := range sol.assignedUav {
+		if uavId >= 0 {
+			associations = append(associations, Association{device.DeviceId(deviceId), uavId, sol.assignedConfig[deviceId]})
+		}
 	}
 
 	return associations
@@ -79,7 +139,7 @@ func (sol *UAVSolution) GetAssociations() []Association {
 func (sol *UAVSolution) String() string {
 	t := fmt.Sprintf("id: %d, cost: %f, move: %+v\n", sol.id, sol.GetCost(), sol.generatingMove)
 	for i, _ := range sol.problem.devices.GetDeviceIds() {
-		t += fmt.Sprintf(" (%d,%d),", sol.deviceAssociation[device.DeviceId(i)].uavId, sol.deviceAssociation[device.DeviceId(i)].configId)
+		t += fmt.Sprintf(" (%d,%d),", sol.assignedUav[i], sol.assignedConfig[i])
 	}
 	return t
 }
@@ -110,117 +170,100 @@ func (sol *UAVSolution) deployUav(uavId int32) {
 }
 
 func (sol *UAVSolution) withdrawUav(uavId int32) {
-	slices.Sort(sol.deployedUavs)
-	uavIdx, found := slices.BinarySearch(sol.deployedUavs, uavId)
-	if found {
-		utils.Pop(&sol.deployedUavs, uavIdx)
+	if uavIdx := slices.Index(sol.deployedUavs, uavId); uavIdx >= 0 {
+		last := len(sol.deployedUavs) - 1
+		sol.deployedUavs[uavIdx] = sol.deployedUavs[last]
+		sol.deployedUavs = sol.deployedUavs[:last]
 	}
 }
 
+// filterUavs returns the UAVs of uavs for which keep holds.
+func (sol *UAVSolution) filterUavs(uavs []int32, keep func(uavId int32) bool) []int32 {
+	filtered := make([]int32, 0, len(uavs))
+	for _, uavId := range uavs {
+		if keep(uavId) {
+			filtered = append(filtered, uavId)
+		}
+	}
+	return filtered
+}
+
 func (sol *UAVSolution) GetDevicesAssignedTo(uavId int32) []device.DeviceId {
-	return sol.uavDevices[uavId]
+	devices := make([]device.DeviceId, 0, sol.uavCount[uavId])
+	for slice := int32(0); slice < sol.numSlices; slice++ {
+		devices = sol.appendSliceDevices(devices, sol.key(uavId, slice))
+	}
+	return devices
 }
 
 func (sol *UAVSolution) RemoveDeviceFromGateway(deviceId device.DeviceId, uavId int32) {
-	// Update uavSliceDevices
-	slice := sol.problem.devices.GetDevice(deviceId).Slice()
-	key := uavSliceKey{uavId, slice}
-
-	slices.Sort(sol.uavSliceDevices[key])
-	deviceIdx, _ := slices.BinarySearch(sol.uavSliceDevices[key], deviceId)
-
-	uavSliceDevices := sol.uavSliceDevices[key]
-	utils.Pop(&uavSliceDevices, deviceIdx)
-	sol.uavSliceDevices[key] = uavSliceDevices
-
-	// Update uavDevices
-	slices.Sort(sol.uavDevices[uavId])
-	deviceIdx, _ = slices.BinarySearch(sol.uavDevices[uavId], deviceId)
-
-	uavDevices := sol.uavDevices[uavId]
-	utils.Pop(&uavDevices, deviceIdx)
-	sol.uavDevices[uavId] = uavDevices
+	// Unlink the device from the list of its UAV slice
+	key := sol.key(uavId, sol.problem.GetSlice(deviceId))
+	prev, next := sol.prev[deviceId], sol.next[deviceId]
+	if prev >= 0 {
+		sol.next[prev] = next
+	} else {
+		sol.sliceHead[key] = next
+	}
+	if next >= 0 {
+		sol.prev[next] = prev
+	}
 
 	// Last device removed from UAV
-	if len(sol.uavDevices[uavId]) == 0 {
+	sol.uavCount[uavId]--
+	if sol.uavCount[uavId] == 0 {
 		sol.withdrawUav(uavId)
 	}
 }
 
 func (sol *UAVSolution) AddDeviceToGateway(deviceId device.DeviceId, uavId int32) {
-	// Update uavSliceDevices
-	slice := sol.problem.devices.GetDevice(deviceId).Slice()
-	key := uavSliceKey{uavId, slice}
-	sol.uavSliceDevices[key] = append(sol.uavSliceDevices[key], deviceId)
-
-	// Update uavDevices
+	// Link the device at the head of the list of its UAV slice
+	key := sol.key(uavId, sol.problem.GetSlice(deviceId))
+	head := sol.sliceHead[key]
+	sol.prev[deviceId] = -1
+	sol.next[deviceId] = head
+	if head >= 0 {
+		sol.prev[head] = int32(deviceId)
+	}
+	sol.sliceHead[key] = int32(deviceId)
 
 	// First device added to UAV
-	numDevicesInUav := len(sol.uavDevices[uavId])
-	if numDevicesInUav == 0 {
+	if sol.uavCount[uavId] == 0 {
 		sol.deployUav(uavId)
 	}
-
-	if sol.uavDevices[uavId] == nil {
-		sol.uavDevices[uavId] = make([]device.DeviceId, 0)
-	}
-	sol.uavDevices[uavId] = append(sol.uavDevices[uavId], deviceId)
+	sol.uavCount[uavId]++
 }
 
 func (sol *UAVSolution) GetAssignedUavId(deviceId device.DeviceId) int32 {
-	return sol.deviceAssociation[deviceId].uavId
+	return sol.assignedUav[deviceId]
 }
 
 func (sol *UAVSolution) GetAssignedConfigId(deviceId device.DeviceId) int32 {
-	return sol.deviceAssociation[deviceId].configId
+	return sol.assignedConfig[deviceId]
 }
 
 func (sol *UAVSolution) Print() {
-	numDevices := len(sol.deviceAssociation)
-	for key := 0; key < numDevices; key++ {
-		fmt.Printf("Device %d -> %+v\n", key, sol.deviceAssociation[device.DeviceId(key)])
+	for key := range sol.assignedUav {
+		fmt.Printf("Device %d -> %+v\n", key, sol.association(device.DeviceId(key)))
 	}
 }
 
 func (sol *UAVSolution) copy() *UAVSolution {
-	copyUavSliceDevices := make(map[uavSliceKey][]device.DeviceId)
-	copyUavDevices := make(map[int32][]device.DeviceId)
-	//copyUavDatarate := make(map[uavSliceKey]float32)
-
-	copyDeviceAssociation := maps.Clone(sol.deviceAssociation)
-	copyUavDatarate := maps.Clone(sol.uavDatarate)
-
-	//for key, value := range sol.deviceAssociation {
-	//	copyDeviceAssociation[key] = &uavConfigurationAssociation{value.uavId, value.configId}
-	//}
-	//
-	for key, value := range sol.uavSliceDevices {
-		copyUavSliceDevices[key] = slices.Clone(value)
-	}
-
-	for key, value := range sol.uavDevices {
-		copyUavDevices[key] = slices.Clone(value)
-	}
-
-	//copyDeployedUavs := make([]int32, len(sol.deployedUavs))
-	//copy(copyDeployedUavs, sol.deployedUavs)
-
-	copyDeployedUavs := slices.Clone(sol.deployedUavs)
-
-	//for key, value := range sol.uavDatarate {
-	//	copyUavDatarate[key] = value
-	//}
-
-	id := globalIdx
-	globalIdx++
 	return &UAVSolution{
-		id:                id,
-		deviceAssociation: copyDeviceAssociation,
-		uavSliceDevices:   copyUavSliceDevices,
-		uavDevices:        copyUavDevices,
-		uavDatarate:       copyUavDatarate,
-		deployedUavs:      copyDeployedUavs,
-		problem:           sol.problem,
+		id:             atomic.AddInt64(&globalIdx, 1) - 1,
+		assignedUav:    slices.Clone(sol.assignedUav),
+		assignedConfig: slices.Clone(sol.assignedConfig),
+		uavDatarate:    slices.Clone(sol.uavDatarate),
+		sliceHead:      slices.Clone(sol.sliceHead),
+		next:           slices.Clone(sol.next),
+		prev:           slices.Clone(sol.prev),
+		uavCount:       slices.Clone(sol.uavCount),
+		deployedUavs:   slices.Clone(sol.deployedUavs),
+		sfCount:        sol.sfCount,
+		numSlices:      sol.numSlices,
+		reportedCost:   sol.reportedCost,
+		hasReported:    sol.hasReported,
+		problem:        sol.problem,
 	}
 }
 
@@ -228,15 +271,29 @@ func (sol *UAVSolution) Copy() Solution {
 	return sol.copy()
 }
 
+// Hash is the FNV-1a hash of the UAV and configuration of every device, equal for solutions with
+// the same assignment.
+func (sol *UAVSolution) Hash() uint64 {
+	hash := uint64(14695981039346656037)
+	for deviceId, uavId := range sol.assignedUav {
+		for _, value := range [2]int32{uavId, sol.assignedConfig[deviceId]} {
+			for shift := 0; shift < 32; shift += 8 {
+				hash ^= uint64(uint8(value >> shift))
+				hash *= 1099511628211
+			}
+		}
+	}
+	return hash
+}
+
 func (sol *UAVSolution) fixGatewayCapacity() bool {
 	numUavPositions := sol.problem.uavPositions.Count()
 	numSlices := int32(len(sol.problem.devices.Slices()))
 	for uavId := int32(0); uavId < numUavPositions; uavId++ {
 		for slice := int32(0); slice < numSlices; slice++ {
-			key := uavSliceKey{uavId, slice}
-			if sol.uavDatarate[key] > sol.problem.gateway.GetMaxDatarate(slice) {
+			if sol.uavDatarate[sol.key(uavId, slice)] > sol.problem.gateway.GetMaxDatarate(slice) {
 				//fmt.Printf("(!!!) Fixing gateway capacity...\n")
-				if !sol.unloadGateway(key) {
+				if !sol.unloadGateway(uavSliceKey{uavId, slice}) {
 					panic("Impossible to fix Gateway")
 				}
 			}
@@ -251,8 +308,7 @@ func (sol *UAVSolution) IsFeasible() bool {
 	numSlices := int32(len(sol.problem.devices.Slices()))
 	for uavId := int32(0); uavId < numUavPositions; uavId++ {
 		for slice := int32(0); slice < numSlices; slice++ {
-			key := uavSliceKey{uavId, slice}
-			if sol.uavDatarate[key] > sol.problem.gateway.GetMaxDatarate(slice) {
+			if sol.uavDatarate[sol.key(uavId, slice)] > sol.problem.gateway.GetMaxDatarate(slice) {
 				return false
 			}
 		}
@@ -262,20 +318,19 @@ func (sol *UAVSolution) IsFeasible() bool {
 }
 
 func (sol *UAVSolution) unloadGateway(key uavSliceKey) bool {
-	devicesToMove := make([]device.DeviceId, len(sol.uavSliceDevices[key]))
-	copy(devicesToMove, sol.uavSliceDevices[key])
+	devicesToMove := sol.appendSliceDevices(nil, sol.key(key.uavId, key.slice))
 
 	// Choose a random device to switch uav
 	numDevices := int32(len(devicesToMove))
 	if numDevices == 0 {
 		panic("NO DEVICES")
 	}
-	deviceIdx := rand.Int31n(numDevices)
+	deviceIdx := sol.problem.rng.Int31n(numDevices)
 	deviceId := utils.Pop(&devicesToMove, int(deviceIdx))
 	uavId := key.uavId
 	configId := sol.GetAssignedConfigId(deviceId)
 
-	currentDatarate := sol.uavDatarate[key]
+	currentDatarate := sol.uavDatarate[sol.key(key.uavId, key.slice)]
 	maxDatarate := sol.problem.gateway.GetMaxDatarate(key.slice)
 	for currentDatarate > maxDatarate {
 		// Get the next uav option
@@ -287,7 +342,7 @@ func (sol *UAVSolution) unloadGateway(key uavSliceKey) bool {
 		if uavId != key.uavId {
 			configId := association.configId
 			sfNew := sol.problem.configurations[configId].Sf
-			keyNew := uavSliceKey{uavId, key.slice}
+			keyNew := sol.key(uavId, key.slice)
 			datarateNew := sol.problem.gateway.GetDatarate(sfNew, key.slice)
 
 			if sol.uavDatarate[keyNew]+datarateNew <= sol.problem.gateway.GetMaxDatarate(key.slice) {
@@ -295,7 +350,7 @@ func (sol *UAVSolution) unloadGateway(key uavSliceKey) bool {
 				sol.updateDeviceAssociation(deviceId, association)
 			} else {
 				// Current gateway is overloaded, try next
-				currentDatarate = sol.uavDatarate[key]
+				currentDatarate = sol.uavDatarate[sol.key(key.uavId, key.slice)]
 				continue
 			}
 		}
@@ -305,11 +360,11 @@ func (sol *UAVSolution) unloadGateway(key uavSliceKey) bool {
 			return false
 		}
 
-		currentDatarate = sol.uavDatarate[key]
+		currentDatarate = sol.uavDatarate[sol.key(key.uavId, key.slice)]
 
 		// Try moving another random device
 		numDevices = int32(len(devicesToMove))
-		deviceIdx = rand.Int31n(numDevices)
+		deviceIdx = sol.problem.rng.Int31n(numDevices)
 		deviceId = utils.Pop(&devicesToMove, int(deviceIdx))
 		uavId = key.uavId
 	}
@@ -318,16 +373,23 @@ func (sol *UAVSolution) unloadGateway(key uavSliceKey) bool {
 }
 
 func (sol *UAVSolution) updateDeviceAssociation(deviceId device.DeviceId, association uavConfigurationAssociation) {
+	if len(sol.applied) > 0 {
+		sol.record(deviceId, association)
+	}
+	sol.assign(deviceId, association)
+}
+
+func (sol *UAVSolution) assign(deviceId device.DeviceId, association uavConfigurationAssociation) {
 	slice := sol.problem.devices.GetDevice(deviceId).Slice()
-	if sol.deviceAssociation[deviceId] != *(&uavConfigurationAssociation{}) {
+	if uavPrev := sol.GetAssignedUavId(deviceId); uavPrev >= 0 {
 		// Remove load from previous gateway
 		configPrev := sol.GetAssignedConfigId(deviceId)
-		uavPrev := sol.GetAssignedUavId(deviceId)
-		keyPrev := uavSliceKey{uavPrev, slice}
+		keyPrev := sol.key(uavPrev, slice)
 
 		sfPrev := sol.problem.configurations[configPrev].Sf
 		dataratePrev := sol.problem.gateway.GetDatarate(sfPrev, slice)
 		sol.uavDatarate[keyPrev] -= dataratePrev
+		sol.sfCount[sfPrev-device.MinSF]--
 
 		// Remove device from previous gateway
 		sol.RemoveDeviceFromGateway(deviceId, uavPrev)
@@ -338,13 +400,16 @@ func (sol *UAVSolution) updateDeviceAssociation(deviceId device.DeviceId, associ
 	configNew := association.configId
 	sfNew := sol.problem.configurations[configNew].Sf
 
-	keyNew := uavSliceKey{uavNew, slice}
+	keyNew := sol.key(uavNew, slice)
 	datarateNew := sol.problem.gateway.GetDatarate(sfNew, slice)
 	sol.uavDatarate[keyNew] += datarateNew
+	sol.sfCount[sfNew-device.MinSF]++
 
 	// Update device association
-	sol.deviceAssociation[deviceId] = association
+	sol.assignedUav[deviceId] = uavNew
+	sol.assignedConfig[deviceId] = configNew
 	sol.AddDeviceToGateway(deviceId, uavNew)
+	sol.hasReported = false
 }
 
 func (sol *UAVSolution) FlipAssociation(association Association) {
@@ -352,7 +417,7 @@ func (sol *UAVSolution) FlipAssociation(association Association) {
 	uavId := association.Uav
 	configId := association.Config
 
-	if sol.deviceAssociation[deviceId].uavId != uavId || sol.deviceAssociation[deviceId].configId != configId {
+	if sol.GetAssignedUavId(deviceId) != uavId || sol.GetAssignedConfigId(deviceId) != configId {
 		sol.updateDeviceAssociation(deviceId, uavConfigurationAssociation{uavId: uavId, configId: configId})
 		return
 	}
@@ -368,7 +433,7 @@ func (sol *UAVSolution) neighbourUAV(deviceId device.DeviceId, uavId, configId i
 	}
 
 	// Check probability of moving forward or backward
-	random := utils.GetRandomProbability()
+	random := utils.GetRandomProbability(sol.problem.rng)
 	if random <= 0.5 {
 		if debug {
 			fmt.Printf("Forward...\n")
@@ -394,12 +459,12 @@ func (sol *UAVSolution) neighbourUAVSmarter(deviceId device.DeviceId, uavId, con
 	*/
 
 	// Check probability of moving forward or backward
-	random := utils.GetRandomProbability()
+	random := utils.GetRandomProbability(sol.problem.rng)
 	if random <= 0.5 {
 		if debug {
 			fmt.Printf("Forward...\n")
 		}
-		random = utils.GetRandomProbability()
+		random = utils.GetRandomProbability(sol.problem.rng)
 		if random < sol.problem.newUavChance {
 			return sol.problem.nextNewUav(deviceId, uavId, configId, sol, []int32{}, 1.0)
 		} else {
@@ -409,7 +474,7 @@ func (sol *UAVSolution) neighbourUAVSmarter(deviceId device.DeviceId, uavId, con
 		if debug {
 			fmt.Printf("Backward...\n")
 		}
-		random = utils.GetRandomProbability()
+		random = utils.GetRandomProbability(sol.problem.rng)
 		if random < sol.problem.newUavChance {
 			return sol.problem.previousNewUav(deviceId, uavId, configId, sol, []int32{}, 1.0)
 		} else {
@@ -430,12 +495,12 @@ func (sol *UAVSolution) neighbourUAVSmarterTabu(deviceId device.DeviceId, uavId,
 	*/
 
 	// Check probability of moving forward or backward
-	random := utils.GetRandomProbability()
+	random := utils.GetRandomProbability(sol.problem.rng)
 	if random <= 0.5 {
 		if debug {
 			fmt.Printf("Forward...\n")
 		}
-		random = utils.GetRandomProbability()
+		random = utils.GetRandomProbability(sol.problem.rng)
 		if random < sol.problem.newUavChance {
 			return sol.problem.nextNewUav(deviceId, uavId, configId, sol, uavTabu, tabuPercentage)
 		} else {
@@ -445,7 +510,7 @@ func (sol *UAVSolution) neighbourUAVSmarterTabu(deviceId device.DeviceId, uavId,
 		if debug {
 			fmt.Printf("Backward...\n")
 		}
-		random = utils.GetRandomProbability()
+		random = utils.GetRandomProbability(sol.problem.rng)
 		if random < sol.problem.newUavChance {
 			return sol.problem.previousNewUav(deviceId, uavId, configId, sol, uavTabu, tabuPercentage)
 		} else {
@@ -461,7 +526,7 @@ func (sol *UAVSolution) neighbourConfig(deviceId device.DeviceId, uavId, configI
 	}
 
 	// Check probability of moving forward or backward
-	random := utils.GetRandomProbability()
+	random := utils.GetRandomProbability(sol.problem.rng)
 	if random <= 0.5 {
 		if debug {
 			fmt.Printf("Forward...\n")
@@ -476,19 +541,24 @@ func (sol *UAVSolution) neighbourConfig(deviceId device.DeviceId, uavId, configI
 }
 
 func (sol *UAVSolution) GetNeighbourSA(minDistance, maxDistance int) Solution {
-	distance := rand.Int31n(int32(maxDistance)-int32(minDistance)) + int32(minDistance)
+	distance := sol.problem.rng.Int31n(int32(maxDistance)-int32(minDistance)) + int32(minDistance)
 	newSol := sol.copy()
 	for i := int32(0); i < distance; i++ {
-		newSol = newSol.GetNeighbourSmarter()
+		move, found := newSol.DrawMove()
+		if !found {
+			fmt.Fprintf(os.Stderr, "No valid movement found\n")
+			continue
+		}
+		newSol.moveTo(move)
 	}
 	return newSol
 }
 
 func (sol *UAVSolution) GetNeighbourRandom(minDistance, maxDistance int) Solution {
-	distance := rand.Int31n(int32(maxDistance)-int32(minDistance)) + int32(minDistance)
+	distance := sol.problem.rng.Int31n(int32(maxDistance)-int32(minDistance)) + int32(minDistance)
 	newSol := sol.copy()
 	for i := int32(0); i < distance; i++ {
-		newSol = newSol.getNeighbourRandom()
+		newSol.moveTo(newSol.drawRandomMove())
 	}
 	return newSol
 }
@@ -513,139 +583,42 @@ func (sol *UAVSolution) GetNeighbourListTabu(size int, uavTabu []int32, tabuPerc
 	return solutions
 }
 
-func (sol *UAVSolution) getNeighbourRandom() *UAVSolution {
-	maxTies := 50
-
-	neighbour := sol.copy()
-	var move Move
-
-	for maxTies > 0 {
-		deviceId := neighbour.problem.devices.GetRandomDevice().GetId()
-		uavId := neighbour.GetAssignedUavId(deviceId)
-		configId := neighbour.GetAssignedConfigId(deviceId)
-
-		move.DeviceId = deviceId
+// drawRandomMove draws the move of a random device to a neighbouring UAV or configuration, with
+// the plain UAV order of neighbourUAV rather than the deployed UAVs first.
+func (sol *UAVSolution) drawRandomMove() Move {
+	for maxTies := 50; maxTies > 0; maxTies-- {
+		deviceId := sol.problem.devices.GetRandomDevice(sol.problem.rng).GetId()
+		uavId := sol.GetAssignedUavId(deviceId)
+		configId := sol.GetAssignedConfigId(deviceId)
 
 		// Check probability of changing UAV or changing Configuration
-		random := utils.GetRandomProbability()
-		var ass uavConfigurationAssociation
-		if random <= neighbour.problem.GetChanceOfChangingUAV() {
-			move.Direction = DirectionUAV
-			ass = neighbour.neighbourUAV(deviceId, uavId, configId)
-			if ass.uavId == uavId {
-				// No available move. Try another one
-				if maxTies > 0 {
-					maxTies--
-					continue
-				}
+		random := utils.GetRandomProbability(sol.problem.rng)
+		if random <= sol.problem.GetChanceOfChangingUAV() {
+			ass := sol.neighbourUAV(deviceId, uavId, configId)
+			if ass.uavId != uavId {
+				return Move{deviceId, DirectionUAV, configId, uavId, ass.configId, ass.uavId}
 			}
 		} else {
-			move.Direction = DirectionConfig
-			ass = neighbour.neighbourConfig(deviceId, uavId, configId)
-			if ass.configId == configId {
-				// No available move. Try another one
-				if maxTies > 0 {
-					maxTies--
-					continue
-				}
+			ass := sol.neighbourConfig(deviceId, uavId, configId)
+			if ass.configId != configId {
+				return Move{deviceId, DirectionConfig, configId, uavId, ass.configId, ass.uavId}
 			}
 		}
-
-		move.PrevUAV = uavId
-		move.PrevConfig = configId
-		move.NewUAV = ass.uavId
-		move.NewConfig = ass.configId
-
-		if debug {
-			fmt.Printf("Configs: %+v\n", sol.problem.possibleConfigurations[deviceGatewayAssociation{deviceId, uavId}])
-			fmt.Printf("   Uavs: %+v\n", sol.problem.possibleUavs[deviceId])
-			fmt.Printf(" Previous device: %d | uav: %d | config: %d \n", deviceId, uavId, configId)
-			fmt.Printf("      New device: %d | uav: %d | config: %d \n", deviceId, ass.uavId, ass.configId)
-			fmt.Println("-----------------------------------------------------------------------")
-		}
-
-		// Consolidate movement
-		neighbour.updateDeviceAssociation(deviceId, ass)
-		break
 	}
 
-	if maxTies == 0 {
-		panic("No valid movement found")
-	}
-
-	// Fix gateway capacity feasibility
-	neighbour.fixGatewayCapacity()
-	neighbour.generatingMove = move
-
-	return neighbour
+	panic("No valid movement found")
 }
 
 func (sol *UAVSolution) GetNeighbourSmarter() *UAVSolution {
-	maxTies := 50
-
-	neighbour := sol.copy()
-	var move Move
-
-	for maxTies > 0 {
-		deviceId := neighbour.problem.devices.GetRandomDevice().GetId()
-		uavId := neighbour.GetAssignedUavId(deviceId)
-		configId := neighbour.GetAssignedConfigId(deviceId)
-
-		move.DeviceId = deviceId
-
-		// Check probability of changing UAV or changing Configuration
-		random := utils.GetRandomProbability()
-		var ass uavConfigurationAssociation
-		if random <= neighbour.problem.GetChanceOfChangingUAV() {
-			move.Direction = DirectionUAV
-			ass = neighbour.neighbourUAVSmarter(deviceId, uavId, configId)
-			if ass.uavId == uavId {
-				// No available move. Try another one
-				if maxTies > 0 {
-					maxTies--
-					continue
-				}
-			}
-		} else {
-			move.Direction = DirectionConfig
-			ass = neighbour.neighbourConfig(deviceId, uavId, configId)
-			if ass.configId == configId {
-				// No available move. Try another one
-				if maxTies > 0 {
-					maxTies--
-					continue
-				}
-			}
-
-		}
-
-		move.PrevUAV = uavId
-		move.PrevConfig = configId
-		move.NewUAV = ass.uavId
-		move.NewConfig = ass.configId
-
-		if debug {
-			fmt.Printf("Configs: %+v\n", sol.problem.possibleConfigurations[deviceGatewayAssociation{deviceId, uavId}])
-			fmt.Printf("   Uavs: %+v\n", sol.problem.possibleUavs[deviceId])
-			fmt.Printf(" Previous device: %d | uav: %d | config: %d \n", deviceId, uavId, configId)
-			fmt.Printf("      New device: %d | uav: %d | config: %d \n", deviceId, ass.uavId, ass.configId)
-			fmt.Println("-----------------------------------------------------------------------")
-		}
-
-		// Consolidate movement
-		neighbour.updateDeviceAssociation(deviceId, ass)
-		break
+	move, found := sol.DrawMove()
+	if !found {
+		fmt.Fprintf(os.Stderr, "No valid movement found\n")
+		neighbour := sol.copy()
+		neighbour.fixGatewayCapacity()
+		return neighbour
 	}
 
-	if maxTies == 0 {
-		fmt.Errorf("No valid movement found\n")
-	}
-
-	// Fix gateway capacity feasibility
-	neighbour.fixGatewayCapacity()
-	neighbour.generatingMove = move
-
-	return neighbour
+	return sol.GetNeighbourFor(move)
 }
 
 func (sol *UAVSolution) GetNeighbourSmarterTabu(uavTabu []int32, tabuPercentage float32) *UAVSolution {
@@ -658,7 +631,7 @@ func (sol *UAVSolution) GetNeighbourSmarterTabu(uavTabu []int32, tabuPercentage
 	tabuUavRatio := float32(0.0)
 	if len(uavTabu) > 0 {
 		for _, deviceId := range sol.problem.GetDeviceIds() {
-			found := utils.Contains(uavTabu, sol.deviceAssociation[deviceId].uavId)
+			found := utils.Contains(uavTabu, sol.GetAssignedUavId(deviceId))
 			if found {
 				tabuDevices = append(tabuDevices, deviceId)
 			}
@@ -669,11 +642,11 @@ func (sol *UAVSolution) GetNeighbourSmarterTabu(uavTabu []int32, tabuPercentage
 	}
 
 	for maxTies > 0 {
-		deviceId := neighbour.problem.devices.GetRandomDevice().GetId()
+		deviceId := neighbour.problem.devices.GetRandomDevice(sol.problem.rng).GetId()
 		if len(tabuDevices) > 0 && tabuUavRatio > tabuPercentage {
-			random := utils.GetRandomProbability()
+			random := utils.GetRandomProbability(sol.problem.rng)
 			if random <= 0.75 {
-				deviceId = tabuDevices[rand.Int31n(int32(len(tabuDevices)))]
+				deviceId = tabuDevices[sol.problem.rng.Int31n(int32(len(tabuDevices)))]
 			}
 		}
 		uavId := neighbour.GetAssignedUavId(deviceId)
@@ -682,7 +655,7 @@ func (sol *UAVSolution) GetNeighbourSmarterTabu(uavTabu []int32, tabuPercentage
 		move.DeviceId = deviceId
 
 		// Check probability of changing UAV or changing Configuration
-		random := utils.GetRandomProbability()
+		random := utils.GetRandomProbability(sol.problem.rng)
 		var ass uavConfigurationAssociation
 		if random <= neighbour.problem.GetChanceOfChangingUAV() {
 			move.Direction = DirectionUAV
@@ -713,8 +686,8 @@ func (sol *UAVSolution) GetNeighbourSmarterTabu(uavTabu []int32, tabuPercentage
 		move.NewConfig = ass.configId
 
 		if debug {
-			fmt.Printf("Configs: %+v\n", sol.problem.possibleConfigurations[deviceGatewayAssociation{deviceId, uavId}])
-			fmt.Printf("   Uavs: %+v\n", sol.problem.possibleUavs[deviceId])
+			fmt.Printf("Configs: %+v\n", sol.problem.links.Configs(deviceId, uavId))
+			fmt.Printf("   Uavs: %+v\n", sol.problem.links.Uavs(deviceId))
 			fmt.Printf(" Previous device: %d | uav: %d | config: %d \n", deviceId, uavId, configId)
 			fmt.Printf("      New device: %d | uav: %d | config: %d \n", deviceId, ass.uavId, ass.configId)
 			fmt.Println("-----------------------------------------------------------------------")
@@ -736,56 +709,25 @@ func (sol *UAVSolution) GetNeighbourSmarterTabu(uavTabu []int32, tabuPercentage
 	return neighbour
 }
 
+// GetCostA, GetCostB and GetCost are read from the deployed UAVs and the device count per SF, which
+// updateDeviceAssociation keeps up to date, so they never scan the devices.
 func (sol *UAVSolution) GetCostA() float64 {
-	uavs := make([]int32, 0)
-	for _, association := range sol.deviceAssociation {
-		uavs = append(uavs, association.uavId)
-	}
-	uniqueUavs := utils.Unique(&uavs)
-
-	return float64(len(uniqueUavs)) * sol.problem.alpha
+	return float64(len(sol.deployedUavs)) * sol.problem.alpha
 }
 
 func (sol *UAVSolution) GetCostB() float64 {
-	configs := map[int16]int32{7: 0, 8: 0, 9: 0, 10: 0, 11: 0, 12: 0}
-
-	for _, association := range sol.deviceAssociation {
-		configs[sol.problem.configurations[association.configId].Sf]++
-	}
-
-	maxSfCount := int32(0)
-	for _, count := range configs {
-		if count > maxSfCount {
-			maxSfCount = count
-		}
-	}
-
-	return float64(maxSfCount) * sol.problem.beta
+	return float64(sol.GetMaxSfCount()) * sol.problem.beta
 }
 
 func (sol *UAVSolution) GetCost() float64 {
-	if sol.cost == 0 {
-		uavs := make([]int32, 0)
-		configs := map[int16]int32{7: 0, 8: 0, 9: 0, 10: 0, 11: 0, 12: 0}
-
-		for _, association := range sol.deviceAssociation {
-			uavs = append(uavs, association.uavId)
-			configs[sol.problem.configurations[association.configId].Sf]++
-		}
-
-		uniqueUavs := utils.Unique(&uavs)
-
-		maxSfCount := int32(0)
-		for _, count := range configs {
-			if count > maxSfCount {
-				maxSfCount = count
-			}
-		}
-
-		sol.cost = float64(len(uniqueUavs))*sol.problem.alpha + float64(maxSfCount)*sol.problem.beta
-	}
+	return sol.GetCostA() + sol.GetCostB()
+}
 
-	return sol.cost
+// GetReportedCost is the cost claimed by the record the solution was read from, kept apart from
+// GetCost so that Validate can check the claim. It returns false once the solution changes, or when
+// it was not read from a record.
+func (sol *UAVSolution) GetReportedCost() (float64, bool) {
+	return sol.reportedCost, sol.hasReported
 }
 
 func (sol *UAVSolution) GetInverseCost() float64 {
@@ -795,27 +737,7 @@ func (sol *UAVSolution) GetInverseCost() float64 {
 }
 
 func GetRandomUAVSolution(problem *UAVProblem) (*UAVSolution, error) {
-	numSlices := int32(len(problem.devices.Slices()))
-	numUavs := problem.uavPositions.Count()
-	numAssociations := numUavs * numSlices
-	id := globalIdx
-	globalIdx++
-	sol := &UAVSolution{
-		id:                id,
-		deviceAssociation: make(map[device.DeviceId]uavConfigurationAssociation, problem.devices.Count()),
-		uavSliceDevices:   make(map[uavSliceKey][]device.DeviceId),
-		uavDevices:        make(map[int32][]device.DeviceId),
-		uavDatarate:       make(map[uavSliceKey]float32, numAssociations),
-		deployedUavs:      make([]int32, 0),
-		problem:           problem,
-	}
-
-	for uavId := int32(0); uavId < numUavs; uavId++ {
-		for slice := int32(0); slice < numSlices; slice++ {
-			key := uavSliceKey{uavId, slice}
-			sol.uavSliceDevices[key] = make([]device.DeviceId, 0)
-		}
-	}
+	sol := createEmptyUAVSolution(problem)
 
 	// Random association for each device
 	for deviceId := device.DeviceId(0); deviceId < device.DeviceId(problem.devices.Count()); deviceId++ {
@@ -832,24 +754,7 @@ func GetRandomUAVSolution(problem *UAVProblem) (*UAVSolution, error) {
 }
 
 func GetUAVSolution(problem *UAVProblem, uavs []int32, coverage map[int32][]device.DeviceId, defaultSF int16) (*UAVSolution, error) {
-	numSlices := int32(len(problem.devices.Slices()))
-	numUavs := problem.uavPositions.Count()
-	numAssociations := numUavs * numSlices
-	sol := &UAVSolution{
-		deviceAssociation: make(map[device.DeviceId]uavConfigurationAssociation, problem.devices.Count()),
-		uavSliceDevices:   make(map[uavSliceKey][]device.DeviceId),
-		uavDevices:        make(map[int32][]device.DeviceId),
-		uavDatarate:       make(map[uavSliceKey]float32, numAssociations),
-		deployedUavs:      make([]int32, 0),
-		problem:           problem,
-	}
-
-	for uavId := int32(0); uavId < numUavs; uavId++ {
-		for slice := int32(0); slice < numSlices; slice++ {
-			key := uavSliceKey{uavId, slice}
-			sol.uavSliceDevices[key] = make([]device.DeviceId, 0)
-		}
-	}
+	sol := createEmptyUAVSolution(problem)
 
 	for _, uavId := range uavs {
 		for _, deviceId := range coverage[uavId] {
@@ -864,6 +769,35 @@ func GetUAVSolution(problem *UAVProblem, uavs []int32, coverage map[int32][]devi
 	return sol, nil
 }
 
+// CreateSolution builds the solution holding exactly the given associations, which must assign
+// every device once to a UAV it can reach with the given configuration. Capacities are not fixed.
+func (problem *UAVProblem) CreateSolution(associations []Association) (*UAVSolution, error) {
+	sol := createEmptyUAVSolution(problem)
+	assigned := 0
+	for _, association := range associations {
+		if association.Device < 0 || int32(association.Device) >= problem.devices.Count() {
+			return nil, fmt.Errorf("device %d does not exist", association.Device)
+		}
+		if sol.GetAssignedUavId(association.Device) >= 0 {
+			return nil, fmt.Errorf("device %d is assigned more than once", association.Device)
+		}
+
+		configs := problem.links.Configs(association.Device, association.Uav)
+		if !slices.Contains(configs, association.Config) {
+			return nil, fmt.Errorf("device %d cannot use UAV %d with configuration %d", association.Device, association.Uav, association.Config)
+		}
+
+		sol.updateDeviceAssociation(association.Device, uavConfigurationAssociation{association.Uav, association.Config})
+		assigned++
+	}
+
+	if assigned != int(problem.devices.Count()) {
+		return nil, fmt.Errorf("%d of %d devices are assigned", assigned, problem.devices.Count())
+	}
+
+	return sol, nil
+}
+
 func GetUAVSolutionFromDeployedUAVs(problem *UAVProblem, uavs []int32) (*UAVSolution, error) {
 	deviceCoverage := make(map[device.DeviceId]map[int][]int32)
 
@@ -907,24 +841,7 @@ func GetUAVSolutionFromDeployedUAVs(problem *UAVProblem, uavs []int32) (*UAVSolu
 	//	return 0
 	//})
 
-	numSlices := int32(len(problem.devices.Slices()))
-	numUavs := problem.uavPositions.Count()
-	numAssociations := numUavs * numSlices
-	sol := &UAVSolution{
-		deviceAssociation: make(map[device.DeviceId]uavConfigurationAssociation, problem.devices.Count()),
-		uavSliceDevices:   make(map[uavSliceKey][]device.DeviceId),
-		uavDevices:        make(map[int32][]device.DeviceId),
-		uavDatarate:       make(map[uavSliceKey]float32, numAssociations),
-		deployedUavs:      make([]int32, 0),
-		problem:           problem,
-	}
-
-	for uavId := int32(0); uavId < numUavs; uavId++ {
-		for slice := int32(0); slice < numSlices; slice++ {
-			key := uavSliceKey{uavId, slice}
-			sol.uavSliceDevices[key] = make([]device.DeviceId, 0)
-		}
-	}
+	sol := createEmptyUAVSolution(problem)
 
 	sf := 7
 	uavDevices := make(map[int32]int, len(uavs))
@@ -954,9 +871,8 @@ func GetUAVSolutionFromDeployedUAVs(problem *UAVProblem, uavs []int32) (*UAVSolu
 		selectedUav := int32(-1)
 		cover := deviceCoverage[devId][sf]
 		for _, uavId := range cover {
-			key := uavSliceKey{uavId, slice}
 			dr := sol.problem.gateway.GetDatarate(int16(sf), slice)
-			if sol.uavDatarate[key]+dr > sol.problem.gateway.GetMaxDatarate(slice) {
+			if sol.uavDatarate[sol.key(uavId, slice)]+dr > sol.problem.gateway.GetMaxDatarate(slice) {
 				continue
 			}
 
@@ -1024,9 +940,9 @@ func CrossoverOld(sol1, sol2 *UAVSolution, cprob, mprob float64) (*UAVSolution,
 	sol2Copy := sol2.copy()
 
 	numDevices := int32(len(sol1.problem.devices.GetDeviceIds()))
-	pivotId := device.DeviceId(rand.Int31n(numDevices - 1))
+	pivotId := device.DeviceId(sol1.problem.rng.Int31n(numDevices - 1))
 
-	r := rand.Float64()
+	r := sol1.problem.rng.Float64()
 	if r > cprob {
 		pivotId = device.DeviceId(numDevices)
 	}
@@ -1053,15 +969,19 @@ func CrossoverOld(sol1, sol2 *UAVSolution, cprob, mprob float64) (*UAVSolution,
 	return sol1Copy, sol2Copy
 }
 
-func Crossover(sol1, sol2 *UAVSolution, cprob, mprob float64) (*UAVSolution, *UAVSolution) {
+// Crossover draws every random number from rng, which must not be shared with other goroutines.
+// The children are bound to a view of the parents' problem using the same rng.
+func Crossover(sol1, sol2 *UAVSolution, cprob, mprob float64, rng *rand.Rand) (*UAVSolution, *UAVSolution) {
+	instance := sol1.problem.withRand(rng)
+
 	sol1UAVs := sol1.GetDeployedUavsGene()
 	sol2UAVs := sol2.GetDeployedUavsGene()
 
 	uavIDs := sol1.problem.GetUAVIds()
 	numUAVs := int32(len(uavIDs))
-	pivotUAV := int32(rand.Int31n(numUAVs - 1))
+	pivotUAV := int32(rng.Int31n(numUAVs - 1))
 
-	r := rand.Float64()
+	r := rng.Float64()
 	if r > cprob {
 		pivotUAV = numUAVs
 	}
@@ -1072,12 +992,12 @@ func Crossover(sol1, sol2 *UAVSolution, cprob, mprob float64) (*UAVSolution, *UA
 			sol2UAVs[uav] = sol1UAVs[uav]
 		}
 
-		if shouldMutate(mprob) {
+		if shouldMutate(mprob, rng) {
 			//fmt.Printf("--------------------- C1 Mutated ---------------------\n")
 			sol1UAVs[uav] = !sol1UAVs[uav]
 		}
 
-		if shouldMutate(mprob) {
+		if shouldMutate(mprob, rng) {
 			//fmt.Printf("--------------------- C2 Mutated ---------------------\n")
 			sol2UAVs[uav] = !sol2UAVs[uav]
 		}
@@ -1097,31 +1017,27 @@ func Crossover(sol1, sol2 *UAVSolution, cprob, mprob float64) (*UAVSolution, *UA
 		}
 	}
 
-	child1, _ := GetUAVSolutionFromDeployedUAVs(sol1.problem, deployedUAVs1)
-	child2, _ := GetUAVSolutionFromDeployedUAVs(sol2.problem, deployedUAVs2)
+	child1, _ := GetUAVSolutionFromDeployedUAVs(instance, deployedUAVs1)
+	child2, _ := GetUAVSolutionFromDeployedUAVs(instance, deployedUAVs2)
 
 	return child1, child2
 }
 
-func GetRandomUAVSolutionTabu(problem *UAVProblem, tabuUavs []int32, tabuRatio float32) (*UAVSolution, error) {
-	numSlices := int32(len(problem.devices.Slices()))
-	numUavs := problem.uavPositions.Count()
-	numAssociations := numUavs * numSlices
-	sol := &UAVSolution{
-		deviceAssociation: make(map[device.DeviceId]uavConfigurationAssociation, problem.devices.Count()),
-		uavSliceDevices:   make(map[uavSliceKey][]device.DeviceId),
-		uavDevices:        make(map[int32][]device.DeviceId),
-		uavDatarate:       make(map[uavSliceKey]float32, numAssociations),
-		deployedUavs:      make([]int32, 0),
-		problem:           problem,
-	}
-
-	for uavId := int32(0); uavId < numUavs; uavId++ {
-		for slice := int32(0); slice < numSlices; slice++ {
-			key := uavSliceKey{uavId, slice}
-			sol.uavSliceDevices[key] = make([]device.DeviceId, 0)
+// DecodeDeployedUavsGene builds the solution deploying the UAVs set in gene with
+// GetUAVSolutionFromDeployedUAVs, which may deploy more of them to cover every device. Random
+// numbers are drawn from rng, which must not be shared with other goroutines.
+func DecodeDeployedUavsGene(instance *UAVProblem, gene []bool, rng *rand.Rand) (*UAVSolution, error) {
+	uavs := make([]int32, 0)
+	for uavId, deployed := range gene {
+		if deployed {
+			uavs = append(uavs, int32(uavId))
 		}
 	}
+	return GetUAVSolutionFromDeployedUAVs(instance.withRand(rng), uavs)
+}
+
+func GetRandomUAVSolutionTabu(problem *UAVProblem, tabuUavs []int32, tabuRatio float32) (*UAVSolution, error) {
+	sol := createEmptyUAVSolution(problem)
 
 	usedUavs := make([]int32, 0)
 	// Random association for each device
@@ -1144,10 +1060,7 @@ func GetRandomUAVSolutionTabu(problem *UAVProblem, tabuUavs []int32, tabuRatio f
 
 func (sol *UAVSolution) OutputGatewayPositions() string {
 	output := "id,x,y,z\n"
-	uavs := make([]int32, 0)
-	for _, association := range sol.deviceAssociation {
-		uavs = append(uavs, association.uavId)
-	}
+	uavs := slices.Clone(sol.assignedUav)
 	uniqueUavs := utils.Unique(&uavs)
 
 	for _, uavId := range uniqueUavs {
@@ -1170,7 +1083,7 @@ func (sol *UAVSolution) OutputConfigurations() string {
 }
 
 func (sol *UAVSolution) mutate(id device.DeviceId, mprob float64) bool {
-	r := rand.Float64()
+	r := sol.problem.rng.Float64()
 	if r < mprob {
 		sol.updateDeviceAssociation(id, sol.problem.getRandomUavConfiguration(id))
 		return true
@@ -1178,8 +1091,8 @@ func (sol *UAVSolution) mutate(id device.DeviceId, mprob float64) bool {
 	return false
 }
 
-func shouldMutate(mprob float64) bool {
-	r := rand.Float64()
+func shouldMutate(mprob float64, rng *rand.Rand) bool {
+	r := rng.Float64()
 	if r < mprob {
 		return true
 	}
@@ -0,0 +1,216 @@
+package problem
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+)
+
+type ViolationKind string
+
+const (
+	ViolationUnassigned ViolationKind = "unassigned"
+	ViolationReach      ViolationKind = "reach"
+	ViolationQoS        ViolationKind = "qos"
+	ViolationCapacity   ViolationKind = "capacity"
+	ViolationCost       ViolationKind = "cost"
+	ViolationState      ViolationKind = "state"
+	ViolationArea       ViolationKind = "area"
+)
+
+type Violation struct {
+	Kind    ViolationKind
+	Message string
+}
+
+func (violation Violation) String() string {
+	return fmt.Sprintf("%s: %s", violation.Kind, violation.Message)
+}
+
+// ValidationReport holds the costs recomputed from the associations of a solution, the cost the
+// solution reported, and every violation found.
+type ValidationReport struct {
+	Cost         float64
+	CostA        float64
+	CostB        float64
+	ReportedCost float64
+	NumUavs      int
+	Violations   []Violation
+}
+
+func (report ValidationReport) Valid() bool {
+	return len(report.Violations) == 0
+}
+
+func (report ValidationReport) String() string {
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "cost: %f (UAVs: %f, SFs: %f), reported: %f, UAVs: %d\n", report.Cost, report.CostA, report.CostB, report.ReportedCost, report.NumUavs)
+	if report.Valid() {
+		builder.WriteString("valid\n")
+		return builder.String()
+	}
+
+	fmt.Fprintf(builder, "%d violations:\n", len(report.Violations))
+	for _, violation := range report.Violations {
+		fmt.Fprintf(builder, "  %s\n", violation)
+	}
+	return builder.String()
+}
+
+func (report *ValidationReport) add(kind ViolationKind, format string, args ...any) {
+	report.Violations = append(report.Violations, Violation{kind, fmt.Sprintf(format, args...)})
+}
+
+// Validate re-checks solution from first principles, using only its associations and the instance
+// data: every device is assigned, every association reaches its UAV and meets the QoS bound, no UAV
+// slice exceeds its datarate, and the cost the solution reports matches a fresh recomputation. The
+// reported cost is the one read from a record, when there is one, and GetCost otherwise. The
+// cached state of a UAVSolution, its cost included, is compared against the recomputed one as well.
+func (problem *UAVProblem) Validate(solution Solution) ValidationReport {
+	report := ValidationReport{ReportedCost: solution.GetCost()}
+	if sol, ok := solution.(*UAVSolution); ok {
+		if cost, reported := sol.GetReportedCost(); reported {
+			report.ReportedCost = cost
+		}
+	}
+
+	associations := solution.GetAssociations()
+	slices.SortFunc(associations, func(a, b Association) int { return int(a.Device) - int(b.Device) })
+
+	assigned := make(map[device.DeviceId]bool, len(associations))
+	uavs := make(map[int32]bool)
+	sfCount := make(map[int16]int)
+	datarate := make(map[uavSliceKey]float64)
+
+	for _, association := range associations {
+		deviceId := association.Device
+		if deviceId < 0 || int32(deviceId) >= problem.devices.Count() {
+			report.add(ViolationState, "device %d does not exist", deviceId)
+			continue
+		}
+		if assigned[deviceId] {
+			report.add(ViolationState, "device %d is assigned more than once", deviceId)
+			continue
+		}
+		assigned[deviceId] = true
+
+		if association.Uav < 0 || association.Uav >= problem.uavPositions.Count() {
+			report.add(ViolationState, "device %d is assigned to UAV %d, which does not exist", deviceId, association.Uav)
+			continue
+		}
+		config, found := problem.configurations[association.Config]
+		if !found {
+			report.add(ViolationState, "device %d uses configuration %d, which does not exist", deviceId, association.Config)
+			continue
+		}
+
+		if !problem.checkReachFeasibility(deviceId, association.Uav, association.Config) {
+			report.add(ViolationReach, "device %d cannot reach UAV %d with SF%d/%ddBm", deviceId, association.Uav, config.Sf, config.Tp)
+		}
+		if !problem.checkQoSFeasibility(deviceId, association.Config) {
+			report.add(ViolationQoS, "device %d has QoS %f with SF%d, the bound is %f", deviceId, problem.GetQoS(deviceId, association.Config), config.Sf, QoSBound)
+		}
+
+		slice := problem.devices.GetDevice(deviceId).Slice()
+		uavs[association.Uav] = true
+		sfCount[config.Sf]++
+		datarate[uavSliceKey{association.Uav, slice}] += float64(problem.gateway.GetDatarate(config.Sf, slice))
+	}
+
+	for _, deviceId := range problem.devices.GetDeviceIds() {
+		if !assigned[deviceId] {
+			report.add(ViolationUnassigned, "device %d is not assigned to any UAV", deviceId)
+		}
+	}
+
+	keys := make([]uavSliceKey, 0, len(datarate))
+	for key := range datarate {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b uavSliceKey) int {
+		if a.uavId != b.uavId {
+			return int(a.uavId - b.uavId)
+		}
+		return int(a.slice - b.slice)
+	})
+	for _, key := range keys {
+		maxDatarate := float64(problem.gateway.GetMaxDatarate(key.slice))
+		if datarate[key] > maxDatarate*(1+1e-6) {
+			report.add(ViolationCapacity, "UAV %d slice %d carries %f, the maximum is %f", key.uavId, key.slice, datarate[key], maxDatarate)
+		}
+	}
+
+	// ---------- Cost
+	maxSfCount := 0
+	for _, count := range sfCount {
+		maxSfCount = max(maxSfCount, count)
+	}
+
+	report.NumUavs = len(uavs)
+	report.CostA = float64(len(uavs)) * problem.alpha
+	report.CostB = float64(maxSfCount) * problem.beta
+	report.Cost = report.CostA + report.CostB
+	if !costEqual(report.Cost, report.ReportedCost) {
+		report.add(ViolationCost, "reported cost %f differs from the recomputed cost %f", report.ReportedCost, report.Cost)
+	}
+
+	// ---------- Cached state
+	if sol, ok := solution.(*UAVSolution); ok {
+		problem.validateState(sol, uavs, sfCount, datarate, &report)
+	}
+
+	return report
+}
+
+func (problem *UAVProblem) validateState(sol *UAVSolution, uavs map[int32]bool, sfCount map[int16]int, datarate map[uavSliceKey]float64, report *ValidationReport) {
+	deployed := slices.Clone(sol.deployedUavs)
+	slices.Sort(deployed)
+	used := make([]int32, 0, len(uavs))
+	for uavId := range uavs {
+		used = append(used, uavId)
+	}
+	slices.Sort(used)
+	if !slices.Equal(deployed, used) {
+		report.add(ViolationState, "deployed UAVs %v differ from the UAVs in use %v", deployed, used)
+	}
+
+	if cached := sol.GetCost(); !costEqual(cached, report.Cost) {
+		report.add(ViolationState, "cached cost %f differs from the recomputed cost %f", cached, report.Cost)
+	}
+
+	for key, cached := range sol.uavDatarate {
+		uavId, slice := int32(key)/sol.numSlices, int32(key)%sol.numSlices
+		if recomputed := datarate[uavSliceKey{uavId, slice}]; !costEqual(float64(cached), recomputed) {
+			report.add(ViolationState, "UAV %d slice %d has cached datarate %f, recomputed %f", uavId, slice, cached, recomputed)
+		}
+	}
+
+	for sf := int16(device.MinSF); sf <= device.MaxSF; sf++ {
+		if cached := sol.sfCount[sf-device.MinSF]; int(cached) != sfCount[sf] {
+			report.add(ViolationState, "SF%d has cached device count %d, recomputed %d", sf, cached, sfCount[sf])
+		}
+	}
+
+	listed := make([]int32, len(sol.uavCount))
+	for key := range sol.sliceHead {
+		uavId, slice := int32(key)/sol.numSlices, int32(key)%sol.numSlices
+		for _, deviceId := range sol.appendSliceDevices(nil, int32(key)) {
+			listed[uavId]++
+			if sol.GetAssignedUavId(deviceId) != uavId || problem.GetSlice(deviceId) != slice {
+				report.add(ViolationState, "device %d is listed under UAV %d slice %d but assigned to UAV %d slice %d", deviceId, uavId, slice, sol.GetAssignedUavId(deviceId), problem.GetSlice(deviceId))
+			}
+		}
+	}
+	for uavId, count := range sol.uavCount {
+		if listed[uavId] != count {
+			report.add(ViolationState, "UAV %d has cached device count %d, %d are listed", uavId, count, listed[uavId])
+		}
+	}
+}
+
+func costEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-6*max(1, math.Abs(a), math.Abs(b))
+}
@@ -0,0 +1,314 @@
+package solver
+
+import (
+	"context"
+	"math"
+	"slices"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+)
+
+type assignmentKey struct {
+	deviceId device.DeviceId
+	uavId    int32
+}
+
+// ACOSolver is an ant colony over the candidate positions and the device to UAV assignments. Ants
+// build solutions as GRASP does, deploying UAVs one at a time and filling each with uncovered
+// devices it reaches, but draw both choices in proportion to pheromone^pheromoneWeight *
+// desirability^heuristicWeight. A UAV is as desirable as the number of uncovered devices it
+// reaches, and a device as the inverse of the number of UAVs reaching it, so that hard to reach
+// devices are placed first.
+//
+// With mmas the colony is a MAX-MIN Ant System: only the best ant of each iteration deposits, and
+// trails are kept within bounds derived from the best cost so far. Otherwise every ant deposits
+// and trails are unbounded. With localSearch > 0, the best ant of each iteration is improved by
+// that many tabu search iterations before depositing.
+type ACOSolver struct {
+	problemInstance *problem.UAVProblem
+	uavTrail        map[int32]float64
+	assignmentTrail map[assignmentKey]float64
+	best            *problem.UAVSolution
+	warmStart       problem.Solution
+	ants            int
+	maxIterations   int
+	pheromoneWeight float64
+	heuristicWeight float64
+	evaporation     float64
+	mmas            bool
+	localSearch     int
+	avgCost         float64
+	tracker         tracker
+}
+
+func CreateACOSolver(instance *problem.UAVProblem, ants, maxIterations int, pheromoneWeight, heuristicWeight, evaporation float64, mmas bool, localSearch int) *ACOSolver {
+	return &ACOSolver{
+		problemInstance: instance,
+		ants:            ants,
+		maxIterations:   maxIterations,
+		pheromoneWeight: pheromoneWeight,
+		heuristicWeight: heuristicWeight,
+		evaporation:     evaporation,
+		mmas:            mmas,
+		localSearch:     localSearch,
+	}
+}
+
+func (solver *ACOSolver) SetObserver(observer Observer) {
+	solver.tracker.observer = observer
+}
+
+func (solver *ACOSolver) SetStopCriterion(criterion StopCriterion) {
+	solver.tracker.criterion = criterion
+}
+
+// WarmStart makes solution the best so far, so that it deposits on the trails from the start.
+func (solver *ACOSolver) WarmStart(solution problem.Solution) {
+	solver.warmStart = solution
+}
+
+func (solver *ACOSolver) Solve(ctx context.Context) Result {
+	solver.tracker.begin()
+	solver.best = nil
+	if solver.warmStart != nil {
+		solver.best = solver.warmStart.(*problem.UAVSolution)
+		solver.tracker.evaluate(1)
+		solver.tracker.improve(solver.best.GetCost())
+		solver.problemInstance.SetBestSolution(solver.best)
+	}
+	solver.initTrails()
+
+	for solver.tracker.iteration < solver.maxIterations && !solver.tracker.done(ctx) {
+		ants := make([]*problem.UAVSolution, solver.ants)
+		sum := 0.0
+		for i := range ants {
+			ants[i] = solver.construct()
+			sum += ants[i].GetCost()
+		}
+		solver.avgCost = sum / float64(len(ants))
+		solver.tracker.evaluate(len(ants))
+
+		iterationBest := ants[0]
+		for _, ant := range ants {
+			if ant.GetCost() < iterationBest.GetCost() {
+				iterationBest = ant
+			}
+		}
+		if solver.localSearch > 0 {
+			iterationBest = solver.improve(ctx, iterationBest)
+		}
+		solver.tracker.iterate()
+
+		if solver.best == nil || iterationBest.GetCost() < solver.best.GetCost() {
+			solver.best = iterationBest
+			solver.problemInstance.SetBestSolution(solver.best)
+			solver.tracker.improve(solver.best.GetCost())
+		}
+
+		if solver.mmas {
+			solver.updateTrails([]*problem.UAVSolution{iterationBest})
+		} else {
+			solver.updateTrails(ants)
+		}
+
+		solver.tracker.emit(Event{
+			CurrentCost:   iterationBest.GetCost(),
+			CandidateCost: math.NaN(),
+			AvgCost:       solver.avgCost,
+			Population:    len(ants),
+		})
+	}
+	solver.tracker.finish(StopIterations)
+
+	return solver.tracker.result(solver.best)
+}
+
+// initTrails sets every trail to the upper MMAS bound of the best cost so far, or to 1 when there
+// is none yet, which leaves the first ants guided by desirability alone.
+func (solver *ACOSolver) initTrails() {
+	initial := 1.0
+	if solver.best != nil {
+		initial, _ = solver.bounds()
+	}
+
+	solver.uavTrail = make(map[int32]float64)
+	solver.assignmentTrail = make(map[assignmentKey]float64)
+	for _, uavId := range solver.problemInstance.GetUAVIds() {
+		solver.uavTrail[uavId] = initial
+	}
+	for _, deviceId := range solver.problemInstance.GetDeviceIds() {
+		for _, uavId := range solver.problemInstance.GetPossibleUavs(deviceId) {
+			solver.assignmentTrail[assignmentKey{deviceId, uavId}] = initial
+		}
+	}
+}
+
+// bounds are the MMAS trail limits for the best cost so far.
+func (solver *ACOSolver) bounds() (float64, float64) {
+	tauMax := 1 / (solver.evaporation * solver.best.GetCost())
+	return tauMax, tauMax / float64(2*len(solver.uavTrail)+1)
+}
+
+// construct builds the solution of one ant. Devices that no free UAV reaches any more, because all
+// of theirs are deployed and full, go to one of their deployed UAVs and are left to the capacity
+// fix of problem.GetUAVSolution.
+func (solver *ACOSolver) construct() *problem.UAVSolution {
+	instance := solver.problemInstance
+	rng := instance.GetRand()
+
+	uncovered := make(map[device.DeviceId]bool)
+	for _, deviceId := range instance.GetDeviceIds() {
+		uncovered[deviceId] = true
+	}
+	free := instance.GetUAVIds()
+	deployed := make([]int32, 0)
+	coverage := make(map[int32][]device.DeviceId)
+
+	for len(uncovered) > 0 {
+		reach := make([][]device.DeviceId, len(free))
+		weights := make([]float64, len(free))
+		for i, uavId := range free {
+			for _, deviceId := range instance.GetCoverage(uavId) {
+				if uncovered[deviceId] {
+					reach[i] = append(reach[i], deviceId)
+				}
+			}
+			if len(reach[i]) > 0 {
+				weights[i] = math.Pow(solver.uavTrail[uavId], solver.pheromoneWeight) * math.Pow(float64(len(reach[i])), solver.heuristicWeight)
+			}
+		}
+
+		chosen := roulette(weights, rng.Float64())
+		if chosen < 0 {
+			break
+		}
+		uavId := free[chosen]
+		deployed = append(deployed, uavId)
+		coverage[uavId] = solver.fill(uavId, reach[chosen])
+		for _, deviceId := range coverage[uavId] {
+			delete(uncovered, deviceId)
+		}
+		free = slices.Delete(free, chosen, chosen+1)
+	}
+
+	for _, deviceId := range instance.GetDeviceIds() {
+		if !uncovered[deviceId] {
+			continue
+		}
+		for _, uavId := range deployed {
+			if slices.Contains(instance.GetPossibleUavs(deviceId), uavId) {
+				coverage[uavId] = append(coverage[uavId], deviceId)
+				break
+			}
+		}
+	}
+
+	sol, err := problem.GetUAVSolution(instance, deployed, coverage, 10)
+	if err != nil {
+		panic(err)
+	}
+	return sol
+}
+
+// fill draws the devices of uavId among candidates until no more fit in 90% of its capacity at SF
+// 10, which leaves room for the configurations GetUAVSolution picks when SF 10 does not reach.
+func (solver *ACOSolver) fill(uavId int32, candidates []device.DeviceId) []device.DeviceId {
+	instance := solver.problemInstance
+	rng := instance.GetRand()
+
+	candidates = slices.Clone(candidates)
+	weights := make([]float64, len(candidates))
+	for i, deviceId := range candidates {
+		desirability := 1 / float64(len(instance.GetPossibleUavs(deviceId)))
+		weights[i] = math.Pow(solver.assignmentTrail[assignmentKey{deviceId, uavId}], solver.pheromoneWeight) * math.Pow(desirability, solver.heuristicWeight)
+	}
+
+	covered := make([]device.DeviceId, 0)
+	usedCapacity := make(map[int32]float32)
+	for {
+		chosen := roulette(weights, rng.Float64())
+		if chosen < 0 {
+			return covered
+		}
+		deviceId := candidates[chosen]
+		weights[chosen] = 0
+
+		slice := instance.GetSlice(deviceId)
+		datarate := instance.GetDatarate(10, slice)
+		if usedCapacity[slice]+datarate > instance.GetMaxDatarate(slice)*0.9 {
+			continue
+		}
+		usedCapacity[slice] += datarate
+		covered = append(covered, deviceId)
+	}
+}
+
+// improve runs the tabu search from sol on a copy of the instance, as GA does with its best
+// individuals.
+func (solver *ACOSolver) improve(ctx context.Context, sol *problem.UAVSolution) *problem.UAVSolution {
+	tabuSolver := CreateTSSolver(solver.localSearch, 20, 25, solver.localSearch, 0.25, solver.problemInstance.Copy())
+	tabuSolver.problemInstance.SetCurrentSolution(sol)
+	result := tabuSolver.Solve(ctx)
+	solver.tracker.evaluate(result.Evaluations)
+	return result.Best.(*problem.UAVSolution)
+}
+
+// updateTrails evaporates every trail and lets each of the given solutions deposit the inverse of
+// its cost on its UAVs and assignments.
+func (solver *ACOSolver) updateTrails(solutions []*problem.UAVSolution) {
+	for uavId := range solver.uavTrail {
+		solver.uavTrail[uavId] *= 1 - solver.evaporation
+	}
+	for key := range solver.assignmentTrail {
+		solver.assignmentTrail[key] *= 1 - solver.evaporation
+	}
+
+	for _, sol := range solutions {
+		deposit := 1 / sol.GetCost()
+		for _, uavId := range sol.GetDeployedUavs() {
+			solver.uavTrail[uavId] += deposit
+		}
+		for _, association := range sol.GetAssociations() {
+			solver.assignmentTrail[assignmentKey{association.Device, association.Uav}] += deposit
+		}
+	}
+
+	if !solver.mmas {
+		return
+	}
+
+	tauMax, tauMin := solver.bounds()
+	for uavId, tau := range solver.uavTrail {
+		solver.uavTrail[uavId] = math.Max(tauMin, math.Min(tauMax, tau))
+	}
+	for key, tau := range solver.assignmentTrail {
+		solver.assignmentTrail[key] = math.Max(tauMin, math.Min(tauMax, tau))
+	}
+}
+
+// roulette picks an index with probability proportional to its weight, given a uniform draw in
+// [0, 1). It returns -1 when every weight is zero.
+func roulette(weights []float64, draw float64) int {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+	if total <= 0 {
+		return -1
+	}
+
+	target := draw * total
+	last := -1
+	for i, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		last = i
+		target -= weight
+		if target < 0 {
+			return i
+		}
+	}
+	return last
+}
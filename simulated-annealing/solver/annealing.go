@@ -1,12 +1,10 @@
 package solver
 
 import (
-	"fmt"
+	"context"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/utils"
 	"math"
-	"strings"
-	"time"
 )
 
 type SASolver struct {
@@ -17,12 +15,11 @@ type SASolver struct {
 	maxIterations     int
 	minDistance       int
 	maxDistance       int
-	log               strings.Builder
-	startTime         time.Time
+	tracker           tracker
 }
 
 func CreateSASolver(initialTemp, coolingRate float64, iterationsPerTemp, maxIterations, minDistance, maxDistance int, problem problem.Problem) *SASolver {
-	s := &SASolver{
+	return &SASolver{
 		problemInstance:   problem,
 		initialTemp:       initialTemp,
 		coolingRate:       coolingRate,
@@ -31,39 +28,49 @@ func CreateSASolver(initialTemp, coolingRate float64, iterationsPerTemp, maxIter
 		minDistance:       minDistance,
 		maxDistance:       maxDistance,
 	}
+}
+
+func (solver *SASolver) SetObserver(observer Observer) {
+	solver.tracker.observer = observer
+}
 
-	s.log.Grow(64 * maxIterations)
-	s.log.WriteString("it,temp,d,currCost,nextCost,bestCost,timestamp\n")
-	return s
+func (solver *SASolver) SetStopCriterion(criterion StopCriterion) {
+	solver.tracker.criterion = criterion
 }
 
-func (solver *SASolver) GetLog() string {
-	return solver.log.String()
+func (solver *SASolver) WarmStart(solution problem.Solution) {
+	solver.problemInstance.SetCurrentSolution(solution)
 }
 
-func (solver *SASolver) Solve() problem.Solution {
+func (solver *SASolver) Solve(ctx context.Context) Result {
+	solver.tracker.begin()
 	temp := solver.initialTemp
 
-	currSolution := solver.problemInstance.GetCurrentSolution()
-	solver.problemInstance.SetBestSolution(currSolution)
+	// the current solution is moved in place, so neither the caller's nor the best one may share it
+	currSolution := solver.problemInstance.GetCurrentSolution().Copy()
+	solver.problemInstance.SetCurrentSolution(currSolution)
+	solver.problemInstance.SetBestSolution(currSolution.Copy())
+	solver.tracker.evaluate(1)
+	solver.tracker.improve(currSolution.GetCost())
 
-	solver.startTime = time.Now()
-	numIterations := 0
-	for numIterations < solver.maxIterations {
-		solver.iterateOverTemp(temp, numIterations)
-		numIterations += solver.iterationsPerTemp
+	for solver.tracker.iteration < solver.maxIterations && !solver.tracker.done(ctx) {
+		solver.iterateOverTemp(ctx, temp)
 		temp = solver.cool(temp)
 	}
+	solver.tracker.finish(StopIterations)
 
-	return solver.problemInstance.GetBestSolution()
+	return solver.tracker.result(solver.problemInstance.GetBestSolution())
 }
 
-func (solver *SASolver) iterateOverTemp(temp float64, it int) {
+func (solver *SASolver) iterateOverTemp(ctx context.Context, temp float64) {
 	for i := 0; i < solver.iterationsPerTemp; i++ {
-		currSolution := solver.problemInstance.GetCurrentSolution()
-		nextSolution := currSolution.GetNeighbourSA(1, 5)
+		if solver.tracker.done(ctx) {
+			return
+		}
 
+		currSolution := solver.problemInstance.GetCurrentSolution()
 		currCost := currSolution.GetCost()
+		nextSolution := solver.neighbour(currSolution)
 		nextCost := nextSolution.GetCost()
 		bestCost := solver.problemInstance.GetBestSolution().GetCost()
 
@@ -72,23 +79,48 @@ func (solver *SASolver) iterateOverTemp(temp float64, it int) {
 			d = -1
 		}
 
-		checkpoint := time.Since(solver.startTime)
-		fmt.Printf("it: %d | temp: %f | d: %f | currSolution: %f | nextSolution: %f | bestSolution: %f | time: %v\n", it+i, temp, d, currCost, nextCost, bestCost, checkpoint)
-		solver.log.WriteString(fmt.Sprintf("%d,%f,%f,%f,%f,%f,%v\n", it+i, temp, d, currCost, nextCost, bestCost, checkpoint))
+		solver.tracker.iterate()
+		solver.tracker.evaluate(1)
 
-		if nextCost <= currCost {
+		if nextCost < bestCost {
+			solver.problemInstance.SetBestSolution(nextSolution.Copy())
+			solver.tracker.improve(nextCost)
+		}
+
+		accept := nextCost <= currCost || utils.GetRandomProbability(solver.problemInstance.GetRand()) < d
+		uavSolution, inPlace := nextSolution.(*problem.UAVSolution)
+		switch {
+		case accept && inPlace:
+			uavSolution.Commit()
+		case accept:
 			solver.problemInstance.SetCurrentSolution(nextSolution)
-		} else {
-			if utils.GetRandomProbability() < d {
-				solver.problemInstance.SetCurrentSolution(nextSolution)
+		case inPlace:
+			for uavSolution.Undo() {
 			}
 		}
 
-		if nextCost <= bestCost {
-			solver.problemInstance.SetBestSolution(nextSolution)
-		}
+		solver.tracker.emit(Event{
+			CurrentCost:   currCost,
+			CandidateCost: nextCost,
+			Temperature:   temp,
+			Acceptance:    d,
+		})
+	}
+}
+
+// neighbour draws the candidate of an iteration at a random distance from sol. A UAVSolution is
+// moved in place, to be committed or undone by the caller, and other solutions are copied.
+func (solver *SASolver) neighbour(sol problem.Solution) problem.Solution {
+	uavSolution, ok := sol.(*problem.UAVSolution)
+	if !ok {
+		return sol.GetNeighbourSA(solver.minDistance, solver.maxDistance)
+	}
 
+	distance := solver.problemInstance.GetRand().Intn(solver.maxDistance-solver.minDistance) + solver.minDistance
+	for it := uavSolution.Candidates(distance); it.Next(); {
+		uavSolution.Apply(it.Move())
 	}
+	return uavSolution
 }
 
 func (solver *SASolver) cool(temp float64) float64 {
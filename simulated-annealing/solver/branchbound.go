@@ -0,0 +1,955 @@
+package solver
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"math/bits"
+	"slices"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+)
+
+const (
+	bbEpsilon = 1e-9
+	// number of assignment nodes explored between two checks of the stop criteria
+	bbCheckInterval = 4096
+	// tabu search iterations improving the GRASP construction that seeds the incumbent
+	bbSeedIterations = 1000
+	bbNumSfs         = device.MaxSF - device.MinSF + 1
+)
+
+// bbOption assigns a device to a UAV with an SF. Configurations sharing an SF only differ in their
+// transmission power, which changes neither the cost nor the load, so one per SF is enough.
+type bbOption struct {
+	uav      int32
+	sf       int16
+	config   int32
+	datarate float64
+}
+
+// sfSet is a set of SFs, one bit per SF from device.MinSF.
+type sfSet uint8
+
+func sfBit(sf int16) sfSet {
+	return 1 << (sf - device.MinSF)
+}
+
+// sfLoads is the load of a device on each SF, with the SFs from the lightest one.
+type sfLoads struct {
+	load  [bbNumSfs]float64
+	order [bbNumSfs]int
+}
+
+func newSfLoads(load [bbNumSfs]float64) sfLoads {
+	loads := sfLoads{load: load}
+	for sf := range loads.order {
+		loads.order[sf] = sf
+	}
+	slices.SortStableFunc(loads.order[:], func(a, b int) int { return cmp.Compare(load[a], load[b]) })
+	return loads
+}
+
+// bbBudget bounds a deployment to uavs UAVs and sfCount devices per SF, which together bound its
+// cost.
+type bbBudget struct {
+	uavs    int
+	sfCount int
+}
+
+// BBSolver finds a proven optimum with a branch-and-bound over the deployments. The budgets of UAVs
+// and devices per SF are checked in increasing cost, so the first one a deployment fits is optimal.
+// For each budget, the search branches on the UAVs that can relieve the most constrained devices:
+// an uncovered device, or else a set of SFs that more devices are restricted to than the budget
+// lets share it, which by Hall's theorem is what rules an assignment out when the capacities are
+// ignored. Deployments passing that test go through an exact assignment search under the slice
+// capacities, whose failures are cached. The search is exponential and meant for small instances.
+type BBSolver struct {
+	instance     *problem.UAVProblem
+	alpha        float64
+	beta         float64
+	numDevices   int
+	numUavs      int
+	devices      []device.DeviceId
+	slices       []int
+	maxDatarates []float64
+	datarates    []sfLoads
+	sharedLoads  sfLoads
+	options      [][]bbOption
+	coveringUavs [][]int32
+	coveringSfs  [][]sfSet
+	uavDevices   [][]int
+	packingOrder []int
+	minSfCount   int
+	deployed     []bool
+	excluded     []bool
+	coverCount   []int
+	infeasible   map[string]int
+	current      []bbOption
+	best         problem.Solution
+	bestCost     float64
+	innerNodes   int
+	stopped      bool
+	ctx          context.Context
+	warmStart    problem.Solution
+	tracker      tracker
+}
+
+func CreateBBSolver(instance *problem.UAVProblem) *BBSolver {
+	solver := &BBSolver{
+		instance: instance,
+		alpha:    instance.GetAlpha(),
+		beta:     instance.GetBeta(),
+		devices:  instance.GetDeviceIds(),
+		numUavs:  len(instance.GetUAVIds()),
+	}
+	solver.numDevices = len(solver.devices)
+
+	sliceIdx := make(map[int32]int)
+	solver.slices = make([]int, solver.numDevices)
+	solver.options = make([][]bbOption, solver.numDevices)
+	solver.coveringUavs = make([][]int32, solver.numDevices)
+	solver.coveringSfs = make([][]sfSet, solver.numDevices)
+	solver.uavDevices = make([][]int, solver.numUavs)
+	usableSfs := make([]sfSet, solver.numDevices)
+
+	for i, deviceId := range solver.devices {
+		slice := instance.GetSlice(deviceId)
+		if _, found := sliceIdx[slice]; !found {
+			sliceIdx[slice] = len(solver.maxDatarates)
+			solver.maxDatarates = append(solver.maxDatarates, float64(instance.GetMaxDatarate(slice)))
+			solver.datarates = append(solver.datarates, sliceDatarates(instance, slice))
+		}
+		solver.slices[i] = sliceIdx[slice]
+
+		for _, uavId := range instance.GetPossibleUavs(deviceId) {
+			// configurations are sorted, so the first one of each SF is kept, unless the SF is too fast
+			// for the slice capacity to hold even a single device
+			sfs := sfSet(0)
+			for _, configId := range instance.GetPossibleConfigs(deviceId, uavId) {
+				sf := int16(device.GetSF(configId))
+				datarate := solver.datarates[solver.slices[i]].load[sf-device.MinSF]
+				if sfs&sfBit(sf) != 0 || datarate > solver.maxDatarates[solver.slices[i]]+bbEpsilon {
+					continue
+				}
+				sfs |= sfBit(sf)
+				solver.options[i] = append(solver.options[i], bbOption{uavId, sf, configId, datarate})
+			}
+			if sfs == 0 {
+				continue
+			}
+
+			solver.coveringUavs[i] = append(solver.coveringUavs[i], uavId)
+			solver.coveringSfs[i] = append(solver.coveringSfs[i], sfs)
+			solver.uavDevices[uavId] = append(solver.uavDevices[uavId], i)
+			usableSfs[i] |= sfs
+		}
+	}
+
+	// the smallest share of its slice capacity a device of each SF takes, whatever its slice
+	var shares [bbNumSfs]float64
+	for sf := range shares {
+		shares[sf] = math.Inf(1)
+		for slice, datarates := range solver.datarates {
+			shares[sf] = min(shares[sf], datarates.load[sf]/solver.maxDatarates[slice])
+		}
+	}
+	solver.sharedLoads = newSfLoads(shares)
+
+	// the packing bound picks devices with few candidates first, as they block fewer others
+	solver.packingOrder = make([]int, solver.numDevices)
+	for i := range solver.packingOrder {
+		solver.packingOrder[i] = i
+	}
+	slices.SortStableFunc(solver.packingOrder, func(i, j int) int {
+		return cmp.Compare(len(solver.coveringUavs[i]), len(solver.coveringUavs[j]))
+	})
+
+	solver.minSfCount = sfBound(usableSfs)
+
+	return solver
+}
+
+// sliceDatarates returns the datarate of each SF in slice.
+func sliceDatarates(instance *problem.UAVProblem, slice int32) sfLoads {
+	var datarates [bbNumSfs]float64
+	for sf := range datarates {
+		datarates[sf] = float64(instance.GetDatarate(int16(sf+device.MinSF), slice))
+	}
+	return newSfLoads(datarates)
+}
+
+func (solver *BBSolver) SetObserver(observer Observer) {
+	solver.tracker.observer = observer
+}
+
+func (solver *BBSolver) SetStopCriterion(criterion StopCriterion) {
+	solver.tracker.criterion = criterion
+}
+
+// WarmStart uses solution as the initial incumbent, whose cost prunes the search from the start.
+func (solver *BBSolver) WarmStart(solution problem.Solution) {
+	solver.warmStart = solution
+}
+
+// Solve stops with StopOptimal once the search space is exhausted, in which case the best solution
+// is a proven optimum, or the instance has none. Stopped earlier, it returns the best solution found
+// so far, if any.
+func (solver *BBSolver) Solve(ctx context.Context) Result {
+	solver.tracker.begin()
+	solver.ctx = ctx
+	solver.stopped = false
+	solver.innerNodes = 0
+	solver.deployed = make([]bool, solver.numUavs)
+	solver.excluded = make([]bool, solver.numUavs)
+	solver.coverCount = make([]int, solver.numDevices)
+	solver.infeasible = make(map[string]int)
+	solver.current = make([]bbOption, solver.numDevices)
+	solver.best = nil
+	solver.bestCost = math.Inf(1)
+
+	incumbent := solver.warmStart
+	if incumbent == nil {
+		incumbent = solver.seed(ctx)
+	} else {
+		solver.tracker.evaluate(1)
+	}
+	if incumbent != nil {
+		solver.best = incumbent
+		solver.bestCost = incumbent.GetCost()
+		solver.instance.SetBestSolution(incumbent)
+		solver.tracker.improve(solver.bestCost)
+	}
+
+	for _, budget := range solver.budgets() {
+		lowerBound := solver.alpha*float64(budget.uavs) + solver.beta*float64(budget.sfCount)
+		if lowerBound >= solver.bestCost-bbEpsilon || solver.done() {
+			break
+		}
+		solver.tracker.emit(Event{
+			CurrentCost:   lowerBound,
+			CandidateCost: math.NaN(),
+		})
+
+		// every cheaper budget has been ruled out, so the first deployment found is optimal
+		if solver.search(0, budget.uavs, budget.sfCount) {
+			solver.improve()
+			break
+		}
+	}
+	solver.tracker.finish(StopOptimal)
+
+	return solver.tracker.result(solver.best)
+}
+
+// seed builds the initial incumbent when there is no warm start: a greedy randomized construction
+// as GRASP makes, improved by a short tabu search on a copy of the instance, as ACO improves its
+// ants. The heuristics panic when they cannot fix the slice capacities, which tight instances
+// provoke, so a failed tabu search falls back to the construction and a failed construction leaves
+// the search without an incumbent.
+func (solver *BBSolver) seed(ctx context.Context) problem.Solution {
+	instance := solver.instance.Copy()
+	construction := recoverSolution(func() problem.Solution { return CreateGRASPSolver(instance).SolveFast() })
+	if construction == nil {
+		return nil
+	}
+	solver.tracker.evaluate(1)
+
+	tabuSolver := CreateTSSolver(bbSeedIterations, 20, 25, bbSeedIterations, 0.25, instance)
+	improved := recoverSolution(func() problem.Solution {
+		tabuSolver.problemInstance.SetCurrentSolution(construction)
+		result := tabuSolver.Solve(ctx)
+		solver.tracker.evaluate(result.Evaluations)
+		return result.Best
+	})
+	if improved == nil {
+		return construction
+	}
+	return improved
+}
+
+// recoverSolution runs build and turns a panic into a nil solution.
+func recoverSolution(build func() problem.Solution) (sol problem.Solution) {
+	defer func() {
+		if recover() != nil {
+			sol = nil
+		}
+	}()
+	return build()
+}
+
+func (solver *BBSolver) done() bool {
+	if !solver.stopped && solver.tracker.done(solver.ctx) {
+		solver.stopped = true
+	}
+	return solver.stopped
+}
+
+// budgets lists the budgets in increasing cost, from the UAVs needed to cover the devices and the
+// devices per SF needed with every UAV deployed. A term without weight is left at its loosest
+// value, as tightening it cannot lower the cost.
+func (solver *BBSolver) budgets() []bbBudget {
+	if solver.minSfCount > solver.numDevices {
+		// some device has no UAV in reach
+		return nil
+	}
+
+	uavCounts := []int{solver.numUavs}
+	if solver.alpha != 0 {
+		uavCounts = uavCounts[:0]
+		for uavs := solver.packingBound(); uavs <= solver.numUavs; uavs++ {
+			uavCounts = append(uavCounts, uavs)
+		}
+	}
+	sfCounts := []int{solver.numDevices}
+	if solver.beta != 0 {
+		sfCounts = sfCounts[:0]
+		for sfCount := solver.minSfCount; sfCount <= solver.numDevices; sfCount++ {
+			sfCounts = append(sfCounts, sfCount)
+		}
+	}
+
+	budgets := make([]bbBudget, 0, len(uavCounts)*len(sfCounts))
+	for _, uavs := range uavCounts {
+		for _, sfCount := range sfCounts {
+			budgets = append(budgets, bbBudget{uavs, sfCount})
+		}
+	}
+	slices.SortStableFunc(budgets, func(a, b bbBudget) int {
+		return cmp.Compare(solver.alpha*float64(a.uavs)+solver.beta*float64(a.sfCount),
+			solver.alpha*float64(b.uavs)+solver.beta*float64(b.sfCount))
+	})
+	return budgets
+}
+
+// search looks for a deployment of at most maxUavs UAVs extending the current one, where
+// numDeployed UAVs are deployed, on which the devices can be assigned with at most sfCount devices
+// per SF. When it succeeds, the assignment is left in solver.current.
+func (solver *BBSolver) search(numDeployed, maxUavs, sfCount int) bool {
+	if solver.done() {
+		return false
+	}
+	solver.tracker.iterate()
+
+	if numDeployed+solver.packingBound() > maxUavs {
+		return false
+	}
+
+	available := make([]int32, 0)
+	for uavId := int32(0); uavId < int32(solver.numUavs); uavId++ {
+		if !solver.deployed[uavId] && !solver.excluded[uavId] && len(solver.uavDevices[uavId]) > 0 {
+			available = append(available, uavId)
+		}
+	}
+	// no deployment left to reach satisfies Hall's condition, or fits the devices in maxUavs UAVs
+	reachable := solver.reachableSfs(true)
+	if sfBound(reachable) > sfCount || !solver.capacityFits(reachable, maxUavs, sfCount) {
+		return false
+	}
+
+	if numDeployed+len(available) <= maxUavs {
+		// the budget fits every UAV left, and deploying them all dominates deploying a part of them
+		for _, uavId := range available {
+			solver.deploy(uavId)
+		}
+		found := solver.feasible(sfCount)
+		for _, uavId := range available {
+			solver.withdraw(uavId)
+		}
+		return found
+	}
+
+	candidates, constrained := solver.candidates(sfCount)
+	if !constrained {
+		if solver.feasible(sfCount) {
+			return true
+		}
+		// the slice capacities rule the deployment out, which any other UAV may help with
+		candidates = available
+	}
+	if numDeployed == maxUavs {
+		return false
+	}
+
+	// once a UAV has been tried, the following branches exclude it to avoid visiting a deployment twice
+	found := false
+	for _, uavId := range candidates {
+		solver.deploy(uavId)
+		found = solver.search(numDeployed+1, maxUavs, sfCount)
+		solver.withdraw(uavId)
+		solver.excluded[uavId] = true
+		if found || solver.stopped {
+			break
+		}
+	}
+	for _, uavId := range candidates {
+		solver.excluded[uavId] = false
+	}
+	return found
+}
+
+// capacityFits checks that maxUavs UAVs reaching every device could hold the devices restricted to
+// the reachable SFs, with at most sfCount devices per SF.
+func (solver *BBSolver) capacityFits(reachable []sfSet, maxUavs, sfCount int) bool {
+	demands := make([]bbDemand, solver.numDevices)
+	for i, sfs := range reachable {
+		demands[i] = bbDemand{slice: solver.slices[i], sfs: sfs, uavs: math.MaxUint64}
+	}
+	free := make([][]float64, maxUavs)
+	for idx := range free {
+		free[idx] = solver.maxDatarates
+	}
+	return solver.groupHolds(demands, free, uniformSlots(sfCount), math.MaxUint64)
+}
+
+// candidates returns the UAVs one of which any feasible deployment extending the current one
+// deploys, those relieving the most devices first, so that good deployments are found early. It
+// returns false when no such constraint is violated: every device is covered and the SF counts
+// satisfy Hall's condition.
+func (solver *BBSolver) candidates(sfCount int) ([]int32, bool) {
+	gain := make([]int, solver.numUavs)
+	candidates := make([]int32, 0)
+
+	// uncovered device with the fewest UAVs left to cover it
+	target := -1
+	for i := 0; i < solver.numDevices; i++ {
+		if solver.coverCount[i] > 0 {
+			continue
+		}
+		available := make([]int32, 0, len(solver.coveringUavs[i]))
+		for _, uavId := range solver.coveringUavs[i] {
+			if !solver.excluded[uavId] {
+				available = append(available, uavId)
+			}
+		}
+		if target == -1 || len(available) < len(candidates) {
+			target = i
+			candidates = available
+		}
+	}
+
+	if target != -1 {
+		for _, uavId := range candidates {
+			for _, i := range solver.uavDevices[uavId] {
+				if solver.coverCount[i] == 0 {
+					gain[uavId]++
+				}
+			}
+		}
+	} else {
+		reachable := solver.reachableSfs(false)
+		restricted, violated := hallViolation(subsetCounts(reachable), uniformSlots(sfCount))
+		if !violated {
+			return nil, false
+		}
+
+		// the devices restricted to the SF set need another SF, which a UAV offers them
+		for i := 0; i < solver.numDevices; i++ {
+			if reachable[i]&^restricted != 0 {
+				continue
+			}
+			for j, uavId := range solver.coveringUavs[i] {
+				if solver.deployed[uavId] || solver.excluded[uavId] || solver.coveringSfs[i][j]&^restricted == 0 {
+					continue
+				}
+				if gain[uavId] == 0 {
+					candidates = append(candidates, uavId)
+				}
+				gain[uavId]++
+			}
+		}
+		slices.Sort(candidates)
+	}
+
+	slices.SortStableFunc(candidates, func(a, b int32) int { return cmp.Compare(gain[b], gain[a]) })
+	return candidates, true
+}
+
+// reachableSfs returns the SFs each device can use with the deployed UAVs, and with the ones not
+// excluded yet if undecided is set.
+func (solver *BBSolver) reachableSfs(undecided bool) []sfSet {
+	reachable := make([]sfSet, solver.numDevices)
+	for i := range reachable {
+		for j, uavId := range solver.coveringUavs[i] {
+			if solver.deployed[uavId] || undecided && !solver.excluded[uavId] {
+				reachable[i] |= solver.coveringSfs[i][j]
+			}
+		}
+	}
+	return reachable
+}
+
+// sfCounts counts devices by set of SFs.
+type sfCounts [1 << bbNumSfs]int
+
+// subsetCounts counts, for every set of SFs, the devices whose SFs all belong to it.
+func subsetCounts(usable []sfSet) *sfCounts {
+	var count sfCounts
+	for _, sfs := range usable {
+		count[sfs]++
+	}
+	for bit := sfSet(1); bit < 1<<bbNumSfs; bit <<= 1 {
+		for set := sfSet(0); set < 1<<bbNumSfs; set++ {
+			if set&bit != 0 {
+				count[set] += count[set&^bit]
+			}
+		}
+	}
+	return &count
+}
+
+// sfBound is the smallest maximum SF count letting each device use one of its usable SFs, the
+// UAVs and their capacities aside. Devices restricted to a set of SFs share it, and by Hall's
+// theorem the largest of these shares is the bound. It exceeds the number of devices when a device
+// has no usable SF.
+func sfBound(usable []sfSet) int {
+	count := subsetCounts(usable)
+	if count[0] > 0 {
+		return count[1<<bbNumSfs-1] + 1
+	}
+
+	bound := 0
+	for set := 1; set < 1<<bbNumSfs; set++ {
+		bound = max(bound, ceilDiv(count[set], bits.OnesCount8(uint8(set))))
+	}
+	return bound
+}
+
+// hallViolation returns a set of SFs more of the counted devices are restricted to than its free
+// slots accommodate, picking the one restricting the fewest devices. A device without usable SF
+// violates the empty set.
+func hallViolation(count *sfCounts, freeSlots [bbNumSfs]int) (sfSet, bool) {
+	if count[0] > 0 {
+		return 0, true
+	}
+
+	var slots [1 << bbNumSfs]int
+	restricted, violated := sfSet(0), false
+	for set := sfSet(1); set < 1<<bbNumSfs; set++ {
+		lowest := set & -set
+		slots[set] = slots[set&^lowest] + freeSlots[bits.TrailingZeros8(uint8(lowest))]
+		if count[set] > slots[set] && (!violated || count[set] < count[restricted]) {
+			restricted, violated = set, true
+		}
+	}
+	return restricted, violated
+}
+
+// uniformSlots gives every SF sfCount free slots.
+func uniformSlots(sfCount int) [bbNumSfs]int {
+	var slots [bbNumSfs]int
+	for sf := range slots {
+		slots[sf] = sfCount
+	}
+	return slots
+}
+
+func (solver *BBSolver) deploy(uavId int32) {
+	solver.deployed[uavId] = true
+	for _, i := range solver.uavDevices[uavId] {
+		solver.coverCount[i]++
+	}
+}
+
+func (solver *BBSolver) withdraw(uavId int32) {
+	solver.deployed[uavId] = false
+	for _, i := range solver.uavDevices[uavId] {
+		solver.coverCount[i]--
+	}
+}
+
+// packingBound counts uncovered devices that pairwise share no available UAV, each of which needs a
+// UAV of its own.
+func (solver *BBSolver) packingBound() int {
+	blocked := make([]bool, solver.numUavs)
+	count := 0
+	for _, i := range solver.packingOrder {
+		if solver.coverCount[i] > 0 {
+			continue
+		}
+
+		free := true
+		for _, uavId := range solver.coveringUavs[i] {
+			if !solver.excluded[uavId] && blocked[uavId] {
+				free = false
+				break
+			}
+		}
+		if !free {
+			continue
+		}
+
+		count++
+		for _, uavId := range solver.coveringUavs[i] {
+			blocked[uavId] = true
+		}
+	}
+	return count
+}
+
+// feasible searches an assignment of the devices to the deployed UAVs with at most sfCount devices
+// per SF. The budgets checked one after the other revisit the same deployments, so the largest
+// count each one is known to fail with is cached.
+func (solver *BBSolver) feasible(sfCount int) bool {
+	key := solver.deploymentKey()
+	if failed, found := solver.infeasible[key]; found && sfCount <= failed {
+		return false
+	}
+
+	state := solver.newAssignment(sfCount)
+	if state == nil {
+		solver.infeasible[key] = solver.numDevices
+		return false
+	}
+
+	solver.tracker.evaluate(1)
+	if solver.assign(state) {
+		return true
+	}
+	if !solver.stopped {
+		solver.infeasible[key] = max(solver.infeasible[key], sfCount)
+	}
+	return false
+}
+
+func (solver *BBSolver) deploymentKey() string {
+	key := make([]byte, (solver.numUavs+7)/8)
+	for uavId, deployed := range solver.deployed {
+		if deployed {
+			key[uavId/8] |= 1 << (uavId % 8)
+		}
+	}
+	return string(key)
+}
+
+// bbDemand is what a device left to assign can still use: its SFs and, one bit per UAV deployed, its
+// UAVs.
+type bbDemand struct {
+	slice int
+	sfs   sfSet
+	uavs  uint64
+}
+
+// assignment is the state of the inner search over the devices. Loads are indexed by UAV and slice.
+// Devices of a slice with the same UAVs and SFs are interchangeable, so the members of such a class
+// are assigned in order, each to an option at or after the one of the previous member.
+type assignment struct {
+	options    [][]bbOption
+	assigned   []bool
+	remaining  int
+	class      []int
+	members    [][]int
+	numDone    []int
+	floor      []int
+	sfCount    [bbNumSfs]int
+	maxSfCount int
+	uavs       []int32
+	uavBits    []uint64
+	load       []float64
+}
+
+// newAssignment restricts the options of the devices to the deployed UAVs, or returns nil when a
+// device has none.
+func (solver *BBSolver) newAssignment(maxSfCount int) *assignment {
+	state := &assignment{
+		options:    make([][]bbOption, solver.numDevices),
+		assigned:   make([]bool, solver.numDevices),
+		remaining:  solver.numDevices,
+		maxSfCount: maxSfCount,
+		uavBits:    make([]uint64, solver.numUavs),
+		load:       make([]float64, solver.numUavs*len(solver.maxDatarates)),
+	}
+
+	for uavId, deployed := range solver.deployed {
+		if deployed {
+			state.uavs = append(state.uavs, int32(uavId))
+		}
+	}
+	// past 64 UAVs, they all share the bits and the capacity checks only consider them together
+	for idx, uavId := range state.uavs {
+		state.uavBits[uavId] = math.MaxUint64
+		if len(state.uavs) <= 64 {
+			state.uavBits[uavId] = 1 << idx
+		}
+	}
+
+	state.class = make([]int, solver.numDevices)
+	classes := make(map[string]int)
+	for i := 0; i < solver.numDevices; i++ {
+		key := []byte{byte(solver.slices[i]), byte(solver.slices[i] >> 8)}
+		for _, option := range solver.options[i] {
+			if solver.deployed[option.uav] {
+				state.options[i] = append(state.options[i], option)
+				key = append(key, byte(option.uav), byte(option.uav>>8), byte(option.sf))
+			}
+		}
+		if len(state.options[i]) == 0 {
+			return nil
+		}
+
+		class, found := classes[string(key)]
+		if !found {
+			class = len(state.members)
+			classes[string(key)] = class
+			state.members = append(state.members, nil)
+		}
+		state.class[i] = class
+		state.members[class] = append(state.members[class], i)
+	}
+	state.numDone = make([]int, len(state.members))
+	state.floor = make([]int, len(state.members))
+
+	return state
+}
+
+func (solver *BBSolver) fits(state *assignment, i int, option bbOption) bool {
+	slice := solver.slices[i]
+	return state.sfCount[option.sf-device.MinSF] < state.maxSfCount &&
+		state.load[int(option.uav)*len(solver.maxDatarates)+slice]+option.datarate <= solver.maxDatarates[slice]+bbEpsilon
+}
+
+// assign tries to assign the remaining devices without exceeding the maximum SF count or any slice
+// capacity. It branches on the device with the fewest options left, after checking that every
+// device has one, that the SFs they leave satisfy Hall's condition on the free SF slots and that
+// the capacities of the UAVs they leave hold them.
+func (solver *BBSolver) assign(state *assignment) bool {
+	if state.remaining == 0 {
+		return true
+	}
+
+	solver.innerNodes++
+	if solver.innerNodes%bbCheckInterval == 0 && solver.done() {
+		return false
+	}
+
+	target, targetOptions := -1, 0
+	demands := make([]bbDemand, 0, state.remaining)
+	for i, assigned := range state.assigned {
+		if assigned {
+			continue
+		}
+
+		class := state.class[i]
+		numOptions, demand := 0, bbDemand{slice: solver.slices[i]}
+		for _, option := range state.options[i][state.floor[class]:] {
+			if solver.fits(state, i, option) {
+				numOptions++
+				demand.sfs |= sfBit(option.sf)
+				demand.uavs |= state.uavBits[option.uav]
+			}
+		}
+		if numOptions == 0 {
+			return false
+		}
+		// only the next member of each class can be assigned
+		next := state.members[class][state.numDone[class]] == i
+		if next && (target == -1 || numOptions < targetOptions) {
+			target, targetOptions = i, numOptions
+		}
+		demands = append(demands, demand)
+	}
+
+	var freeSlots [bbNumSfs]int
+	for sf, count := range state.sfCount {
+		freeSlots[sf] = state.maxSfCount - count
+	}
+	if !solver.holds(demands, state.freeCapacities(len(solver.maxDatarates), solver.maxDatarates), freeSlots) {
+		return false
+	}
+
+	// least used SF first, which keeps the counts balanced
+	class := state.class[target]
+	floor := state.floor[class]
+	choices := make([]int, 0, targetOptions)
+	for choice := floor; choice < len(state.options[target]); choice++ {
+		if solver.fits(state, target, state.options[target][choice]) {
+			choices = append(choices, choice)
+		}
+	}
+	slices.SortStableFunc(choices, func(a, b int) int {
+		return cmp.Compare(state.sfCount[state.options[target][a].sf-device.MinSF], state.sfCount[state.options[target][b].sf-device.MinSF])
+	})
+
+	state.assigned[target] = true
+	state.remaining--
+	state.numDone[class]++
+	numSlices := len(solver.maxDatarates)
+	slice := solver.slices[target]
+	found := false
+	for _, choice := range choices {
+		option := state.options[target][choice]
+		load := int(option.uav)*numSlices + slice
+		state.sfCount[option.sf-device.MinSF]++
+		state.load[load] += option.datarate
+		state.floor[class] = choice
+		solver.current[target] = option
+
+		found = solver.assign(state)
+
+		state.sfCount[option.sf-device.MinSF]--
+		state.load[load] -= option.datarate
+
+		if found || solver.stopped {
+			break
+		}
+	}
+	state.floor[class] = floor
+	state.numDone[class]--
+	state.assigned[target] = false
+	state.remaining++
+
+	return found
+}
+
+// freeCapacities returns the free capacity of each deployed UAV by slice, in the order of
+// state.uavs.
+func (state *assignment) freeCapacities(numSlices int, maxDatarates []float64) [][]float64 {
+	free := make([][]float64, len(state.uavs))
+	for idx, uavId := range state.uavs {
+		free[idx] = make([]float64, numSlices)
+		for slice, maxDatarate := range maxDatarates {
+			free[idx][slice] = maxDatarate - state.load[int(uavId)*numSlices+slice]
+		}
+	}
+	return free
+}
+
+// holds checks that the devices left, asking for demands, can use the SFs with freeSlots devices
+// each and fit in the free capacities of the UAVs. It checks each set of UAVs some device is
+// restricted to, with the devices restricted to it, as well as all the UAVs together.
+func (solver *BBSolver) holds(demands []bbDemand, free [][]float64, freeSlots [bbNumSfs]int) bool {
+	usable := make([]sfSet, len(demands))
+	for i, demand := range demands {
+		usable[i] = demand.sfs
+	}
+	if _, violated := hallViolation(subsetCounts(usable), freeSlots); violated {
+		return false
+	}
+
+	groups := []uint64{math.MaxUint64}
+	for _, demand := range demands {
+		if !slices.Contains(groups, demand.uavs) {
+			groups = append(groups, demand.uavs)
+		}
+	}
+	for _, group := range groups {
+		if !solver.groupHolds(demands, free, freeSlots, group) {
+			return false
+		}
+	}
+	return true
+}
+
+// groupHolds checks the capacity of the UAVs in group against the devices restricted to them. Each
+// UAV holds as many more devices of an SF as its free capacity fits, besides the SF count, and the
+// smallest load the devices of each slice allow must fit in the free capacity of the slice. The
+// slices also compete for the same SF slots, which the shares of their capacities account for.
+func (solver *BBSolver) groupHolds(demands []bbDemand, free [][]float64, freeSlots [bbNumSfs]int, group uint64) bool {
+	numSlices := len(solver.maxDatarates)
+	usable := make([]sfSet, 0, len(demands))
+	sliceUsable := make([][]sfSet, numSlices)
+	for _, demand := range demands {
+		if demand.uavs&^group == 0 {
+			usable = append(usable, demand.sfs)
+			sliceUsable[demand.slice] = append(sliceUsable[demand.slice], demand.sfs)
+		}
+	}
+	if len(usable) == 0 {
+		return true
+	}
+
+	freeShare := 0.0
+	var sharedSlots [bbNumSfs]int
+	for slice, sfs := range sliceUsable {
+		freeCapacity := 0.0
+		var sliceSlots [bbNumSfs]int
+		for idx, uavFree := range free {
+			if idx < 64 && group&(1<<idx) == 0 {
+				continue
+			}
+			freeCapacity += uavFree[slice]
+			for sf, datarate := range solver.datarates[slice].load {
+				sliceSlots[sf] += int((uavFree[slice] + bbEpsilon) / datarate)
+			}
+		}
+		for sf := range sliceSlots {
+			sharedSlots[sf] += sliceSlots[sf]
+			sliceSlots[sf] = min(sliceSlots[sf], freeSlots[sf])
+		}
+		freeShare += freeCapacity / solver.maxDatarates[slice]
+
+		if len(sfs) == 0 {
+			continue
+		}
+		count := subsetCounts(sfs)
+		if _, violated := hallViolation(count, sliceSlots); violated {
+			return false
+		}
+		if minLoad(count, sliceSlots, solver.datarates[slice]) > freeCapacity+bbEpsilon {
+			return false
+		}
+	}
+
+	for sf := range sharedSlots {
+		sharedSlots[sf] = min(sharedSlots[sf], freeSlots[sf])
+	}
+	count := subsetCounts(usable)
+	if _, violated := hallViolation(count, sharedSlots); violated {
+		return false
+	}
+	return minLoad(count, sharedSlots, solver.sharedLoads) <= freeShare+bbEpsilon
+}
+
+// minLoad is the smallest total load of the counted devices each using one of its SFs, with at most
+// slots devices per SF. The sets of slots the devices can fill together form a transversal matroid, so filling as
+// many slots of the lightest SFs as possible first gives the minimum. A set of slots can be filled
+// when, for every set of SFs, the devices able to use one of them are at least as many as its
+// slots.
+func minLoad(count *sfCounts, slots [bbNumSfs]int, loads sfLoads) float64 {
+	var filled [1 << bbNumSfs]int
+	total := 0.0
+	for _, sf := range loads.order {
+		bit := sfSet(1) << sf
+		fill := slots[sf]
+		for set := sfSet(1); set < 1<<bbNumSfs; set++ {
+			if set&bit != 0 {
+				reaching := count[1<<bbNumSfs-1] - count[(1<<bbNumSfs-1)&^set]
+				fill = min(fill, reaching-filled[set])
+			}
+		}
+		for set := sfSet(1); set < 1<<bbNumSfs; set++ {
+			if set&bit != 0 {
+				filled[set] += fill
+			}
+		}
+		total += float64(fill) * loads.load[sf]
+	}
+	return total
+}
+
+// improve turns the current assignment into the new incumbent.
+func (solver *BBSolver) improve() {
+	associations := make([]problem.Association, solver.numDevices)
+	for i, option := range solver.current {
+		associations[i] = problem.Association{Device: solver.devices[i], Uav: option.uav, Config: option.config}
+	}
+
+	sol, err := solver.instance.CreateSolution(associations)
+	if err != nil {
+		panic(err)
+	}
+
+	solver.best = sol
+	solver.bestCost = sol.GetCost()
+	solver.instance.SetBestSolution(sol)
+	solver.tracker.improve(solver.bestCost)
+	solver.tracker.emit(Event{
+		CurrentCost:   solver.bestCost,
+		CandidateCost: solver.bestCost,
+	})
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
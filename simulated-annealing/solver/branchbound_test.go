@@ -0,0 +1,243 @@
+package solver
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/gateway"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+)
+
+// writeLines writes lines to a file of dir and returns its path.
+func writeLines(t *testing.T, dir, name string, lines []string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// headLines returns the first n lines of a data file.
+func headLines(t *testing.T, path string, n int) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	lines := make([]string, 0, n)
+	scanner := bufio.NewScanner(file)
+	for len(lines) < n && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+func createTestInstance(t *testing.T, devicePath, slicePath, positionPath string, alpha, beta float64, maxDatarate float32) *problem.UAVProblem {
+	deviceList, err := device.ReadDeviceList(devicePath, slicePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidatePosList, err := gateway.ReadCandidatePositionList(positionPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gw := &gateway.Gateway{}
+	gw.SetSensitivity(map[int16]float32{7: -130.0, 8: -132.5, 9: -135.0, 10: -137.5, 11: -140.0, 12: -142.5})
+	for _, slice := range deviceList.Slices() {
+		gw.AddSlice(slice, 125000.0, maxDatarate)
+	}
+
+	instance, err := problem.CreateUAVProblemInstance(alpha, beta, 0.5, 0.05, deviceList, candidatePosList, gw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance.SetRand(rand.New(rand.NewSource(1)))
+	return instance
+}
+
+// bruteForce enumerates every assignment of the devices to a UAV in reach with one of its SFs. Of
+// those within the slice capacities, it returns the smallest cost, or +Inf when there is none, and
+// the costliest assignment. Transmission powers sharing an SF change neither the cost nor the load,
+// so one of them is enough.
+func bruteForce(instance *problem.UAVProblem) (float64, []problem.Association) {
+	type choice struct {
+		uav      int32
+		sf       int16
+		config   int32
+		datarate float64
+	}
+
+	deviceIds := instance.GetDeviceIds()
+	numUavs := len(instance.GetUAVIds())
+	choices := make([][]choice, len(deviceIds))
+	numSlices := 0
+	for i, deviceId := range deviceIds {
+		slice := instance.GetSlice(deviceId)
+		numSlices = max(numSlices, int(slice)+1)
+		for _, uavId := range instance.GetPossibleUavs(deviceId) {
+			for _, configId := range instance.GetPossibleConfigs(deviceId, uavId) {
+				sf := int16(device.GetSF(configId))
+				if !slices.ContainsFunc(choices[i], func(c choice) bool { return c.uav == uavId && c.sf == sf }) {
+					choices[i] = append(choices[i], choice{uavId, sf, configId, float64(instance.GetDatarate(sf, slice))})
+				}
+			}
+		}
+	}
+
+	load := make([]float64, numUavs*numSlices)
+	devicesOn := make([]int, numUavs)
+	sfCount := make(map[int16]int)
+	numDeployed := 0
+	current := make([]problem.Association, len(deviceIds))
+	best, worst := math.Inf(1), math.Inf(-1)
+	var worstAssociations []problem.Association
+
+	var enumerate func(i int)
+	enumerate = func(i int) {
+		if i == len(deviceIds) {
+			maxCount := 0
+			for _, count := range sfCount {
+				maxCount = max(maxCount, count)
+			}
+			cost := instance.GetAlpha()*float64(numDeployed) + instance.GetBeta()*float64(maxCount)
+			best = min(best, cost)
+			if cost > worst {
+				worst = cost
+				worstAssociations = slices.Clone(current)
+			}
+			return
+		}
+
+		slice := instance.GetSlice(deviceIds[i])
+		for _, c := range choices[i] {
+			key := int(c.uav)*numSlices + int(slice)
+			if load[key]+c.datarate > float64(instance.GetMaxDatarate(slice)) {
+				continue
+			}
+
+			load[key] += c.datarate
+			sfCount[c.sf]++
+			current[i] = problem.Association{Device: deviceIds[i], Uav: c.uav, Config: c.config}
+			if devicesOn[c.uav]++; devicesOn[c.uav] == 1 {
+				numDeployed++
+			}
+
+			enumerate(i + 1)
+
+			load[key] -= c.datarate
+			sfCount[c.sf]--
+			if devicesOn[c.uav]--; devicesOn[c.uav] == 0 {
+				numDeployed--
+			}
+		}
+	}
+	enumerate(0)
+
+	return best, worstAssociations
+}
+
+func TestBBMatchesBruteForce(t *testing.T) {
+	dir := t.TempDir()
+	devices := headLines(t, "../data/endDevices_LNM_Placement_1s+50d.dat", 5)
+	slicePath := writeLines(t, dir, "slices.dat", []string{"0 0", "1 1", "2 0", "3 1", "4 0"})
+	positionPath := writeLines(t, dir, "positions.dat", []string{"8125 5625 45", "9375 5625 45", "9375 4375 45", "10625 6875 45"})
+
+	// devices sharing a position and a slice are interchangeable, which the search takes advantage of
+	deviceSets := []struct {
+		name string
+		path string
+	}{
+		{"scattered", writeLines(t, dir, "scattered.dat", devices)},
+		{"duplicates", writeLines(t, dir, "duplicates.dat", []string{devices[0], devices[1], devices[0], devices[1], devices[0]})},
+	}
+
+	tests := []struct {
+		name        string
+		alpha       float64
+		beta        float64
+		maxDatarate float32
+	}{
+		{"balanced", 2, 1, 15197.75390625},
+		{"balanced tight", 2, 1, 6000},
+		{"uavs first", 100, 1, 15197.75390625},
+		{"sfs first", 1, 10, 6000},
+		{"sfs first tight", 1, 10, 2500},
+		{"free uavs", 0, 1, 6000},
+		{"free sfs", 1, 0, 4000},
+		{"infeasible", 2, 1, 300},
+	}
+
+	for _, deviceSet := range deviceSets {
+		for _, test := range tests {
+			t.Run(deviceSet.name+"/"+test.name, func(t *testing.T) {
+				instance := createTestInstance(t, deviceSet.path, slicePath, positionPath, test.alpha, test.beta, test.maxDatarate)
+				want, worst := bruteForce(instance)
+
+				// starting from the costliest solution, rather than from the heuristics that may well
+				// find the optimum on such a small instance, the search has to find the optimum itself
+				bbSolver := CreateBBSolver(instance)
+				if worst != nil {
+					sol, err := instance.CreateSolution(worst)
+					if err != nil {
+						t.Fatal(err)
+					}
+					bbSolver.WarmStart(sol)
+				}
+				result := bbSolver.Solve(context.Background())
+				if result.StopReason != StopOptimal {
+					t.Fatalf("stopped by %s, want %s", result.StopReason, StopOptimal)
+				}
+				if math.IsInf(want, 1) {
+					if result.Best != nil {
+						t.Errorf("found a solution of cost %f, the instance has none", result.Cost)
+					}
+					return
+				}
+
+				if result.Best == nil {
+					t.Fatalf("found no solution, want cost %f", want)
+				}
+				if math.Abs(result.Cost-want) > 1e-9 {
+					t.Errorf("cost %f, want %f", result.Cost, want)
+				}
+				if report := instance.Validate(result.Best); !report.Valid() {
+					t.Errorf("invalid solution:\n%s", report)
+				}
+			})
+		}
+	}
+}
+
+// TestBBTightCapacity runs on an instance whose slice capacities are too tight for the heuristics
+// seeding the incumbent to fix, which must not take the search down.
+func TestBBTightCapacity(t *testing.T) {
+	dir := t.TempDir()
+	devicePath := writeLines(t, dir, "devices.dat", headLines(t, "../data/endDevices_LNM_Placement_10s+50d.dat", 20))
+	slicePath := writeLines(t, dir, "slices.dat", headLines(t, "../data/skl_10s_64x1Gv_50D.dat", 20))
+	instance := createTestInstance(t, devicePath, slicePath, "../data/equidistantPlacement_64.dat", 2, 1, 6000)
+
+	bbSolver := CreateBBSolver(instance)
+	bbSolver.SetStopCriterion(WallTime(2 * time.Second))
+	result := bbSolver.Solve(context.Background())
+
+	if result.Best == nil {
+		t.Fatalf("found no solution, stopped by %s", result.StopReason)
+	}
+	if report := instance.Validate(result.Best); !report.Valid() {
+		t.Errorf("invalid solution:\n%s", report)
+	}
+}
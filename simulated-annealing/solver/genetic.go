@@ -1,11 +1,11 @@
 package solver
 
 import (
-	"fmt"
+	"context"
+	"math"
+	"math/rand"
 	"os"
-	"strings"
 	"sync"
-	"time"
 
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
 )
@@ -20,12 +20,9 @@ type GA struct {
 	mutationRate      float64
 	maxGen            int
 	iteration         int
-	log               strings.Builder
-	lastLog           string
 	wg                *sync.WaitGroup
-	mu                *sync.Mutex
-	startTime         time.Time
-	bestCostTime      float64
+	rng               *rand.Rand
+	tracker           tracker
 	maxIterationsTabu int
 }
 
@@ -47,55 +44,56 @@ func CreateGASolver(instance problem.Problem, maxGen, populationSize, maxTabuIte
 		crossRate:         crossRate,
 		mutationRate:      mutationRate,
 		wg:                &sync.WaitGroup{},
-		mu:                &sync.Mutex{},
+		rng:               instance.GetRand(),
 	}
 }
 
-func (solver *GA) GetLog() string {
-	return solver.log.String()
+func (solver *GA) SetObserver(observer Observer) {
+	solver.tracker.observer = observer
 }
 
-func (solver *GA) GetLastLog() string {
-	return solver.lastLog
+func (solver *GA) SetStopCriterion(criterion StopCriterion) {
+	solver.tracker.criterion = criterion
 }
 
-func (solver *GA) GetBestCostTime() float64 {
-	return solver.bestCostTime
+// WarmStart seeds the initial population with solution in place of its worst individual.
+func (solver *GA) WarmStart(solution problem.Solution) {
+	solver.newPopulation.ReplaceWorstIndividual(solution)
 }
 
-func (solver *GA) GetCurrentIteration() int {
-	return solver.iteration
-}
-
-func (solver *GA) Solve() problem.Solution {
-	fmt.Printf("Solving with GA\n")
-	solver.startTime = time.Now()
+func (solver *GA) Solve(ctx context.Context) Result {
+	solver.tracker.begin()
 
 	solver.problemInstance.SetBestSolution(solver.newPopulation.GetBestIndividual())
+	solver.tracker.evaluate(solver.newPopulation.Size())
+	solver.tracker.improve(solver.problemInstance.GetBestSolution().GetCost())
 
-	solver.log.WriteString("gen,bestCost,avgCost,infeasible,population,time\n")
-	solver.log.WriteString(fmt.Sprintf("%d, %f, %f, %d, %d, 0.0\n", 0, solver.newPopulation.minCost, solver.newPopulation.avgCost, solver.infeasible, solver.newPopulation.Size()))
-	solver.lastLog = fmt.Sprintf("it: 0 | bestCost: %f | avgCost: %f | infe: %d | Population: %d | time: 0.0\n", solver.newPopulation.minCost, solver.newPopulation.avgCost, solver.infeasible, solver.newPopulation.Size())
-	fmt.Printf(solver.lastLog)
+	solver.emit()
 
-	for solver.iteration = 1; solver.iteration <= solver.maxGen; solver.iteration++ {
-		solver.reproduce()
-		checkpoint := time.Since(solver.startTime)
-		solver.log.WriteString(fmt.Sprintf("%d, %f, %f, %d, %d, %v\n", solver.iteration, solver.newPopulation.minCost, solver.newPopulation.avgCost, solver.infeasible, solver.newPopulation.Size(), checkpoint.Seconds()))
-		solver.lastLog = fmt.Sprintf("it: %d | bestCost: %f | avgCost: %f | infe: %d | Population: %d | time: %v\n", solver.iteration, solver.newPopulation.minCost, solver.newPopulation.avgCost, solver.infeasible, solver.newPopulation.Size(), checkpoint.Seconds())
-		fmt.Printf(solver.lastLog)
+	for solver.iteration = 1; solver.iteration <= solver.maxGen && !solver.tracker.done(ctx); solver.iteration++ {
+		solver.reproduce(ctx)
+		solver.tracker.iterate()
 
 		if solver.problemInstance.GetBestSolution().GetCost() > solver.newPopulation.GetBestIndividual().GetCost() {
 			solver.problemInstance.SetBestSolution(solver.newPopulation.GetBestIndividual())
-			solver.bestCostTime = checkpoint.Seconds()
+			solver.tracker.improve(solver.problemInstance.GetBestSolution().GetCost())
 		}
 
-		if checkpoint.Seconds() > 60 {
-			break
-		}
+		solver.emit()
 	}
+	solver.tracker.finish(StopIterations)
 
-	return solver.problemInstance.GetBestSolution()
+	return solver.tracker.result(solver.problemInstance.GetBestSolution())
+}
+
+func (solver *GA) emit() {
+	solver.tracker.emit(Event{
+		CurrentCost:   solver.newPopulation.minCost,
+		CandidateCost: math.NaN(),
+		AvgCost:       solver.newPopulation.avgCost,
+		Infeasible:    solver.infeasible,
+		Population:    solver.newPopulation.Size(),
+	})
 }
 
 func (solver *GA) Test() {
@@ -187,7 +185,7 @@ func (solver *GA) Test() {
 		panic(err)
 	}
 
-	solver.reproduce()
+	solver.reproduce(context.Background())
 
 	placementFile = cwd + "/output/test_c1_Placement_1s_64x1Gv_80D.csv"
 	file, err = os.Create(placementFile)
@@ -272,23 +270,36 @@ func (solver *GA) Test() {
 	}
 }
 
-func (solver *GA) reproduce() {
+func (solver *GA) reproduce(ctx context.Context) {
 	solver.oldPopulation = solver.newPopulation
 	solver.newPopulation = CreatePopulationEmpty()
 
 	workers := 8
 
-	out := make(chan problem.Solution, solver.maxPopulation)
+	// Every worker owns a random source seeded from the solver's one and its own output slot, so
+	// the new population does not depend on how the goroutines are scheduled.
+	children := make([][]problem.Solution, workers)
+	infeasible := make([]int, workers)
 	for i := 0; i < workers; i++ {
 		solver.wg.Add(1)
 		childs := solver.maxPopulation / workers
 		if i == workers-1 {
 			childs += solver.maxPopulation % workers
 		}
-		go solver.reproduceService(childs, out)
+		rng := rand.New(rand.NewSource(solver.rng.Int63()))
+		go solver.reproduceService(childs, rng, &children[i], &infeasible[i])
 	}
 	solver.wg.Wait()
 
+	solver.infeasible = 0
+	for i := 0; i < workers; i++ {
+		for _, child := range children[i] {
+			solver.newPopulation.AddIndividual(child)
+		}
+		solver.infeasible += infeasible[i]
+		solver.tracker.evaluate(len(children[i]))
+	}
+
 	bestIndividuals := solver.newPopulation.RemoveBestIndividuals()
 	for idx, _ := range bestIndividuals {
 		individual := bestIndividuals[idx]
@@ -301,39 +312,36 @@ func (solver *GA) reproduce() {
 
 		tabuSolver := CreateTSSolver(maxIterations, batchSize, tabuListSize, maxIterationsWithoutEnhancement, tabuUavRatio, solver.problemInstance.Copy())
 		tabuSolver.problemInstance.SetCurrentSolution(individual)
-		sol := tabuSolver.Solve()
+		result := tabuSolver.Solve(ctx)
+		solver.tracker.evaluate(result.Evaluations)
 
-		solver.newPopulation.AddIndividual(sol)
+		solver.newPopulation.AddIndividual(result.Best)
 	}
 
 	solver.newPopulation.UpdateMetrics()
 }
 
-func (solver *GA) reproduceService(numChilds int, out chan problem.Solution) {
+func (solver *GA) reproduceService(numChilds int, rng *rand.Rand, children *[]problem.Solution, infeasible *int) {
 	defer solver.wg.Done()
-	solver.infeasible = 0
 	for i := 0; i < numChilds; i += 2 {
-		p1 := solver.oldPopulation.SelectIndividual()
-		p2 := solver.oldPopulation.SelectIndividual()
+		p1 := solver.oldPopulation.SelectIndividual(rng)
+		p2 := solver.oldPopulation.SelectIndividual(rng)
 
-		c1, c2 := problem.Crossover(p1.(*problem.UAVSolution), p2.(*problem.UAVSolution), solver.crossRate, solver.mutationRate)
+		c1, c2 := problem.Crossover(p1.(*problem.UAVSolution), p2.(*problem.UAVSolution), solver.crossRate, solver.mutationRate, rng)
 
 		c1.GetCost()
 		c2.GetCost()
 
 		if !c1.IsFeasible() {
 			c1 = p1.Copy().(*problem.UAVSolution)
-			solver.infeasible++
+			*infeasible++
 		}
 
 		if !c2.IsFeasible() {
 			c2 = p2.Copy().(*problem.UAVSolution)
-			solver.infeasible++
+			*infeasible++
 		}
 
-		solver.mu.Lock()
-		solver.newPopulation.AddIndividual(c1)
-		solver.newPopulation.AddIndividual(c2)
-		solver.mu.Unlock()
+		*children = append(*children, c1, c2)
 	}
 }
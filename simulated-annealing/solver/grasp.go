@@ -2,10 +2,10 @@ package solver
 
 import (
 	"cmp"
+	"context"
 	"fmt"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
-	"math/rand"
 	"os"
 	"slices"
 	"time"
@@ -15,9 +15,10 @@ type GRASP struct {
 	problemInstance problem.Problem
 	coverage        map[int32][]device.DeviceId
 	uavs            []int32
-	startTime       time.Time
 	devicePriority  map[device.DeviceId]int
 	TabuSolver      *TSSolver
+	tracker         tracker
+	warmStart       problem.Solution
 }
 
 func CreateGRASPSolver(instance problem.Problem) *GRASP {
@@ -26,34 +27,60 @@ func CreateGRASPSolver(instance problem.Problem) *GRASP {
 	}
 }
 
-func (solver *GRASP) GetCurrentIteration() int {
-	return solver.TabuSolver.GetCurrentIteration()
+// SetStopCriterion applies to the whole run. The construction checks it after each UAV it deploys,
+// and the local search, which is where GRASP spends its iterations, runs on what is left of it.
+func (solver *GRASP) SetStopCriterion(criterion StopCriterion) {
+	solver.tracker.criterion = criterion
 }
 
-func (solver *GRASP) GetBestCostTime() float64 {
-	return solver.TabuSolver.GetBestCostTime()
+// SetObserver receives the events of the local search.
+func (solver *GRASP) SetObserver(observer Observer) {
+	solver.tracker.observer = observer
 }
 
 func (solver *GRASP) SolveFast() problem.Solution {
-	solver.startTime = time.Now()
+	solver.tracker.begin()
 
-	solution := solver.constructGreedyRandomizedSolution()
+	solution := solver.constructGreedyRandomizedSolution(context.Background())
 	solver.problemInstance.SetCurrentSolution(solution)
 
 	return solution
 }
 
-func (solver *GRASP) Solve() problem.Solution {
-	solver.startTime = time.Now()
+// WarmStart replaces the greedy randomized construction with solution.
+func (solver *GRASP) WarmStart(solution problem.Solution) {
+	solver.warmStart = solution
+}
+
+func (solver *GRASP) Solve(ctx context.Context) Result {
+	solver.tracker.begin()
 
-	solution := solver.constructGreedyRandomizedSolution()
+	solution := solver.warmStart
+	if solution == nil {
+		solution = solver.constructGreedyRandomizedSolution(ctx)
+	}
 	solver.problemInstance.SetCurrentSolution(solution)
-	solution = solver.localSearch(solution)
+	solver.tracker.evaluate(1)
+	solver.tracker.improve(solution.GetCost())
+	construction := solver.tracker.elapsed()
+
+	// The construction may have used up the budget
+	if solver.tracker.done(ctx) {
+		solver.problemInstance.SetBestSolution(solution)
+		return solver.tracker.result(solution)
+	}
 
-	return solution
+	result := solver.localSearch(ctx, construction)
+	result.Evaluations++
+	result.TimeToBest += construction
+	result.Elapsed = solver.tracker.elapsed()
+
+	return result
 }
 
-func (solver *GRASP) localSearch(solution problem.Solution) problem.Solution {
+// localSearch runs the tabu search from the current solution, under the stop criterion as if it had
+// been running since the start of the construction.
+func (solver *GRASP) localSearch(ctx context.Context, construction time.Duration) Result {
 	maxIterations := 500000
 	tabuListSize := 25
 	batchSize := 20
@@ -61,10 +88,21 @@ func (solver *GRASP) localSearch(solution problem.Solution) problem.Solution {
 	tabuUavRatio := float32(0.25)
 
 	solver.TabuSolver = CreateTSSolver(maxIterations, batchSize, tabuListSize, maxIterationsWithoutEnhancement, tabuUavRatio, solver.problemInstance)
-	return solver.TabuSolver.Solve()
+	if criterion := solver.tracker.criterion; criterion != nil {
+		solver.TabuSolver.SetStopCriterion(StopFunc(func(progress Progress) (StopReason, bool) {
+			progress.Elapsed += construction
+			progress.Evaluations++
+			return criterion.ShouldStop(progress)
+		}))
+	}
+	solver.TabuSolver.SetObserver(solver.tracker.observer)
+	return solver.TabuSolver.Solve(ctx)
 }
 
-func (solver *GRASP) constructGreedyRandomizedSolution() problem.Solution {
+// constructGreedyRandomizedSolution deploys UAVs until every device is covered. When ctx or the
+// stop criterion ends the run first, the devices still uncovered are assigned to a UAV they reach
+// without further greedy choices, so that there is a solution to return.
+func (solver *GRASP) constructGreedyRandomizedSolution(ctx context.Context) problem.Solution {
 	solver.uavs = solver.problemInstance.GetUAVIds()
 	if len(solver.uavs) <= 0 {
 		panic("No UAVs")
@@ -83,10 +121,10 @@ func (solver *GRASP) constructGreedyRandomizedSolution() problem.Solution {
 	coverUavs := make([]int32, 0)
 	mapCoverage := make(map[int32][]device.DeviceId, 1)
 
-	for len(uncoveredDevices) > 0 {
+	for len(uncoveredDevices) > 0 && !solver.tracker.done(ctx) {
 		candidates := solver.getCandidates(0.8)
 
-		idxChosen := rand.Intn(len(candidates))
+		idxChosen := solver.problemInstance.GetRand().Intn(len(candidates))
 		uavIdChosen := candidates[idxChosen]
 
 		coverUavs = append(coverUavs, uavIdChosen)
@@ -99,7 +137,10 @@ func (solver *GRASP) constructGreedyRandomizedSolution() problem.Solution {
 		solver.uavs = slices.Delete(solver.uavs, idx, idx+1)
 	}
 
-	solution, err := problem.GetUAVSolution(solver.problemInstance.(*problem.UAVProblem), coverUavs, mapCoverage, 10)
+	instance := solver.problemInstance.(*problem.UAVProblem)
+	coverUavs = solver.coverRemaining(instance, 10, uncoveredDevices, coverUavs, mapCoverage)
+
+	solution, err := problem.GetUAVSolution(instance, coverUavs, mapCoverage, 10)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 	}
@@ -116,6 +157,49 @@ func (solver *GRASP) constructGreedyRandomizedSolution() problem.Solution {
 	return solution
 }
 
+// coverRemaining assigns each uncovered device to the first UAV already deployed that it reaches
+// with capacity to spare on defaultSF, or else deploys a random one of those it reaches.
+func (solver *GRASP) coverRemaining(instance *problem.UAVProblem, defaultSF int16, uncoveredDevices []device.DeviceId, coverUavs []int32, mapCoverage map[int32][]device.DeviceId) []int32 {
+	if len(uncoveredDevices) == 0 {
+		return coverUavs
+	}
+
+	type uavSlice struct {
+		uavId int32
+		slice int32
+	}
+	usedCapacity := make(map[uavSlice]float32)
+	for uavId, covered := range mapCoverage {
+		for _, deviceId := range covered {
+			slice := instance.GetSlice(deviceId)
+			usedCapacity[uavSlice{uavId, slice}] += instance.GetDatarate(defaultSF, slice)
+		}
+	}
+
+	for _, deviceId := range uncoveredDevices {
+		slice := instance.GetSlice(deviceId)
+		datarate := instance.GetDatarate(defaultSF, slice)
+		uavs := instance.GetLinkBudget().Uavs(deviceId)
+
+		uavIdChosen := uavs[instance.GetRand().Intn(len(uavs))]
+		for _, uavId := range uavs {
+			_, deployed := mapCoverage[uavId]
+			if deployed && usedCapacity[uavSlice{uavId, slice}]+datarate <= instance.GetMaxDatarate(slice) {
+				uavIdChosen = uavId
+				break
+			}
+		}
+
+		if _, deployed := mapCoverage[uavIdChosen]; !deployed {
+			coverUavs = append(coverUavs, uavIdChosen)
+		}
+		mapCoverage[uavIdChosen] = append(mapCoverage[uavIdChosen], deviceId)
+		usedCapacity[uavSlice{uavIdChosen, slice}] += datarate
+	}
+
+	return coverUavs
+}
+
 func (solver *GRASP) processCoveredDevices(uavId int32, defaultSF int16, alpha float32, uncoveredDevices []device.DeviceId) []device.DeviceId {
 	covered := make([]device.DeviceId, 0)
 	slices.SortFunc(uncoveredDevices, func(i, j device.DeviceId) int {
@@ -183,7 +267,3 @@ func (solver *GRASP) getCandidates(alpha float64) []int32 {
 	}
 	return uavs[:size]
 }
-
-func (solver *GRASP) GetLog() string {
-	return solver.TabuSolver.GetLog()
-}
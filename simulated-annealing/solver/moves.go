@@ -0,0 +1,22 @@
+package solver
+
+import "github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+
+// scoredMove is a candidate move of the current solution with the cost it leads to.
+type scoredMove struct {
+	move problem.Move
+	cost float64
+}
+
+// scoreMove reads the cost of move from its delta when it can, and otherwise applies the move to
+// sol and undoes it, so that sol is left as it was.
+func scoreMove(sol *problem.UAVSolution, move problem.Move) scoredMove {
+	if delta, ok := sol.Delta(move); ok {
+		return scoredMove{move: move, cost: sol.GetCost() + delta}
+	}
+
+	sol.Apply(move)
+	cost := sol.GetCost()
+	sol.Undo()
+	return scoredMove{move: move, cost: cost}
+}
@@ -0,0 +1,435 @@
+package solver
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"io"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+)
+
+// FrontSolver is implemented by the multi-objective solvers, which find a set of non-dominated
+// solutions rather than a single best one.
+type FrontSolver interface {
+	Solver
+	Front() []*problem.UAVSolution
+	WriteFront(w io.Writer) error
+}
+
+// NSGA2Solver is NSGA-II over the given objectives, with the crossover and mutation of GA. Each
+// generation breeds as many children as there are parents, by binary tournaments on rank and
+// crowding distance, and keeps the best half of parents and children by the same order.
+//
+// The crossover only recombines the deployed UAVs and decodes every device on SF 10, so the SFs of
+// each child are then balanced on the UAVs it deploys, see balanceSFs.
+//
+// Its Result is the solution of the final front with the lowest weighted cost, so that it compares
+// with the single objective solvers; the whole front is given by Front and WriteFront.
+type NSGA2Solver struct {
+	problemInstance *problem.UAVProblem
+	objectives      []problem.Objective
+	population      []*individual
+	warmStart       problem.Solution
+	populationSize  int
+	generations     int
+	crossRate       float64
+	mutationRate    float64
+	infeasible      int
+	rng             *rand.Rand
+	tracker         tracker
+}
+
+type individual struct {
+	solution *problem.UAVSolution
+	values   []float64
+	rank     int
+	crowding float64
+}
+
+func CreateNSGA2Solver(instance *problem.UAVProblem, objectives []problem.Objective, populationSize, generations int, crossRate, mutationRate float64) *NSGA2Solver {
+	return &NSGA2Solver{
+		problemInstance: instance,
+		objectives:      objectives,
+		populationSize:  populationSize,
+		generations:     generations,
+		crossRate:       crossRate,
+		mutationRate:    mutationRate,
+		rng:             instance.GetRand(),
+	}
+}
+
+func (solver *NSGA2Solver) SetObserver(observer Observer) {
+	solver.tracker.observer = observer
+}
+
+func (solver *NSGA2Solver) SetStopCriterion(criterion StopCriterion) {
+	solver.tracker.criterion = criterion
+}
+
+// WarmStart seeds the initial population with solution in place of one random individual.
+func (solver *NSGA2Solver) WarmStart(solution problem.Solution) {
+	solver.warmStart = solution
+}
+
+func (solver *NSGA2Solver) Solve(ctx context.Context) Result {
+	solver.tracker.begin()
+
+	solver.population = make([]*individual, solver.populationSize)
+	for i := range solver.population {
+		sol, err := problem.GetRandomUAVSolution(solver.problemInstance)
+		if err != nil {
+			panic(err)
+		}
+		solver.population[i] = solver.createIndividual(sol)
+	}
+	if solver.warmStart != nil {
+		solver.population[0] = solver.createIndividual(solver.warmStart.(*problem.UAVSolution))
+	}
+	solver.population = solver.selectSurvivors(solver.population, solver.populationSize)
+	solver.tracker.evaluate(len(solver.population))
+	solver.updateBest()
+	solver.emit()
+
+	for generation := 1; generation <= solver.generations && !solver.tracker.done(ctx); generation++ {
+		offspring := solver.breed()
+		solver.tracker.evaluate(len(offspring))
+		solver.population = solver.selectSurvivors(append(solver.population, offspring...), solver.populationSize)
+		solver.tracker.iterate()
+		solver.updateBest()
+		solver.emit()
+	}
+	solver.tracker.finish(StopIterations)
+
+	return solver.tracker.result(solver.problemInstance.GetBestSolution())
+}
+
+func (solver *NSGA2Solver) createIndividual(sol *problem.UAVSolution) *individual {
+	values := make([]float64, len(solver.objectives))
+	for i, objective := range solver.objectives {
+		values[i] = objective.Minimised(sol)
+	}
+	return &individual{solution: sol, values: values}
+}
+
+// breed returns populationSize children. Infeasible children are replaced by a copy of their
+// parent, as in GA.
+func (solver *NSGA2Solver) breed() []*individual {
+	solver.infeasible = 0
+	offspring := make([]*individual, 0, solver.populationSize)
+	for len(offspring) < solver.populationSize {
+		p1 := solver.tournament()
+		p2 := solver.tournament()
+		c1, c2 := problem.Crossover(p1.solution, p2.solution, solver.crossRate, solver.mutationRate, solver.rng)
+
+		for _, child := range []struct{ sol, parent *problem.UAVSolution }{{c1, p1.solution}, {c2, p2.solution}} {
+			sol := child.sol
+			if !sol.IsFeasible() {
+				sol = child.parent.Copy().(*problem.UAVSolution)
+				solver.infeasible++
+			}
+			solver.balanceSFs(sol)
+			if len(offspring) < solver.populationSize {
+				offspring = append(offspring, solver.createIndividual(sol))
+			}
+		}
+	}
+	return offspring
+}
+
+// balanceSFs moves the devices of sol, one at a time, from the most used SF to the least used one
+// they can take on their UAV or another, as long as that has at least two devices less, at the
+// lowest transmission power closing the link. Moves must fit in the slice capacity and not raise the cost,
+// so they never deploy a UAV. It stops when no device of the most used SF can move; each move lowers
+// the sum of the squared SF counts, so it ends.
+func (solver *NSGA2Solver) balanceSFs(sol *problem.UAVSolution) {
+	bySf := make([][]device.DeviceId, device.MaxSF-device.MinSF+1)
+	for _, association := range sol.GetAssociations() {
+		i := device.GetSF(association.Config) - device.MinSF
+		bySf[i] = append(bySf[i], association.Device)
+	}
+
+	for moved := true; moved; {
+		moved = false
+		busiest := 0
+		for i := range bySf {
+			if len(bySf[i]) > len(bySf[busiest]) {
+				busiest = i
+			}
+		}
+
+		devices := bySf[busiest]
+		solver.rng.Shuffle(len(devices), func(a, b int) { devices[a], devices[b] = devices[b], devices[a] })
+		for k := 0; k < len(devices) && !moved; k++ {
+			deviceId := devices[k]
+			move, target, found := solver.balancingMove(sol, deviceId, bySf, len(devices)-1)
+			if !found {
+				continue
+			}
+
+			sol.Apply(move)
+			devices[k] = devices[len(devices)-1]
+			bySf[busiest] = devices[:len(devices)-1]
+			bySf[target] = append(bySf[target], deviceId)
+			moved = true
+		}
+	}
+	sol.Commit()
+}
+
+// balancingMove finds the move of deviceId to the least used SF below limit, among the UAVs it
+// reaches, preferring its own, that fits and does not raise the cost. It also returns the index of
+// the SF in bySf.
+func (solver *NSGA2Solver) balancingMove(sol *problem.UAVSolution, deviceId device.DeviceId, bySf [][]device.DeviceId, limit int) (problem.Move, int, bool) {
+	links := solver.problemInstance.GetLinkBudget()
+	prevUav := sol.GetAssignedUavId(deviceId)
+	prevConfig := sol.GetAssignedConfigId(deviceId)
+
+	best, bestTarget, found := problem.Move{}, 0, false
+	for _, uavId := range links.Uavs(deviceId) {
+		for _, sf := range links.SFs(deviceId, uavId) {
+			target := sf - device.MinSF
+			if len(bySf[target]) >= limit {
+				continue
+			}
+			if found && (len(bySf[target]) > len(bySf[bestTarget]) || len(bySf[target]) == len(bySf[bestTarget]) && uavId != prevUav) {
+				continue
+			}
+
+			tp, _ := links.MinTp(deviceId, uavId, int16(sf))
+			move := problem.Move{
+				DeviceId:   deviceId,
+				Direction:  problem.DirectionConfig,
+				PrevConfig: prevConfig,
+				PrevUAV:    prevUav,
+				NewConfig:  device.GetConfigID(sf, int(tp)),
+				NewUAV:     uavId,
+			}
+			if uavId != prevUav {
+				move.Direction = problem.DirectionUAV
+			}
+			if delta, fits := sol.Delta(move); fits && delta <= 0 {
+				best, bestTarget, found = move, target, true
+			}
+		}
+	}
+
+	return best, bestTarget, found
+}
+
+func (solver *NSGA2Solver) tournament() *individual {
+	a := solver.population[solver.rng.Intn(len(solver.population))]
+	b := solver.population[solver.rng.Intn(len(solver.population))]
+	if crowdedCompare(a, b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// crowdedCompare orders individuals by rank, then by decreasing crowding distance.
+func crowdedCompare(a, b *individual) int {
+	if a.rank != b.rank {
+		return cmp.Compare(a.rank, b.rank)
+	}
+	return cmp.Compare(b.crowding, a.crowding)
+}
+
+// selectSurvivors ranks candidates by non-dominated sorting and keeps the size best, breaking the
+// last front that fits partially by crowding distance.
+func (solver *NSGA2Solver) selectSurvivors(candidates []*individual, size int) []*individual {
+	survivors := make([]*individual, 0, size)
+	for _, front := range nonDominatedSort(candidates) {
+		assignCrowding(front)
+		if len(survivors)+len(front) > size {
+			slices.SortStableFunc(front, crowdedCompare)
+			front = front[:size-len(survivors)]
+		}
+		survivors = append(survivors, front...)
+		if len(survivors) == size {
+			break
+		}
+	}
+	return survivors
+}
+
+func dominates(a, b *individual) bool {
+	better := false
+	for i := range a.values {
+		if a.values[i] > b.values[i] {
+			return false
+		}
+		if a.values[i] < b.values[i] {
+			better = true
+		}
+	}
+	return better
+}
+
+// nonDominatedSort splits individuals into fronts, setting their rank, with the fast
+// non-dominated sort of Deb et al.
+func nonDominatedSort(individuals []*individual) [][]*individual {
+	dominated := make([][]int, len(individuals))
+	dominators := make([]int, len(individuals))
+	fronts := [][]*individual{{}}
+	current := make([]int, 0)
+
+	for i, a := range individuals {
+		for j, b := range individuals {
+			if dominates(a, b) {
+				dominated[i] = append(dominated[i], j)
+			} else if dominates(b, a) {
+				dominators[i]++
+			}
+		}
+		if dominators[i] == 0 {
+			a.rank = 0
+			current = append(current, i)
+			fronts[0] = append(fronts[0], a)
+		}
+	}
+
+	for rank := 1; len(current) > 0; rank++ {
+		next := make([]int, 0)
+		front := make([]*individual, 0)
+		for _, i := range current {
+			for _, j := range dominated[i] {
+				dominators[j]--
+				if dominators[j] == 0 {
+					individuals[j].rank = rank
+					next = append(next, j)
+					front = append(front, individuals[j])
+				}
+			}
+		}
+		if len(front) > 0 {
+			fronts = append(fronts, front)
+		}
+		current = next
+	}
+
+	return fronts
+}
+
+// assignCrowding sets the crowding distance of the members of a front: the sum over objectives of
+// the normalised gap between their neighbours, infinite at the extremes.
+func assignCrowding(front []*individual) {
+	for _, ind := range front {
+		ind.crowding = 0
+	}
+	if len(front) == 0 {
+		return
+	}
+
+	for m := range front[0].values {
+		slices.SortStableFunc(front, func(a, b *individual) int {
+			return cmp.Compare(a.values[m], b.values[m])
+		})
+
+		minValue, maxValue := front[0].values[m], front[len(front)-1].values[m]
+		front[0].crowding = math.Inf(1)
+		front[len(front)-1].crowding = math.Inf(1)
+		if maxValue == minValue {
+			continue
+		}
+		for i := 1; i < len(front)-1; i++ {
+			front[i].crowding += (front[i+1].values[m] - front[i-1].values[m]) / (maxValue - minValue)
+		}
+	}
+}
+
+func (solver *NSGA2Solver) updateBest() {
+	best := solver.population[0].solution
+	for _, ind := range solver.population {
+		if ind.solution.GetCost() < best.GetCost() {
+			best = ind.solution
+		}
+	}
+
+	if solver.tracker.improve(best.GetCost()) {
+		solver.problemInstance.SetBestSolution(best)
+	}
+}
+
+func (solver *NSGA2Solver) emit() {
+	sum, minCost := 0.0, math.Inf(1)
+	for _, ind := range solver.population {
+		sum += ind.solution.GetCost()
+		minCost = math.Min(minCost, ind.solution.GetCost())
+	}
+
+	solver.tracker.emit(Event{
+		CurrentCost:   minCost,
+		CandidateCost: math.NaN(),
+		AvgCost:       sum / float64(len(solver.population)),
+		Infeasible:    solver.infeasible,
+		Population:    len(solver.population),
+	})
+}
+
+func (solver *NSGA2Solver) Objectives() []problem.Objective {
+	return solver.objectives
+}
+
+// Front returns the first front of the population, keeping one solution per distinct point and
+// sorting them by the first objective.
+func (solver *NSGA2Solver) Front() []*problem.UAVSolution {
+	members := make([]*individual, 0)
+	for _, ind := range solver.population {
+		if ind.rank != 0 {
+			continue
+		}
+		if !slices.ContainsFunc(members, func(other *individual) bool { return slices.Equal(ind.values, other.values) }) {
+			members = append(members, ind)
+		}
+	}
+	slices.SortFunc(members, func(a, b *individual) int {
+		return slices.Compare(a.values, b.values)
+	})
+
+	front := make([]*problem.UAVSolution, len(members))
+	for i, ind := range members {
+		front[i] = ind.solution
+	}
+	return front
+}
+
+// WriteFront writes the front as CSV, with one column per objective in natural units followed by
+// the weighted cost and the deployed UAVs.
+func (solver *NSGA2Solver) WriteFront(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, 0, len(solver.objectives)+2)
+	for _, objective := range solver.objectives {
+		header = append(header, objective.Name)
+	}
+	if err := writer.Write(append(header, "cost", "deployedUavs")); err != nil {
+		return err
+	}
+
+	for _, sol := range solver.Front() {
+		row := make([]string, 0, len(header)+2)
+		for _, objective := range solver.objectives {
+			row = append(row, strconv.FormatFloat(objective.Value(sol), 'g', -1, 64))
+		}
+
+		deployed := slices.Clone(sol.GetDeployedUavs())
+		slices.Sort(deployed)
+		uavs := make([]string, 0, len(deployed))
+		for _, uavId := range deployed {
+			uavs = append(uavs, strconv.Itoa(int(uavId)))
+		}
+		row = append(row, strconv.FormatFloat(sol.GetCost(), 'g', -1, 64), strings.Join(uavs, " "))
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,235 @@
+package solver
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is emitted by a solver after every iteration. The common fields are always set, the others
+// only by the solvers they apply to: Temperature and Acceptance by SA, Tabu by TS, AvgCost and
+// Population by the population based ones, and Infeasible by GA and PSO. CandidateCost is NaN when
+// no candidate was found.
+type Event struct {
+	Iteration     int
+	Evaluations   int
+	Elapsed       time.Duration
+	CurrentCost   float64
+	CandidateCost float64
+	BestCost      float64
+	Temperature   float64
+	Acceptance    float64
+	Tabu          bool
+	AvgCost       float64
+	Infeasible    int
+	Population    int
+}
+
+type Observer interface {
+	OnEvent(event Event)
+}
+
+type ObserverFunc func(event Event)
+
+func (f ObserverFunc) OnEvent(event Event) {
+	f(event)
+}
+
+// Flush writes out anything an observer still buffers. Observers that do not buffer are ignored.
+func Flush(observer Observer) error {
+	if flusher, ok := observer.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+type multiObserver []Observer
+
+// Observers sends every event to all the given observers, skipping nil ones.
+func Observers(observers ...Observer) Observer {
+	multi := make(multiObserver, 0, len(observers))
+	for _, observer := range observers {
+		if observer != nil {
+			multi = append(multi, observer)
+		}
+	}
+	return multi
+}
+
+func (multi multiObserver) OnEvent(event Event) {
+	for _, observer := range multi {
+		observer.OnEvent(event)
+	}
+}
+
+func (multi multiObserver) Flush() error {
+	var err error
+	for _, observer := range multi {
+		if e := Flush(observer); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// ---------- CSV
+
+var eventHeader = []string{
+	"it", "evaluations", "time", "currCost", "candidateCost", "bestCost",
+	"temp", "acceptance", "tabu", "avgCost", "infeasible", "population",
+}
+
+// CSVObserver streams events as CSV rows, keeping only a small write buffer in memory.
+type CSVObserver struct {
+	writer      *csv.Writer
+	wroteHeader bool
+	err         error
+}
+
+func CreateCSVObserver(w io.Writer) *CSVObserver {
+	return &CSVObserver{writer: csv.NewWriter(w)}
+}
+
+func (observer *CSVObserver) OnEvent(event Event) {
+	if observer.err != nil {
+		return
+	}
+
+	if !observer.wroteHeader {
+		observer.wroteHeader = true
+		observer.err = observer.writer.Write(eventHeader)
+	}
+
+	if observer.err == nil {
+		observer.err = observer.writer.Write([]string{
+			strconv.Itoa(event.Iteration),
+			strconv.Itoa(event.Evaluations),
+			strconv.FormatFloat(event.Elapsed.Seconds(), 'f', 6, 64),
+			formatFloat(event.CurrentCost),
+			formatFloat(event.CandidateCost),
+			formatFloat(event.BestCost),
+			formatFloat(event.Temperature),
+			formatFloat(event.Acceptance),
+			strconv.FormatBool(event.Tabu),
+			formatFloat(event.AvgCost),
+			strconv.Itoa(event.Infeasible),
+			strconv.Itoa(event.Population),
+		})
+	}
+}
+
+func (observer *CSVObserver) Flush() error {
+	if observer.err != nil {
+		return observer.err
+	}
+	observer.writer.Flush()
+	return observer.writer.Error()
+}
+
+func formatFloat(value float64) string {
+	if math.IsNaN(value) {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// ---------- Console progress
+
+// ProgressObserver redraws a single status line at most once per interval. When the wall time
+// budget is known the line starts with a bar of the elapsed fraction of it.
+type ProgressObserver struct {
+	w         io.Writer
+	interval  time.Duration
+	timeLimit time.Duration
+	last      time.Time
+	drawn     bool
+	event     Event
+}
+
+func CreateProgressObserver(w io.Writer, interval, timeLimit time.Duration) *ProgressObserver {
+	return &ProgressObserver{
+		w:         w,
+		interval:  interval,
+		timeLimit: timeLimit,
+	}
+}
+
+func (observer *ProgressObserver) OnEvent(event Event) {
+	observer.event = event
+	if time.Since(observer.last) < observer.interval {
+		return
+	}
+	observer.last = time.Now()
+	observer.draw()
+}
+
+func (observer *ProgressObserver) draw() {
+	const width = 30
+
+	line := &strings.Builder{}
+	if observer.timeLimit > 0 {
+		fraction := min(observer.event.Elapsed.Seconds()/observer.timeLimit.Seconds(), 1)
+		filled := int(fraction * width)
+		fmt.Fprintf(line, "[%s%s] %3.0f%% ", strings.Repeat("#", filled), strings.Repeat(".", width-filled), fraction*100)
+	}
+	fmt.Fprintf(line, "it: %d | evals: %d | best: %f | time: %v",
+		observer.event.Iteration, observer.event.Evaluations, observer.event.BestCost, observer.event.Elapsed.Round(time.Millisecond))
+
+	fmt.Fprintf(observer.w, "\r%s\033[K", line.String())
+	observer.drawn = true
+}
+
+// Flush draws the last event received and ends the status line.
+func (observer *ProgressObserver) Flush() error {
+	if !observer.drawn && observer.event.Iteration == 0 {
+		return nil
+	}
+	observer.draw()
+	_, err := fmt.Fprintln(observer.w)
+	return err
+}
+
+// ---------- Ring buffer
+
+// RingObserver keeps the last events in memory, dropping the oldest ones once it is full.
+type RingObserver struct {
+	mu     sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+func CreateRingObserver(capacity int) *RingObserver {
+	return &RingObserver{events: make([]Event, capacity)}
+}
+
+func (observer *RingObserver) OnEvent(event Event) {
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+
+	if len(observer.events) == 0 {
+		return
+	}
+
+	observer.events[observer.next] = event
+	observer.next = (observer.next + 1) % len(observer.events)
+	if observer.next == 0 {
+		observer.full = true
+	}
+}
+
+// Events returns the buffered events from the oldest to the newest.
+func (observer *RingObserver) Events() []Event {
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+
+	if !observer.full {
+		return append([]Event(nil), observer.events[:observer.next]...)
+	}
+	return append(append([]Event(nil), observer.events[observer.next:]...), observer.events[:observer.next]...)
+}
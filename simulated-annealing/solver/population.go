@@ -28,7 +28,7 @@ func CreatePopulationRandom(instance problem.Problem, size int) *population {
 	for i := 0; i < size; i++ {
 		sol, err := instance.GetRandomSolution()
 		if err != nil {
-			fmt.Errorf("error creating random solution: %v", err)
+			panic(fmt.Errorf("error creating random solution: %v", err))
 		}
 
 		sumFitness += sol.GetInverseCost()
@@ -96,10 +96,10 @@ func (p *population) UpdateMetrics() {
 	p.avgFitness = p.sumFitness / float64(p.Size())
 }
 
-func (p *population) SelectIndividual() problem.Solution {
+func (p *population) SelectIndividual(rng *rand.Rand) problem.Solution {
 	sum := 0.0
 	selected := -1
-	r := rand.Float64() * p.SumFitness()
+	r := rng.Float64() * p.SumFitness()
 
 	for sum < r && selected < (p.Size()-1) {
 		selected++
@@ -149,3 +149,9 @@ func (p *population) AddIndividual(individual problem.Solution) {
 		p.minCost = cost
 	}
 }
+
+func (p *population) ReplaceWorstIndividual(individual problem.Solution) {
+	p.SortPopulation()
+	p.individuals[p.Size()-1] = individual
+	p.UpdateMetrics()
+}
@@ -0,0 +1,234 @@
+package solver
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+)
+
+// PSOSolver is a binary particle swarm over the deployed-UAV gene of the solutions. Every bit of a
+// particle has a velocity, and is set at each iteration with probability sigmoid(velocity).
+// Particles are decoded into solutions with problem.DecodeDeployedUavsGene, and take back the gene
+// of the UAVs their decoded solution actually deploys.
+type PSOSolver struct {
+	problemInstance *problem.UAVProblem
+	particles       []*particle
+	best            *problem.UAVSolution
+	warmStart       problem.Solution
+	swarmSize       int
+	maxIterations   int
+	inertia         float64
+	cognitive       float64
+	social          float64
+	maxVelocity     float64
+	infeasible      int
+	avgCost         float64
+	minCost         float64
+	rng             *rand.Rand
+	tracker         tracker
+}
+
+type particle struct {
+	position     []bool
+	velocity     []float64
+	solution     *problem.UAVSolution
+	bestPosition []bool
+	best         *problem.UAVSolution
+}
+
+func CreatePSOSolver(instance *problem.UAVProblem, swarmSize, maxIterations int, inertia, cognitive, social, maxVelocity float64) *PSOSolver {
+	return &PSOSolver{
+		problemInstance: instance,
+		swarmSize:       swarmSize,
+		maxIterations:   maxIterations,
+		inertia:         inertia,
+		cognitive:       cognitive,
+		social:          social,
+		maxVelocity:     maxVelocity,
+		rng:             instance.GetRand(),
+	}
+}
+
+func (solver *PSOSolver) SetObserver(observer Observer) {
+	solver.tracker.observer = observer
+}
+
+func (solver *PSOSolver) SetStopCriterion(criterion StopCriterion) {
+	solver.tracker.criterion = criterion
+}
+
+// WarmStart seeds the initial swarm with solution in place of its worst particle.
+func (solver *PSOSolver) WarmStart(solution problem.Solution) {
+	solver.warmStart = solution
+}
+
+func (solver *PSOSolver) Solve(ctx context.Context) Result {
+	solver.tracker.begin()
+
+	solver.initSwarm()
+	solver.tracker.evaluate(len(solver.particles))
+	solver.problemInstance.SetBestSolution(solver.best)
+	solver.tracker.improve(solver.best.GetCost())
+	solver.emit()
+
+	for iteration := 1; iteration <= solver.maxIterations && !solver.tracker.done(ctx); iteration++ {
+		solver.move()
+		solver.tracker.iterate()
+		solver.tracker.evaluate(len(solver.particles))
+
+		for _, p := range solver.particles {
+			if p.best.GetCost() < solver.best.GetCost() {
+				solver.best = p.best
+				solver.problemInstance.SetBestSolution(solver.best)
+				solver.tracker.improve(solver.best.GetCost())
+			}
+		}
+
+		solver.emit()
+	}
+	solver.tracker.finish(StopIterations)
+
+	return solver.tracker.result(solver.best)
+}
+
+// initSwarm starts every particle from a random solution, with velocities of half the maximum
+// pointing towards its own gene, so the first iterations explore around the initial solutions.
+func (solver *PSOSolver) initSwarm() {
+	solver.particles = make([]*particle, solver.swarmSize)
+	for i := range solver.particles {
+		sol, err := problem.GetRandomUAVSolution(solver.problemInstance)
+		if err != nil {
+			panic(err)
+		}
+		solver.particles[i] = solver.createParticle(sol)
+	}
+
+	if solver.warmStart != nil {
+		worst := 0
+		for i, p := range solver.particles {
+			if p.solution.GetCost() > solver.particles[worst].solution.GetCost() {
+				worst = i
+			}
+		}
+		solver.particles[worst] = solver.createParticle(solver.warmStart.(*problem.UAVSolution))
+	}
+
+	solver.best = solver.particles[0].best
+	for _, p := range solver.particles {
+		if p.best.GetCost() < solver.best.GetCost() {
+			solver.best = p.best
+		}
+	}
+	solver.updateMetrics()
+}
+
+func (solver *PSOSolver) createParticle(sol *problem.UAVSolution) *particle {
+	position := sol.GetDeployedUavsGene()
+	velocity := make([]float64, len(position))
+	for i, deployed := range position {
+		velocity[i] = -solver.maxVelocity / 2
+		if deployed {
+			velocity[i] = solver.maxVelocity / 2
+		}
+	}
+
+	return &particle{
+		position:     position,
+		velocity:     velocity,
+		solution:     sol,
+		bestPosition: position,
+		best:         sol,
+	}
+}
+
+// move updates every particle, spreading the decoding over workers. Each worker owns a random
+// source seeded from the solver's one and a fixed share of the particles, so the swarm does not
+// depend on how the goroutines are scheduled.
+func (solver *PSOSolver) move() {
+	workers := 8
+	globalBest := solver.best.GetDeployedUavsGene()
+
+	wg := &sync.WaitGroup{}
+	infeasible := make([]int, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		rng := rand.New(rand.NewSource(solver.rng.Int63()))
+		go func(w int, rng *rand.Rand) {
+			defer wg.Done()
+			for i := w; i < len(solver.particles); i += workers {
+				if !solver.moveParticle(solver.particles[i], globalBest, rng) {
+					infeasible[w]++
+				}
+			}
+		}(w, rng)
+	}
+	wg.Wait()
+
+	solver.infeasible = 0
+	for _, count := range infeasible {
+		solver.infeasible += count
+	}
+	solver.updateMetrics()
+}
+
+// moveParticle returns false when the new position decodes into an infeasible solution, in which
+// case the particle keeps its previous solution and gene.
+func (solver *PSOSolver) moveParticle(p *particle, globalBest []bool, rng *rand.Rand) bool {
+	position := make([]bool, len(p.position))
+	for i := range p.velocity {
+		r1, r2 := rng.Float64(), rng.Float64()
+		v := solver.inertia*p.velocity[i] +
+			solver.cognitive*r1*(bit(p.bestPosition[i])-bit(p.position[i])) +
+			solver.social*r2*(bit(globalBest[i])-bit(p.position[i]))
+		p.velocity[i] = math.Max(-solver.maxVelocity, math.Min(solver.maxVelocity, v))
+		position[i] = rng.Float64() < sigmoid(p.velocity[i])
+	}
+
+	sol, err := problem.DecodeDeployedUavsGene(solver.problemInstance, position, rng)
+	if err != nil || !sol.IsFeasible() {
+		return false
+	}
+
+	p.solution = sol
+	p.position = sol.GetDeployedUavsGene()
+	if sol.GetCost() < p.best.GetCost() {
+		p.best = sol
+		p.bestPosition = p.position
+	}
+	return true
+}
+
+func (solver *PSOSolver) updateMetrics() {
+	sum := 0.0
+	solver.minCost = math.Inf(1)
+	for _, p := range solver.particles {
+		cost := p.solution.GetCost()
+		sum += cost
+		solver.minCost = math.Min(solver.minCost, cost)
+	}
+	solver.avgCost = sum / float64(len(solver.particles))
+}
+
+func (solver *PSOSolver) emit() {
+	solver.tracker.emit(Event{
+		CurrentCost:   solver.minCost,
+		CandidateCost: math.NaN(),
+		AvgCost:       solver.avgCost,
+		Infeasible:    solver.infeasible,
+		Population:    len(solver.particles),
+	})
+}
+
+func bit(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
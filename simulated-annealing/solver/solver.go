@@ -1,5 +1,60 @@
 package solver
 
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+)
+
 type Solver interface {
-	GetLog() string
+	Solve(ctx context.Context) Result
+	SetStopCriterion(criterion StopCriterion)
+	SetObserver(observer Observer)
+	WarmStart(solution problem.Solution)
+}
+
+// Result describes the outcome of a run in the same terms for every solver. Iterations count the
+// solver's own unit of progress: SA and TS moves, GA generations.
+type Result struct {
+	Best        problem.Solution
+	Cost        float64
+	CostA       float64
+	CostB       float64
+	NumUavs     int
+	Iterations  int
+	Evaluations int
+	TimeToBest  time.Duration
+	Elapsed     time.Duration
+	StopReason  StopReason
+}
+
+// result reports best, which is nil with an infinite cost when the run found no feasible solution.
+func (t *tracker) result(best problem.Solution) Result {
+	result := Result{
+		Best:        best,
+		Cost:        math.Inf(1),
+		Iterations:  t.iteration,
+		Evaluations: t.evaluations,
+		TimeToBest:  t.bestTime,
+		Elapsed:     t.elapsed(),
+		StopReason:  t.reason,
+	}
+	if best != nil {
+		result.Cost = best.GetCost()
+		result.CostA = best.GetCostA()
+		result.CostB = best.GetCostB()
+		result.NumUavs = len(best.GetDeployedUavs())
+	}
+	return result
+}
+
+// Gap is the relative distance between cost and a lower bound on the optimum, such as one computed
+// offline from the exported MILP. It is 0 once cost reaches the bound.
+func Gap(cost, bound float64) float64 {
+	if cost == 0 {
+		return 0
+	}
+	return (cost - bound) / math.Abs(cost)
 }
@@ -0,0 +1,196 @@
+package solver
+
+import (
+	"context"
+	"time"
+)
+
+type StopReason string
+
+const (
+	StopNone        StopReason = ""
+	StopCancelled   StopReason = "cancelled"
+	StopWallTime    StopReason = "wall-time"
+	StopIterations  StopReason = "iterations"
+	StopEvaluations StopReason = "evaluations"
+	StopTargetCost  StopReason = "target-cost"
+	StopStagnation  StopReason = "stagnation"
+	// StopOptimal is reported by exact solvers that exhausted their search space.
+	StopOptimal StopReason = "optimal"
+)
+
+// Progress is the state of a running solver as seen by the stop criteria.
+type Progress struct {
+	Iteration                  int
+	Evaluations                int
+	Elapsed                    time.Duration
+	BestCost                   float64
+	IterationsSinceImprovement int
+}
+
+type StopCriterion interface {
+	ShouldStop(progress Progress) (StopReason, bool)
+}
+
+type StopFunc func(progress Progress) (StopReason, bool)
+
+func (f StopFunc) ShouldStop(progress Progress) (StopReason, bool) {
+	return f(progress)
+}
+
+func WallTime(limit time.Duration) StopCriterion {
+	return StopFunc(func(progress Progress) (StopReason, bool) {
+		return StopWallTime, progress.Elapsed >= limit
+	})
+}
+
+func MaxIterations(limit int) StopCriterion {
+	return StopFunc(func(progress Progress) (StopReason, bool) {
+		return StopIterations, progress.Iteration >= limit
+	})
+}
+
+func MaxEvaluations(limit int) StopCriterion {
+	return StopFunc(func(progress Progress) (StopReason, bool) {
+		return StopEvaluations, progress.Evaluations >= limit
+	})
+}
+
+func TargetCost(target float64) StopCriterion {
+	return StopFunc(func(progress Progress) (StopReason, bool) {
+		return StopTargetCost, progress.BestCost <= target
+	})
+}
+
+func Stagnation(iterations int) StopCriterion {
+	return StopFunc(func(progress Progress) (StopReason, bool) {
+		return StopStagnation, progress.IterationsSinceImprovement >= iterations
+	})
+}
+
+// Any stops as soon as one of the criteria does, reporting the reason of the first one that fired.
+func Any(criteria ...StopCriterion) StopCriterion {
+	return StopFunc(func(progress Progress) (StopReason, bool) {
+		for _, criterion := range criteria {
+			if reason, stop := criterion.ShouldStop(progress); stop {
+				return reason, true
+			}
+		}
+		return StopNone, false
+	})
+}
+
+// All stops only once every criterion does, reporting the reason of the last one.
+func All(criteria ...StopCriterion) StopCriterion {
+	return StopFunc(func(progress Progress) (StopReason, bool) {
+		reason := StopNone
+		for _, criterion := range criteria {
+			r, stop := criterion.ShouldStop(progress)
+			if !stop {
+				return StopNone, false
+			}
+			reason = r
+		}
+		return reason, len(criteria) > 0
+	})
+}
+
+// tracker keeps the progress of a solver run and checks it against the context and the stop
+// criterion, remembering why the run ended.
+type tracker struct {
+	criterion       StopCriterion
+	observer        Observer
+	start           time.Time
+	iteration       int
+	evaluations     int
+	bestCost        float64
+	hasBest         bool
+	bestTime        time.Duration
+	lastImprovement int
+	reason          StopReason
+}
+
+func (t *tracker) begin() {
+	t.start = time.Now()
+	t.iteration = 0
+	t.evaluations = 0
+	t.hasBest = false
+	t.bestTime = 0
+	t.lastImprovement = 0
+	t.reason = StopNone
+}
+
+func (t *tracker) iterate() {
+	t.iteration++
+}
+
+func (t *tracker) evaluate(count int) {
+	t.evaluations += count
+}
+
+func (t *tracker) elapsed() time.Duration {
+	return time.Since(t.start)
+}
+
+// improve records cost if it is better than the best cost seen so far.
+func (t *tracker) improve(cost float64) bool {
+	if t.hasBest && cost >= t.bestCost {
+		return false
+	}
+
+	t.bestCost = cost
+	t.hasBest = true
+	t.bestTime = t.elapsed()
+	t.lastImprovement = t.iteration
+	return true
+}
+
+func (t *tracker) progress() Progress {
+	return Progress{
+		Iteration:                  t.iteration,
+		Evaluations:                t.evaluations,
+		Elapsed:                    t.elapsed(),
+		BestCost:                   t.bestCost,
+		IterationsSinceImprovement: t.iteration - t.lastImprovement,
+	}
+}
+
+func (t *tracker) done(ctx context.Context) bool {
+	if t.reason != StopNone {
+		return true
+	}
+
+	if ctx.Err() != nil {
+		t.reason = StopCancelled
+		return true
+	}
+
+	if t.criterion != nil {
+		if reason, stop := t.criterion.ShouldStop(t.progress()); stop {
+			t.reason = reason
+			return true
+		}
+	}
+
+	return false
+}
+
+// emit fills the common fields of event and hands it to the observer, if any.
+func (t *tracker) emit(event Event) {
+	if t.observer == nil {
+		return
+	}
+
+	event.Iteration = t.iteration
+	event.Evaluations = t.evaluations
+	event.Elapsed = t.elapsed()
+	event.BestCost = t.bestCost
+	t.observer.OnEvent(event)
+}
+
+// finish marks the run as ended by the solver's own limit unless a criterion stopped it first.
+func (t *tracker) finish(reason StopReason) {
+	if t.reason == StopNone {
+		t.reason = reason
+	}
+}
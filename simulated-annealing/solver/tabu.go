@@ -1,14 +1,13 @@
 package solver
 
 import (
-	"fmt"
+	"cmp"
+	"context"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/utils"
-	"math/rand"
+	"math"
 	"slices"
-	"strings"
-	"time"
 )
 
 const Beta float64 = 0.3
@@ -25,7 +24,6 @@ type tabuMove struct {
 
 type TSSolver struct {
 	problemInstance problem.Problem
-	currIteration   int
 	maxIterations   int
 	maxIterationsWE int
 	batchSize       int
@@ -34,15 +32,12 @@ type TSSolver struct {
 	tabuUav         []int32
 	tabuUavRatio    float32
 	eliteSolution   problem.Solution
-	log             strings.Builder
-	startTime       time.Time
-	checkpoint      time.Duration
-	bestCostTime    float64
+	tracker         tracker
 }
 
 func CreateTSSolver(maxIteration, batchSize, tabuListSize, maxIterationsWithoutEnhancement int, tabuUavPercentage float32, instance problem.Problem) *TSSolver {
 
-	s := &TSSolver{
+	return &TSSolver{
 		maxIterations:   maxIteration,
 		batchSize:       batchSize,
 		tabuListSize:    tabuListSize,
@@ -51,101 +46,93 @@ func CreateTSSolver(maxIteration, batchSize, tabuListSize, maxIterationsWithoutE
 		tabuUav:         make([]int32, 0),
 		tabuUavRatio:    tabuUavPercentage,
 	}
-
-	s.log.Grow(64 * maxIteration)
-	s.log.WriteString("it,currCost,nextCost,tabu,bestCost,timestamp\n")
-
-	return s
 }
 
-func (solver *TSSolver) GetLog() string {
-	return solver.log.String()
+func (solver *TSSolver) SetObserver(observer Observer) {
+	solver.tracker.observer = observer
 }
 
-func (solver *TSSolver) GetCurrentIteration() int {
-	return solver.currIteration
+func (solver *TSSolver) SetStopCriterion(criterion StopCriterion) {
+	solver.tracker.criterion = criterion
 }
 
-func (solver *TSSolver) GetBestCostTime() float64 {
-	return solver.bestCostTime
+func (solver *TSSolver) WarmStart(solution problem.Solution) {
+	solver.problemInstance.SetCurrentSolution(solution)
 }
 
-func (solver *TSSolver) Solve() problem.Solution {
-	solver.startTime = time.Now()
+func (solver *TSSolver) Solve(ctx context.Context) Result {
+	solver.tracker.begin()
 	solver.tabuList = make([]tabuMove, 0)
-	currSolution := solver.problemInstance.GetCurrentSolution()
-	solver.problemInstance.SetBestSolution(currSolution)
-
-	for solver.currIteration = 0; solver.currIteration < solver.maxIterations; solver.currIteration++ {
+	// the current solution is moved in place, so neither the caller's nor the best one may share it
+	currSolution := solver.problemInstance.GetCurrentSolution().Copy()
+	solver.problemInstance.SetCurrentSolution(currSolution)
+	solver.problemInstance.SetBestSolution(currSolution.Copy())
+	solver.tracker.evaluate(1)
+	solver.tracker.improve(currSolution.GetCost())
+
+	for solver.tracker.iteration < solver.maxIterations && !solver.tracker.done(ctx) {
 		//fmt.Printf("\n\n==========================\n  starting intensification  \n==========================\n\n")
-		solver.intensification()
+		solver.intensification(ctx)
 		//fmt.Printf("\n\n==========================\n  end of intensification  \n==========================\n\n")
 
-		if solver.currIteration < solver.maxIterations-1 {
+		if solver.tracker.iteration < solver.maxIterations && !solver.tracker.done(ctx) {
 			//fmt.Printf("\n\n==========================\n  starting diversification  \n==========================\n\n")
 			solver.diversificationLongTermMemory()
+			solver.tracker.evaluate(1)
 			//fmt.Printf("\n\n==========================\n  end of diversification  \n==========================\n\n")
 		}
-
-		if solver.checkpoint.Seconds() > 60 {
-			break
-		}
 	}
+	solver.tracker.finish(StopIterations)
 
-	return solver.problemInstance.GetBestSolution()
+	return solver.tracker.result(solver.problemInstance.GetBestSolution())
 }
 
-func (solver *TSSolver) intensification() {
+func (solver *TSSolver) intensification(ctx context.Context) {
 	iterationsWithoutEnhancement := 0
-	solver.eliteSolution = solver.problemInstance.GetCurrentSolution()
+	solver.eliteSolution = solver.problemInstance.GetCurrentSolution().Copy()
 
-	for ; solver.currIteration < solver.maxIterations; solver.currIteration++ {
+	for solver.tracker.iteration < solver.maxIterations && !solver.tracker.done(ctx) {
 		iterationsWithoutEnhancement++
-		candidates := solver.problemInstance.GetCurrentSolution().GetNeighbourList(solver.batchSize)
-		nextSolution, candidateSolutionFound, isTabuMove := solver.evaluateCandidates(candidates)
+		currSolution := solver.problemInstance.GetCurrentSolution().(*problem.UAVSolution)
+		candidates := solver.drawCandidates(currSolution)
+		next, candidateSolutionFound, isTabuMove := solver.evaluateCandidates(candidates)
+		solver.tracker.iterate()
+		solver.tracker.evaluate(len(candidates))
 
-		currCost := solver.problemInstance.GetCurrentSolution().GetCost()
+		currCost := currSolution.GetCost()
 		bestCost := solver.problemInstance.GetBestSolution().GetCost()
-
-		solver.checkpoint = time.Since(solver.startTime)
-		//tabuListSize := len(solver.tabuList)
+		nextCost := math.NaN()
 
 		if candidateSolutionFound {
-			nextCost := nextSolution.GetCost()
-
-			//nextUavTabuRatio := nextSolution.GetUavTabuRatio(solver.tabuUav)
-			//fmt.Printf("it: %d | currSolution: %f | nextSolution: %f | tabu: %t | tabuUavRatio: %f | bestSolution: %f | tabuListSize: %d | time: %v\n", solver.currIteration, currCost, nextCost, isTabuMove, nextUavTabuRatio, bestCost, tabuListSize, solver.checkpoint.Seconds())
+			currSolution.Apply(next.move)
+			currSolution.Commit()
+			nextCost = currSolution.GetCost()
 
-			solver.log.WriteString(fmt.Sprintf("%d,%f,%f,%t,%f,%v\n", solver.currIteration, currCost, nextCost, isTabuMove, bestCost, solver.checkpoint.Seconds()))
-
-			move := nextSolution.GetGeneratingMove()
+			move := currSolution.GetGeneratingMove()
 
 			if !isTabuMove {
 				solver.addTabuMove(tabuMove{move.DeviceId, move.Direction})
 			}
 
-			solver.problemInstance.SetCurrentSolution(nextSolution)
-
 			if nextCost < bestCost {
-				solver.problemInstance.SetBestSolution(nextSolution)
-				solver.bestCostTime = solver.checkpoint.Seconds()
+				solver.problemInstance.SetBestSolution(currSolution.Copy())
+				solver.tracker.improve(nextCost)
 			}
 
 			if nextCost < solver.eliteSolution.GetCost() {
 				iterationsWithoutEnhancement = 0
-				solver.eliteSolution = nextSolution
+				solver.eliteSolution = currSolution.Copy()
 			}
 
-		} else {
-			//fmt.Printf("it: %d | currSolution: %f | NO MOVE! | bestSolution: %f | tabuListSize: %d | time: %v\n", solver.currIteration, currCost, bestCost, tabuListSize, solver.checkpoint.Seconds())
-			solver.log.WriteString(fmt.Sprintf("%d,%f,%f,%t,%f,%v\n", solver.currIteration, currCost, -1.0, isTabuMove, bestCost, solver.checkpoint.Seconds()))
 		}
 
-		if iterationsWithoutEnhancement > solver.maxIterationsWE {
-			break
-		}
+		solver.tracker.emit(Event{
+			CurrentCost:   currCost,
+			CandidateCost: nextCost,
+			Tabu:          isTabuMove,
+		})
 
-		if solver.checkpoint.Seconds() > 60 {
+		if iterationsWithoutEnhancement > solver.maxIterationsWE {
 			break
 		}
 	}
@@ -169,37 +156,53 @@ func (solver *TSSolver) diversificationLongTermMemory() {
 	solver.problemInstance.SetCurrentSolution(newSolution)
 }
 
-func (solver *TSSolver) evaluateCandidates(candidates []problem.Solution) (problem.Solution, bool, bool) {
-	slices.SortFunc(candidates, func(i, j problem.Solution) int { return int(i.GetCost() - j.GetCost()) })
+// drawCandidates draws a batch of moves of sol and scores them without copying it.
+func (solver *TSSolver) drawCandidates(sol *problem.UAVSolution) []scoredMove {
+	candidates := make([]scoredMove, 0, solver.batchSize)
+	for it := sol.Candidates(solver.batchSize); it.Next(); {
+		candidates = append(candidates, scoreMove(sol, it.Move()))
+	}
+	return candidates
+}
+
+func (solver *TSSolver) evaluateCandidates(candidates []scoredMove) (*scoredMove, bool, bool) {
+	if len(candidates) == 0 {
+		return nil, false, false
+	}
+	slices.SortFunc(candidates, func(i, j scoredMove) int { return cmp.Compare(i.cost, j.cost) })
 
 	currCost := solver.problemInstance.GetCurrentSolution().GetCost()
 	bestCost := solver.problemInstance.GetBestSolution().GetCost()
 
-	tabuCandidates := make([]problem.Solution, 0, solver.batchSize)
-	nonTabuCandidates := make([]problem.Solution, 0, solver.batchSize)
+	tabuCandidates := make([]*scoredMove, 0, solver.batchSize)
+	nonTabuCandidates := make([]*scoredMove, 0, solver.batchSize)
 
 	candidateSolutionFound := false
 	isTabuMove := false
-	var nextSolution problem.Solution
-	bestCandidateCost := candidates[0].GetCost()
+	var nextSolution *scoredMove
+	bestCandidateCost := candidates[0].cost
 
-	for idx, candidate := range candidates {
-		if candidate.GetCost() > bestCandidateCost || idx == len(candidates)-1 {
+	for idx := range candidates {
+		candidate := &candidates[idx]
+		if candidate.cost > bestCandidateCost || idx == len(candidates)-1 {
 			if len(nonTabuCandidates) > 0 {
-				idx := rand.Intn(len(nonTabuCandidates))
+				idx := solver.problemInstance.GetRand().Intn(len(nonTabuCandidates))
 				nextSolution = nonTabuCandidates[idx]
 				isTabuMove = false
 			} else {
-				idx := rand.Intn(len(tabuCandidates))
+				if len(tabuCandidates) == 0 {
+					break
+				}
+				idx := solver.problemInstance.GetRand().Intn(len(tabuCandidates))
 				nextSolution = tabuCandidates[idx]
 				isTabuMove = true
-				if candidate.GetCost() > bestCost {
-					bestCandidateCost = candidate.GetCost()
+				if candidate.cost > bestCost {
+					bestCandidateCost = candidate.cost
 					continue
 				}
 			}
 
-			if nextSolution.GetCost() <= currCost {
+			if nextSolution.cost <= currCost {
 				candidateSolutionFound = true
 			} else {
 				candidateSolutionFound = false
@@ -208,8 +211,7 @@ func (solver *TSSolver) evaluateCandidates(candidates []problem.Solution) (probl
 			break
 		}
 
-		move := candidate.GetGeneratingMove()
-		if solver.isTabuMove(move) {
+		if solver.isTabuMove(candidate.move) {
 			tabuCandidates = append(tabuCandidates, candidate)
 		} else {
 			nonTabuCandidates = append(nonTabuCandidates, candidate)
@@ -0,0 +1,144 @@
+package solver
+
+import (
+	"context"
+
+	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
+)
+
+// VNSSolver is a basic variable neighbourhood search. Each iteration shakes the current solution
+// by k random moves, then descends from it through problem.Neighbourhoods in order, going back to
+// the first neighbourhood whenever one improves. The result replaces the current solution if it is
+// better, resetting k to 1; otherwise k grows up to maxShake and wraps around.
+//
+// The descent samples up to samples neighbours of each neighbourhood rather than enumerating it.
+// Samples are applied to the shaken solution in place and undone, so it is only copied to keep an
+// improving close or swap neighbour.
+type VNSSolver struct {
+	problemInstance *problem.UAVProblem
+	maxIterations   int
+	maxShake        int
+	samples         int
+	tracker         tracker
+}
+
+func CreateVNSSolver(instance *problem.UAVProblem, maxIterations, maxShake, samples int) *VNSSolver {
+	return &VNSSolver{
+		problemInstance: instance,
+		maxIterations:   maxIterations,
+		maxShake:        maxShake,
+		samples:         samples,
+	}
+}
+
+func (solver *VNSSolver) SetObserver(observer Observer) {
+	solver.tracker.observer = observer
+}
+
+func (solver *VNSSolver) SetStopCriterion(criterion StopCriterion) {
+	solver.tracker.criterion = criterion
+}
+
+func (solver *VNSSolver) WarmStart(solution problem.Solution) {
+	solver.problemInstance.SetCurrentSolution(solution)
+}
+
+func (solver *VNSSolver) Solve(ctx context.Context) Result {
+	solver.tracker.begin()
+
+	current := solver.problemInstance.GetCurrentSolution().Copy().(*problem.UAVSolution)
+	solver.tracker.evaluate(1)
+	current = solver.descend(ctx, current)
+	solver.problemInstance.SetCurrentSolution(current)
+	solver.problemInstance.SetBestSolution(current)
+	solver.tracker.improve(current.GetCost())
+
+	k := 1
+	for solver.tracker.iteration < solver.maxIterations && !solver.tracker.done(ctx) {
+		shaken := current.GetNeighbourRandom(k, k+1).(*problem.UAVSolution)
+		solver.tracker.evaluate(1)
+		candidate := solver.descend(ctx, shaken)
+		solver.tracker.iterate()
+
+		currCost := current.GetCost()
+		if candidate.GetCost() < currCost {
+			current = candidate
+			solver.problemInstance.SetCurrentSolution(current)
+			k = 1
+		} else {
+			k = k%solver.maxShake + 1
+		}
+
+		if solver.tracker.improve(candidate.GetCost()) {
+			solver.problemInstance.SetBestSolution(candidate)
+		}
+
+		solver.tracker.emit(Event{
+			CurrentCost:   currCost,
+			CandidateCost: candidate.GetCost(),
+		})
+	}
+	solver.tracker.finish(StopIterations)
+
+	return solver.tracker.result(solver.problemInstance.GetBestSolution())
+}
+
+// descend is the variable neighbourhood descent from sol, which it moves in place, going to the
+// best sampled neighbour of the current neighbourhood while it improves.
+func (solver *VNSSolver) descend(ctx context.Context, sol *problem.UAVSolution) *problem.UAVSolution {
+	for l := 0; l < len(problem.Neighbourhoods) && !solver.tracker.done(ctx); {
+		if next := solver.improveIn(sol, problem.Neighbourhoods[l]); next != nil {
+			sol = next
+			l = 0
+		} else {
+			l++
+		}
+	}
+
+	return sol
+}
+
+// improveIn samples neighbours of sol in neighbourhood and returns the best of them if it improves
+// on sol, or nil. Moves of one device are scored from their delta and the best is applied to sol;
+// close and swap moves are applied and undone, and the best is kept as a copy.
+func (solver *VNSSolver) improveIn(sol *problem.UAVSolution, neighbourhood problem.Neighbourhood) *problem.UAVSolution {
+	cost := sol.GetCost()
+
+	if neighbourhood == problem.NeighbourhoodConfig || neighbourhood == problem.NeighbourhoodUAV {
+		var best *scoredMove
+		for it := sol.CandidatesIn(neighbourhood, solver.samples); it.Next(); {
+			candidate := scoreMove(sol, it.Move())
+			solver.tracker.evaluate(1)
+			if best == nil || candidate.cost < best.cost {
+				best = &candidate
+			}
+		}
+		if best == nil || best.cost >= cost {
+			return nil
+		}
+
+		// a move that overloads its UAV may be repaired differently than when it was scored
+		sol.Apply(best.move)
+		if sol.GetCost() >= cost {
+			sol.Undo()
+			return nil
+		}
+		sol.Commit()
+		return sol
+	}
+
+	var best *problem.UAVSolution
+	for s := 0; s < solver.samples; s++ {
+		if _, found := sol.ApplyIn(neighbourhood); !found {
+			break
+		}
+		solver.tracker.evaluate(1)
+		if sol.GetCost() < cost {
+			cost = sol.GetCost()
+			best = sol.Copy().(*problem.UAVSolution)
+		}
+		sol.Undo()
+	}
+
+	return best
+}
@@ -0,0 +1,118 @@
+// Package stats summarises and compares samples of solver results: descriptive statistics with
+// confidence intervals, the non-parametric tests used to compare metaheuristics, and tables to
+// report them.
+package stats
+
+import (
+	"math"
+	"slices"
+)
+
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+func Median(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle]
+	}
+	return (sorted[middle-1] + sorted[middle]) / 2
+}
+
+// Std is the sample standard deviation, NaN for fewer than two values.
+func Std(values []float64) float64 {
+	if len(values) < 2 {
+		return math.NaN()
+	}
+	mean := Mean(values)
+	sum := 0.0
+	for _, value := range values {
+		sum += (value - mean) * (value - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+type Summary struct {
+	N      int
+	Mean   float64
+	Median float64
+	Std    float64
+	Min    float64
+	Max    float64
+	CILow  float64
+	CIHigh float64
+}
+
+// Describe summarises values, with the Student t confidence interval of the mean at the given
+// level. The interval is NaN for fewer than two values.
+func Describe(values []float64, confidence float64) Summary {
+	summary := Summary{
+		N:      len(values),
+		Mean:   Mean(values),
+		Median: Median(values),
+		Std:    Std(values),
+		Min:    math.NaN(),
+		Max:    math.NaN(),
+		CILow:  math.NaN(),
+		CIHigh: math.NaN(),
+	}
+	if len(values) > 0 {
+		summary.Min = slices.Min(values)
+		summary.Max = slices.Max(values)
+	}
+	if len(values) > 1 {
+		half := StudentTQuantile(1-(1-confidence)/2, float64(len(values)-1)) * summary.Std / math.Sqrt(float64(len(values)))
+		summary.CILow = summary.Mean - half
+		summary.CIHigh = summary.Mean + half
+	}
+	return summary
+}
+
+// Ranks gives the rank of each value from 1 for the smallest, averaging the ranks of ties. It also
+// returns the sum of t^3 - t over the groups of t tied values, which the tests use to correct
+// their variance.
+func Ranks(values []float64) ([]float64, float64) {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		switch {
+		case values[a] < values[b]:
+			return -1
+		case values[a] > values[b]:
+			return 1
+		}
+		return 0
+	})
+
+	ranks := make([]float64, len(values))
+	ties := 0.0
+	for start := 0; start < len(order); {
+		end := start + 1
+		for end < len(order) && values[order[end]] == values[order[start]] {
+			end++
+		}
+		rank := float64(start+end+1) / 2
+		for _, i := range order[start:end] {
+			ranks[i] = rank
+		}
+		t := float64(end - start)
+		ties += t*t*t - t
+		start = end
+	}
+	return ranks, ties
+}
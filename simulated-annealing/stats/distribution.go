@@ -0,0 +1,142 @@
+package stats
+
+import "math"
+
+// NormalSurvival is P(Z > z) for a standard normal Z.
+func NormalSurvival(z float64) float64 {
+	return math.Erfc(z/math.Sqrt2) / 2
+}
+
+// StudentTCDF is P(T <= t) for Student's t with df degrees of freedom.
+func StudentTCDF(t, df float64) float64 {
+	tail := regularizedBeta(df/(df+t*t), df/2, 0.5) / 2
+	if t < 0 {
+		return tail
+	}
+	return 1 - tail
+}
+
+// StudentTQuantile inverts StudentTCDF by bisection, for p in (0, 1).
+func StudentTQuantile(p, df float64) float64 {
+	if p == 0.5 {
+		return 0
+	}
+	if p < 0.5 {
+		return -StudentTQuantile(1-p, df)
+	}
+
+	low, high := 0.0, 1.0
+	for StudentTCDF(high, df) < p {
+		high *= 2
+	}
+	for i := 0; i < 100; i++ {
+		middle := (low + high) / 2
+		if StudentTCDF(middle, df) < p {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2
+}
+
+// ChiSquareSurvival is P(X > x) for a chi-square X with df degrees of freedom.
+func ChiSquareSurvival(x, df float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	return 1 - regularizedGamma(df/2, x/2)
+}
+
+// regularizedGamma is the lower regularized incomplete gamma function P(a, x), by its series below
+// a+1 and its continued fraction above, as in Numerical Recipes.
+func regularizedGamma(a, x float64) float64 {
+	lgamma, _ := math.Lgamma(a)
+	if x < a+1 {
+		term := 1 / a
+		sum := term
+		for n := 1; n < 500; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-15 {
+				break
+			}
+		}
+		return sum * math.Exp(-x+a*math.Log(x)-lgamma)
+	}
+
+	b := x + 1 - a
+	c := 1 / 1e-300
+	d := 1 / b
+	h := d
+	for n := 1; n < 500; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < 1e-300 {
+			d = 1e-300
+		}
+		c = b + an/c
+		if math.Abs(c) < 1e-300 {
+			c = 1e-300
+		}
+		d = 1 / d
+		h *= d * c
+		if math.Abs(d*c-1) < 1e-15 {
+			break
+		}
+	}
+	return 1 - math.Exp(-x+a*math.Log(x)-lgamma)*h
+}
+
+// regularizedBeta is the regularized incomplete beta function I_x(a, b), by the continued fraction
+// of Numerical Recipes.
+func regularizedBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	if x > (a+1)/(a+b+2) {
+		return 1 - front*betaFraction(1-x, b, a)/b
+	}
+	return front * betaFraction(x, a, b) / a
+}
+
+func betaFraction(x, a, b float64) float64 {
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < 1e-300 {
+		d = 1e-300
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m < 500; m++ {
+		fm := float64(m)
+		for _, numerator := range []float64{
+			fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm)),
+			-(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1)),
+		} {
+			d = 1 + numerator*d
+			if math.Abs(d) < 1e-300 {
+				d = 1e-300
+			}
+			c = 1 + numerator/c
+			if math.Abs(c) < 1e-300 {
+				c = 1e-300
+			}
+			d = 1 / d
+			h *= d * c
+		}
+		if math.Abs(d*c-1) < 1e-15 {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+// closeTo compares against reference values given to a few significant digits.
+func closeTo(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance*math.Max(1, math.Abs(want))
+}
+
+func TestDistributions(t *testing.T) {
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"NormalSurvival(0)", NormalSurvival(0), 0.5},
+		{"NormalSurvival(1.96)", NormalSurvival(1.959964), 0.025},
+		{"StudentTCDF(0,5)", StudentTCDF(0, 5), 0.5},
+		{"StudentTCDF(2.2281,10)", StudentTCDF(2.2281, 10), 0.975},
+		{"StudentTQuantile(.975,10)", StudentTQuantile(0.975, 10), 2.2281},
+		{"StudentTQuantile(.975,1)", StudentTQuantile(0.975, 1), 12.7062},
+		{"StudentTQuantile(.95,5)", StudentTQuantile(0.95, 5), 2.0150},
+		{"StudentTQuantile(.025,10)", StudentTQuantile(0.025, 10), -2.2281},
+		{"ChiSquareSurvival(20,5)", ChiSquareSurvival(20, 5), 0.00125},
+		{"ChiSquareSurvival(3.8415,1)", ChiSquareSurvival(3.841459, 1), 0.05},
+		{"ChiSquareSurvival(4.5,2)", ChiSquareSurvival(4.5, 2), 0.1054},
+		{"ChiSquareSurvival(0,3)", ChiSquareSurvival(0, 3), 1},
+	}
+	for _, test := range tests {
+		if !closeTo(test.got, test.want, 1e-4) {
+			t.Errorf("%s = %.6f, want %.6f", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	summary := Describe([]float64{5, 1, 4, 2, 3}, 0.95)
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"Mean", summary.Mean, 3},
+		{"Median", summary.Median, 3},
+		{"Std", summary.Std, 1.5811},
+		{"Min", summary.Min, 1},
+		{"Max", summary.Max, 5},
+		{"CILow", summary.CILow, 1.0368},
+		{"CIHigh", summary.CIHigh, 4.9632},
+	}
+	for _, test := range tests {
+		if !closeTo(test.got, test.want, 1e-4) {
+			t.Errorf("%s = %.6f, want %.6f", test.name, test.got, test.want)
+		}
+	}
+
+	if single := Describe([]float64{7}, 0.95); !math.IsNaN(single.Std) || !math.IsNaN(single.CILow) {
+		t.Errorf("Describe of one value has Std %f and CILow %f, want NaN", single.Std, single.CILow)
+	}
+}
+
+func TestRanks(t *testing.T) {
+	ranks, ties := Ranks([]float64{3, 1, 3, 2})
+	want := []float64{3.5, 1, 3.5, 2}
+	for i := range want {
+		if ranks[i] != want[i] {
+			t.Errorf("Ranks = %v, want %v", ranks, want)
+			break
+		}
+	}
+	if ties != 6 {
+		t.Errorf("ties = %f, want 6", ties)
+	}
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Table is a captioned grid of already formatted cells, rendered for papers as LaTeX or for notes
+// and READMEs as Markdown.
+type Table struct {
+	Caption string
+	Header  []string
+	Rows    [][]string
+}
+
+const (
+	FormatMarkdown = "markdown"
+	FormatLaTeX    = "latex"
+)
+
+var Formats = []string{FormatMarkdown, FormatLaTeX}
+
+func (table Table) Render(format string) (string, error) {
+	switch format {
+	case FormatMarkdown:
+		return table.Markdown(), nil
+	case FormatLaTeX:
+		return table.LaTeX(), nil
+	default:
+		return "", fmt.Errorf("unknown table format %q, valid formats are %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+func (table Table) Markdown() string {
+	var b strings.Builder
+	if table.Caption != "" {
+		fmt.Fprintf(&b, "**%s**\n\n", markdownEscaper.Replace(table.Caption))
+	}
+	fmt.Fprintf(&b, "| %s |\n", strings.Join(table.Header, " | "))
+	fmt.Fprintf(&b, "|%s\n", strings.Repeat(" --- |", len(table.Header)))
+	for _, row := range table.Rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = markdownEscaper.Replace(cell)
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+	}
+	return b.String()
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`)
+
+// LaTeX renders a booktabs table, with the first column left aligned and the others right aligned.
+func (table Table) LaTeX() string {
+	var b strings.Builder
+	b.WriteString("\\begin{table}[ht]\n\\centering\n")
+	if table.Caption != "" {
+		fmt.Fprintf(&b, "\\caption{%s}\n", escapeLaTeX(table.Caption))
+	}
+	fmt.Fprintf(&b, "\\begin{tabular}{l%s}\n\\toprule\n", strings.Repeat("r", max(len(table.Header)-1, 0)))
+	b.WriteString(latexRow(table.Header))
+	b.WriteString("\\midrule\n")
+	for _, row := range table.Rows {
+		b.WriteString(latexRow(row))
+	}
+	b.WriteString("\\bottomrule\n\\end{tabular}\n\\end{table}\n")
+	return b.String()
+}
+
+func latexRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = escapeLaTeX(cell)
+	}
+	return strings.Join(escaped, " & ") + " \\\\\n"
+}
+
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`, "&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`,
+	"{", `\{`, "}", `\}`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+)
+
+func escapeLaTeX(text string) string {
+	return latexEscaper.Replace(text)
+}
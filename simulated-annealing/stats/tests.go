@@ -0,0 +1,249 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+)
+
+// TestResult is the statistic of a test and its two-sided p-value. Exact tells whether the p-value
+// comes from the exact null distribution rather than the normal approximation.
+type TestResult struct {
+	Statistic float64
+	PValue    float64
+	Exact     bool
+}
+
+// exactLimit bounds the sample sizes for which the rank tests enumerate their null distribution.
+const exactLimit = 25
+
+// WilcoxonSignedRank tests whether the paired samples x and y differ in location. Zero differences
+// are dropped. The statistic is the smaller of the positive and negative rank sums; its p-value is
+// exact for up to 25 pairs without tied differences, and normal with tie and continuity
+// corrections otherwise.
+func WilcoxonSignedRank(x, y []float64) (TestResult, error) {
+	if len(x) != len(y) {
+		return TestResult{}, fmt.Errorf("paired samples have %d and %d values", len(x), len(y))
+	}
+
+	differences := make([]float64, 0, len(x))
+	for i := range x {
+		if d := x[i] - y[i]; d != 0 {
+			differences = append(differences, d)
+		}
+	}
+	n := len(differences)
+	if n == 0 {
+		return TestResult{Statistic: 0, PValue: 1, Exact: true}, nil
+	}
+
+	magnitudes := make([]float64, n)
+	for i, d := range differences {
+		magnitudes[i] = math.Abs(d)
+	}
+	ranks, ties := Ranks(magnitudes)
+
+	positive := 0.0
+	for i, d := range differences {
+		if d > 0 {
+			positive += ranks[i]
+		}
+	}
+	total := float64(n*(n+1)) / 2
+	w := math.Min(positive, total-positive)
+
+	if ties == 0 && n <= exactLimit {
+		// counts[s] is the number of subsets of the ranks 1..n summing to s
+		counts := make([]float64, int(total)+1)
+		counts[0] = 1
+		for rank := 1; rank <= n; rank++ {
+			for s := int(total); s >= rank; s-- {
+				counts[s] += counts[s-rank]
+			}
+		}
+		below := 0.0
+		for s := 0; s <= int(w); s++ {
+			below += counts[s]
+		}
+		return TestResult{Statistic: w, PValue: math.Min(1, 2*below/math.Pow(2, float64(n))), Exact: true}, nil
+	}
+
+	mean := total / 2
+	variance := float64(n*(n+1)*(2*n+1))/24 - ties/48
+	return TestResult{Statistic: w, PValue: normalTwoSided(w, mean, variance)}, nil
+}
+
+// MannWhitneyU tests whether the independent samples x and y differ in location. The statistic is
+// the smaller of the two U values; its p-value is exact for samples of up to 25 values without
+// ties, and normal with tie and continuity corrections otherwise.
+func MannWhitneyU(x, y []float64) (TestResult, error) {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return TestResult{}, fmt.Errorf("samples have %d and %d values, both must be non-empty", n1, n2)
+	}
+
+	ranks, ties := Ranks(append(append(make([]float64, 0, n1+n2), x...), y...))
+	sum := 0.0
+	for _, rank := range ranks[:n1] {
+		sum += rank
+	}
+	u1 := sum - float64(n1*(n1+1))/2
+	u := math.Min(u1, float64(n1*n2)-u1)
+
+	if ties == 0 && n1 <= exactLimit && n2 <= exactLimit {
+		// counts[i][j][s] is the number of orderings of i values of x and j of y with U = s,
+		// built up by placing the largest value last
+		counts := make([][][]float64, n1+1)
+		for i := range counts {
+			counts[i] = make([][]float64, n2+1)
+			for j := range counts[i] {
+				counts[i][j] = make([]float64, i*j+1)
+				switch {
+				case i == 0 || j == 0:
+					counts[i][j][0] = 1
+				default:
+					for s := range counts[i][j] {
+						if s-j >= 0 && s-j < len(counts[i-1][j]) {
+							counts[i][j][s] += counts[i-1][j][s-j]
+						}
+						if s < len(counts[i][j-1]) {
+							counts[i][j][s] += counts[i][j-1][s]
+						}
+					}
+				}
+			}
+		}
+
+		below, all := 0.0, 0.0
+		for s, count := range counts[n1][n2] {
+			all += count
+			if float64(s) <= u {
+				below += count
+			}
+		}
+		return TestResult{Statistic: u, PValue: math.Min(1, 2*below/all), Exact: true}, nil
+	}
+
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - ties/(n*(n-1)))
+	return TestResult{Statistic: u, PValue: normalTwoSided(u, mean, variance)}, nil
+}
+
+// normalTwoSided is the two-sided p-value of statistic under a normal approximation, with the
+// continuity correction of half a unit towards the mean.
+func normalTwoSided(statistic, mean, variance float64) float64 {
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(statistic-mean) - 0.5) / math.Sqrt(variance)
+	return math.Min(1, 2*NormalSurvival(math.Max(z, 0)))
+}
+
+// FriedmanResult holds the chi-square statistic of the Friedman test with its p-value, and the
+// average rank of each treatment, where rank 1 is the smallest value.
+type FriedmanResult struct {
+	Statistic    float64
+	PValue       float64
+	AverageRanks []float64
+}
+
+// Friedman tests whether k treatments differ over n blocks, given as data[block][treatment], with
+// the correction for ties within blocks.
+func Friedman(data [][]float64) (FriedmanResult, error) {
+	n := len(data)
+	if n < 2 {
+		return FriedmanResult{}, fmt.Errorf("need at least 2 blocks, got %d", n)
+	}
+	k := len(data[0])
+	if k < 2 {
+		return FriedmanResult{}, fmt.Errorf("need at least 2 treatments, got %d", k)
+	}
+
+	rankSums := make([]float64, k)
+	ties := 0.0
+	for b, block := range data {
+		if len(block) != k {
+			return FriedmanResult{}, fmt.Errorf("block %d has %d treatments, expected %d", b, len(block), k)
+		}
+		ranks, blockTies := Ranks(block)
+		for j, rank := range ranks {
+			rankSums[j] += rank
+		}
+		ties += blockTies
+	}
+
+	fn, fk := float64(n), float64(k)
+	sumSquares := 0.0
+	averages := make([]float64, k)
+	for j, sum := range rankSums {
+		sumSquares += sum * sum
+		averages[j] = sum / fn
+	}
+	statistic := 12/(fn*fk*(fk+1))*sumSquares - 3*fn*(fk+1)
+	if correction := 1 - ties/(fn*(fk*fk*fk-fk)); correction > 0 {
+		statistic /= correction
+	}
+
+	return FriedmanResult{
+		Statistic:    statistic,
+		PValue:       ChiSquareSurvival(statistic, fk-1),
+		AverageRanks: averages,
+	}, nil
+}
+
+// nemenyiQ holds the critical values q_alpha of the Nemenyi test for 2 to 10 treatments, the
+// studentized range statistic divided by sqrt(2), from Demšar (2006).
+var nemenyiQ = map[float64][]float64{
+	0.05: {1.960, 2.343, 2.569, 2.728, 2.850, 2.949, 3.031, 3.102, 3.164},
+	0.10: {1.645, 2.052, 2.291, 2.459, 2.589, 2.693, 2.780, 2.855, 2.920},
+}
+
+// NemenyiCD is the critical difference of the Nemenyi post-hoc test for k treatments over n blocks:
+// two treatments differ significantly at level alpha, 0.05 or 0.10, when their average Friedman
+// ranks differ by more than it.
+func NemenyiCD(k, n int, alpha float64) (float64, error) {
+	q, found := nemenyiQ[alpha]
+	if !found {
+		return 0, fmt.Errorf("no Nemenyi critical values for alpha %g, use 0.05 or 0.1", alpha)
+	}
+	if k < 2 || k-2 >= len(q) {
+		return 0, fmt.Errorf("no Nemenyi critical values for %d treatments, need 2 to %d", k, len(q)+1)
+	}
+	return q[k-2] * math.Sqrt(float64(k*(k+1))/(6*float64(n))), nil
+}
+
+// ConoverCD is the critical difference of Conover's post-hoc test after a Friedman test on
+// data[block][treatment], as used by irace: two treatments differ significantly at level alpha
+// when their rank sums differ by more than it. It also returns the rank sums.
+func ConoverCD(data [][]float64, alpha float64) ([]float64, float64, error) {
+	n := len(data)
+	if n < 2 {
+		return nil, 0, fmt.Errorf("need at least 2 blocks, got %d", n)
+	}
+	k := len(data[0])
+	if k < 2 {
+		return nil, 0, fmt.Errorf("need at least 2 treatments, got %d", k)
+	}
+
+	rankSums := make([]float64, k)
+	squares := 0.0
+	for b, block := range data {
+		if len(block) != k {
+			return nil, 0, fmt.Errorf("block %d has %d treatments, expected %d", b, len(block), k)
+		}
+		ranks, _ := Ranks(block)
+		for j, rank := range ranks {
+			rankSums[j] += rank
+			squares += rank * rank
+		}
+	}
+
+	sumSquares := 0.0
+	for _, sum := range rankSums {
+		sumSquares += sum * sum
+	}
+
+	df := float64((n - 1) * (k - 1))
+	variance := 2 * (float64(n)*squares - sumSquares) / df
+	return rankSums, StudentTQuantile(1-alpha/2, df) * math.Sqrt(math.Max(variance, 0)), nil
+}
@@ -0,0 +1,72 @@
+package stats
+
+import "testing"
+
+func TestRankTests(t *testing.T) {
+	tests := []struct {
+		name      string
+		test      func(x, y []float64) (TestResult, error)
+		x, y      []float64
+		statistic float64
+		pValue    float64
+		exact     bool
+	}{
+		{"MannWhitneyU separated", MannWhitneyU, []float64{1, 2, 3}, []float64{4, 5, 6}, 0, 0.1, true},
+		{"MannWhitneyU interleaved", MannWhitneyU, []float64{1, 3, 5}, []float64{2, 4, 6}, 3, 0.7, true},
+		{"MannWhitneyU tied", MannWhitneyU, []float64{1, 2, 2}, []float64{2, 3, 4}, 1, 0.1642, false},
+		{"Wilcoxon all positive", WilcoxonSignedRank, []float64{2, 3, 4, 5, 6}, []float64{1, 1, 1, 1, 1}, 0, 0.0625, true},
+		{"Wilcoxon mixed", WilcoxonSignedRank, []float64{2, 3, 4, 5, 0}, []float64{1, 1, 1, 1, 5}, 5, 0.625, true},
+		{"Wilcoxon no differences", WilcoxonSignedRank, []float64{1, 2}, []float64{1, 2}, 0, 1, true},
+	}
+	for _, test := range tests {
+		result, err := test.test(test.x, test.y)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !closeTo(result.Statistic, test.statistic, 1e-9) || !closeTo(result.PValue, test.pValue, 1e-4) || result.Exact != test.exact {
+			t.Errorf("%s = %+v, want statistic %g, p-value %g, exact %t", test.name, result, test.statistic, test.pValue, test.exact)
+		}
+	}
+
+	if _, err := WilcoxonSignedRank([]float64{1, 2}, []float64{1}); err == nil {
+		t.Error("WilcoxonSignedRank accepted samples of different sizes")
+	}
+	if _, err := MannWhitneyU(nil, []float64{1}); err == nil {
+		t.Error("MannWhitneyU accepted an empty sample")
+	}
+}
+
+func TestFriedman(t *testing.T) {
+	data := [][]float64{{1, 2, 3}, {1, 3, 2}, {1, 2, 3}, {2, 1, 3}}
+	result, err := Friedman(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !closeTo(result.Statistic, 4.5, 1e-9) || !closeTo(result.PValue, 0.1054, 1e-4) {
+		t.Errorf("Friedman = %+v, want statistic 4.5 and p-value 0.1054", result)
+	}
+	for j, want := range []float64{1.25, 2, 2.75} {
+		if !closeTo(result.AverageRanks[j], want, 1e-9) {
+			t.Errorf("average ranks = %v, want [1.25 2 2.75]", result.AverageRanks)
+			break
+		}
+	}
+
+	if _, err := Friedman([][]float64{{1, 2}, {1}}); err == nil {
+		t.Error("Friedman accepted blocks of different sizes")
+	}
+}
+
+func TestNemenyiCD(t *testing.T) {
+	cd, err := NemenyiCD(3, 4, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !closeTo(cd, 1.6568, 1e-4) {
+		t.Errorf("NemenyiCD(3, 4, 0.05) = %f, want 1.6568", cd)
+	}
+	if _, err := NemenyiCD(3, 4, 0.01); err == nil {
+		t.Error("NemenyiCD accepted alpha 0.01")
+	}
+}
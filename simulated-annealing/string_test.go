@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/device"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/gateway"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/problem"
 	"github.com/TheDramaturgy/uav-positioning-metaheuristics/simulated-annealing/solver"
+	"math/rand"
 	"os"
 	"strings"
 	"testing"
@@ -109,10 +111,16 @@ func BenchmarkGASolve(b *testing.B) {
 	gatewayPositionFile := cwd + "/data/equidistantPlacement_" + numGateways + ".dat"
 
 	// ---------- Load Data
-	deviceList := device.ReadDeviceList(devicePositionFile, sliceAssociationFile)
+	deviceList, err := device.ReadDeviceList(devicePositionFile, sliceAssociationFile)
+	if err != nil {
+		panic(err)
+	}
 	fmt.Printf("Successfully loaded %d devices\n", deviceList.Count())
 
-	candidatePosList := gateway.ReadCandidatePositionList(gatewayPositionFile)
+	candidatePosList, err := gateway.ReadCandidatePositionList(gatewayPositionFile)
+	if err != nil {
+		panic(err)
+	}
 	fmt.Printf("Successfully loaded %d candidate positions\n", candidatePosList.Count())
 
 	gw := &gateway.Gateway{}
@@ -128,8 +136,8 @@ func BenchmarkGASolve(b *testing.B) {
 	}
 
 	for i := 0; i < b.N; i++ {
-		s := solver.CreateGASolver(instance, 500, 100, 0.6, 0.0001)
-		s.Solve()
+		s := solver.CreateGASolver(instance, 500, 100, 100, 0.6, 0.0001)
+		s.Solve(context.Background())
 	}
 }
 
@@ -149,10 +157,16 @@ func BenchmarkNewGASolve(b *testing.B) {
 	gatewayPositionFile := cwd + "/data/equidistantPlacement_" + numGateways + ".dat"
 
 	// ---------- Load Data
-	deviceList := device.ReadDeviceList(devicePositionFile, sliceAssociationFile)
+	deviceList, err := device.ReadDeviceList(devicePositionFile, sliceAssociationFile)
+	if err != nil {
+		panic(err)
+	}
 	fmt.Printf("Successfully loaded %d devices\n", deviceList.Count())
 
-	candidatePosList := gateway.ReadCandidatePositionList(gatewayPositionFile)
+	candidatePosList, err := gateway.ReadCandidatePositionList(gatewayPositionFile)
+	if err != nil {
+		panic(err)
+	}
 	fmt.Printf("Successfully loaded %d candidate positions\n", candidatePosList.Count())
 
 	gw := &gateway.Gateway{}
@@ -168,7 +182,164 @@ func BenchmarkNewGASolve(b *testing.B) {
 	}
 
 	for i := 0; i < b.N; i++ {
-		s := solver.CreateGASolver(instance, 500, 100, 0.6, 0.0001)
-		s.Solve()
+		s := solver.CreateGASolver(instance, 500, 100, 100, 0.6, 0.0001)
+		s.Solve(context.Background())
+	}
+}
+
+// loadBenchmarkInstance loads the instance of the given seed with 50 devices and 64 candidate
+// positions, with a fixed random seed.
+func loadBenchmarkInstance(b *testing.B, seed string) *problem.UAVProblem {
+	cwd, err := os.Getwd()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	deviceList, err := device.ReadDeviceList(cwd+"/data/endDevices_LNM_Placement_"+seed+"s+50d.dat", cwd+"/data/skl_"+seed+"s_64x1Gv_50D.dat")
+	if err != nil {
+		b.Fatal(err)
+	}
+	candidatePosList, err := gateway.ReadCandidatePositionList(cwd + "/data/equidistantPlacement_64.dat")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	gw := &gateway.Gateway{}
+	gw.SetSensitivity(map[int16]float32{7: -130.0, 8: -132.5, 9: -135.0, 10: -137.5, 11: -140.0, 12: -142.5})
+	for _, slice := range deviceList.Slices() {
+		gw.AddSlice(slice, 125000.0, 15197.75390625)
+	}
+
+	instance, err := problem.CreateUAVProblemInstance(100.0, 1.0, 0.5, 0.05, deviceList, candidatePosList, gw)
+	if err != nil {
+		b.Fatal(err)
+	}
+	instance.SetRand(rand.New(rand.NewSource(1)))
+	return instance
+}
+
+func benchmarkSolution(b *testing.B) *problem.UAVSolution {
+	sol, err := problem.GetRandomUAVSolution(loadBenchmarkInstance(b, "3"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	return sol
+}
+
+func BenchmarkSolutionCopy(b *testing.B) {
+	sol := benchmarkSolution(b)
+	for i := 0; i < b.N; i++ {
+		_ = sol.Copy()
+	}
+}
+
+func BenchmarkSolutionNeighbour(b *testing.B) {
+	sol := benchmarkSolution(b)
+	for i := 0; i < b.N; i++ {
+		_ = sol.GetNeighbourSmarter()
+	}
+}
+
+func BenchmarkSolutionApplyUndo(b *testing.B) {
+	sol := benchmarkSolution(b)
+	for i := 0; i < b.N; i++ {
+		if move, found := sol.DrawMove(); found {
+			sol.Apply(move)
+			_ = sol.GetCost()
+			sol.Undo()
+		}
+	}
+}
+
+func BenchmarkSolutionAssociations(b *testing.B) {
+	sol := benchmarkSolution(b)
+	for i := 0; i < b.N; i++ {
+		_ = sol.GetAssociations()
+	}
+}
+
+func BenchmarkSASolve(b *testing.B) {
+	instance := loadBenchmarkInstance(b, "3")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sol, err := problem.GetRandomUAVSolution(instance)
+		if err != nil {
+			b.Fatal(err)
+		}
+		instance.SetCurrentSolution(sol)
+		s := solver.CreateSASolver(5, 0.99, 100, 20000, 1, 2, instance)
+		s.Solve(context.Background())
+	}
+}
+
+func BenchmarkSolutionHash(b *testing.B) {
+	sol := benchmarkSolution(b)
+	for i := 0; i < b.N; i++ {
+		_ = sol.Hash()
+	}
+}
+
+// loadSyntheticInstance spreads numDevices devices uniformly over a square of the given side in m,
+// in two slices, and numPositions candidate positions on a grid over it.
+func loadSyntheticInstance(b *testing.B, numDevices, numPositions int, side float64) (*device.DeviceList, *gateway.CandidatePositionList, *gateway.Gateway) {
+	dir := b.TempDir()
+	rng := rand.New(rand.NewSource(1))
+
+	devices, slices := &strings.Builder{}, &strings.Builder{}
+	for i := 0; i < numDevices; i++ {
+		fmt.Fprintf(devices, "%f %f %f\n", rng.Float64()*side, rng.Float64()*side, 1+rng.Float64())
+		fmt.Fprintf(slices, "%d %d\n", i, i%2)
+	}
+	cols := 1
+	for cols*cols < numPositions {
+		cols++
+	}
+	positions := &strings.Builder{}
+	for i := 0; i < numPositions; i++ {
+		fmt.Fprintf(positions, "%f %f 45\n", (float64(i%cols)+0.5)*side/float64(cols), (float64(i/cols)+0.5)*side/float64(cols))
+	}
+	for name, content := range map[string]string{"devices.dat": devices.String(), "slices.dat": slices.String(), "positions.dat": positions.String()} {
+		if err := os.WriteFile(dir+"/"+name, []byte(content), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	deviceList, err := device.ReadDeviceList(dir+"/devices.dat", dir+"/slices.dat")
+	if err != nil {
+		b.Fatal(err)
+	}
+	candidatePosList, err := gateway.ReadCandidatePositionList(dir + "/positions.dat")
+	if err != nil {
+		b.Fatal(err)
+	}
+	gw := &gateway.Gateway{}
+	gw.SetSensitivity(map[int16]float32{7: -130.0, 8: -132.5, 9: -135.0, 10: -137.5, 11: -140.0, 12: -142.5})
+	for _, slice := range deviceList.Slices() {
+		gw.AddSlice(slice, 125000.0, 15197.75390625)
+	}
+	return deviceList, candidatePosList, gw
+}
+
+func benchmarkProblemCreation(b *testing.B, numDevices, numPositions int, side float64) {
+	deviceList, candidatePosList, gw := loadSyntheticInstance(b, numDevices, numPositions, side)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := problem.CreateUAVProblemInstance(100.0, 1.0, 0.5, 0.05, deviceList, candidatePosList, gw); err != nil {
+			b.Fatal(err)
+		}
 	}
 }
+
+func BenchmarkProblemCreation(b *testing.B) {
+	benchmarkProblemCreation(b, 10000, 1000, 10000)
+}
+
+// BenchmarkProblemCreationCity covers a 60 km square, where each device reaches a small share of
+// the candidate positions.
+func BenchmarkProblemCreationCity(b *testing.B) {
+	benchmarkProblemCreation(b, 10000, 10000, 60000)
+}
@@ -0,0 +1,109 @@
+package utils
+
+import "math"
+
+// Grid is a spatial index over a fixed set of positions. It buckets them into square cells by their
+// X and Y coordinates, so that a query around a position only scans the cells it overlaps.
+type Grid struct {
+	positions  []Position
+	cellSize   float32
+	minX, minY float32
+	cols, rows int32
+	cellStart  []int32 // the positions of cell c are points[cellStart[c]:cellStart[c+1]]
+	points     []int32
+}
+
+// CreateGrid indexes positions, identified by their index, into cells of the given size. The size
+// is raised as needed to keep the number of cells under the number of positions.
+func CreateGrid(positions []Position, cellSize float32) *Grid {
+	grid := &Grid{positions: positions, cellSize: max(cellSize, math.SmallestNonzeroFloat32)}
+	if len(positions) == 0 {
+		grid.cellStart = make([]int32, 1)
+		return grid
+	}
+
+	minX, minY := positions[0].X, positions[0].Y
+	maxX, maxY := minX, minY
+	for _, pos := range positions {
+		minX, minY = min(minX, pos.X), min(minY, pos.Y)
+		maxX, maxY = max(maxX, pos.X), max(maxY, pos.Y)
+	}
+	grid.minX, grid.minY = minX, minY
+
+	for {
+		cols := math.Floor(float64((maxX-minX)/grid.cellSize)) + 1
+		rows := math.Floor(float64((maxY-minY)/grid.cellSize)) + 1
+		if cols*rows <= float64(len(positions)) {
+			grid.cols, grid.rows = int32(cols), int32(rows)
+			break
+		}
+		grid.cellSize *= 2
+	}
+
+	// Counting sort of the positions by cell
+	grid.cellStart = make([]int32, grid.cols*grid.rows+1)
+	cells := make([]int32, len(positions))
+	for i, pos := range positions {
+		cells[i] = grid.cell(pos)
+		grid.cellStart[cells[i]+1]++
+	}
+	for cell := 1; cell < len(grid.cellStart); cell++ {
+		grid.cellStart[cell] += grid.cellStart[cell-1]
+	}
+	next := make([]int32, len(grid.cellStart)-1)
+	copy(next, grid.cellStart)
+	grid.points = make([]int32, len(positions))
+	for i, cell := range cells {
+		grid.points[next[cell]] = int32(i)
+		next[cell]++
+	}
+
+	return grid
+}
+
+func (grid *Grid) cell(pos Position) int32 {
+	return grid.row(pos.Y)*grid.cols + grid.column(pos.X)
+}
+
+func (grid *Grid) column(x float32) int32 {
+	return clampCell((x-grid.minX)/grid.cellSize, grid.cols)
+}
+
+func (grid *Grid) row(y float32) int32 {
+	return clampCell((y-grid.minY)/grid.cellSize, grid.rows)
+}
+
+// clampCell converts a coordinate in cells to a cell index below count, before the conversion so
+// that infinite coordinates are clamped too.
+func clampCell(coordinate float32, count int32) int32 {
+	if !(coordinate > 0) {
+		return 0
+	}
+	if coordinate >= float32(count-1) {
+		return count - 1
+	}
+	return int32(coordinate)
+}
+
+// Within appends to found the index of every position at most radius away from pos, and returns
+// it. The indices come out grouped by cell, not sorted.
+func (grid *Grid) Within(pos Position, radius float32, found []int32) []int32 {
+	if len(grid.positions) == 0 || radius < 0 {
+		return found
+	}
+
+	firstCol, lastCol := grid.column(pos.X-radius), grid.column(pos.X+radius)
+	firstRow, lastRow := grid.row(pos.Y-radius), grid.row(pos.Y+radius)
+	squaredRadius := float64(radius) * float64(radius)
+	for row := firstRow; row <= lastRow; row++ {
+		first, last := grid.cellStart[row*grid.cols+firstCol], grid.cellStart[row*grid.cols+lastCol+1]
+		for _, point := range grid.points[first:last] {
+			target := grid.positions[point]
+			dx, dy, dz := float64(pos.X)-float64(target.X), float64(pos.Y)-float64(target.Y), float64(pos.Z)-float64(target.Z)
+			if dx*dx+dy*dy+dz*dz <= squaredRadius {
+				found = append(found, point)
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// within is the brute force version of Grid.Within.
+func within(positions []Position, pos Position, radius float32) []int32 {
+	found := make([]int32, 0)
+	if radius < 0 {
+		return found
+	}
+	squaredRadius := float64(radius) * float64(radius)
+	for i, target := range positions {
+		dx, dy, dz := float64(pos.X)-float64(target.X), float64(pos.Y)-float64(target.Y), float64(pos.Z)-float64(target.Z)
+		if dx*dx+dy*dy+dz*dz <= squaredRadius {
+			found = append(found, int32(i))
+		}
+	}
+	return found
+}
+
+func TestGridWithin(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	scattered := make([]Position, 500)
+	for i := range scattered {
+		scattered[i] = Position{rng.Float32() * 1000, rng.Float32() * 1000, rng.Float32() * 100}
+	}
+
+	// points on the cell boundaries, the corners of the box and its edges
+	lattice := make([]Position, 0)
+	for x := 0; x <= 400; x += 100 {
+		for y := 0; y <= 400; y += 100 {
+			lattice = append(lattice, Position{float32(x), float32(y), 45})
+		}
+	}
+
+	tests := []struct {
+		name      string
+		positions []Position
+		cellSize  float32
+		queries   []Position
+		radii     []float32
+	}{
+		{"empty", nil, 10, []Position{{0, 0, 0}}, []float32{0, 100}},
+		{"single", []Position{{5, 5, 5}}, 10, []Position{{5, 5, 5}, {5, 5, 15}, {100, 100, 0}}, []float32{0, 10, 1000}},
+		{"duplicates", []Position{{1, 1, 0}, {1, 1, 0}, {1, 1, 0}}, 1, []Position{{1, 1, 0}, {2, 1, 0}}, []float32{0, 1, 0.5}},
+		{"lattice", lattice, 100, append(slices.Clone(lattice), Position{-50, -50, 45}, Position{450, 450, 45}, Position{200, -100, 45}),
+			[]float32{0, 50, 100, 141.43, 200, 1000, -1}},
+		{"line", []Position{{0, 0, 0}, {0, 10, 0}, {0, 20, 0}, {0, 30, 0}}, 10, []Position{{0, 15, 0}, {0, 40, 0}}, []float32{5, 10, 15}},
+		{"scattered", scattered, 50, scattered[:50], []float32{0, 10, 75, 250}},
+		{"scattered small cells", scattered, 0.001, scattered[:20], []float32{30, 120}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			grid := CreateGrid(test.positions, test.cellSize)
+			for _, query := range test.queries {
+				for _, radius := range test.radii {
+					got := grid.Within(query, radius, nil)
+					slices.Sort(got)
+					if want := within(test.positions, query, radius); !slices.Equal(got, want) {
+						t.Errorf("Within(%v, %g) = %v, want %v", query, radius, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGridWithinAppends(t *testing.T) {
+	grid := CreateGrid([]Position{{0, 0, 0}, {1, 0, 0}}, 1)
+	found := grid.Within(Position{0, 0, 0}, 1, []int32{7})
+	slices.Sort(found[1:])
+	if want := []int32{7, 0, 1}; !slices.Equal(found, want) {
+		t.Errorf("Within appended %v, want %v", found, want)
+	}
+}
@@ -2,6 +2,6 @@ package utils
 
 import "math/rand"
 
-func GetRandomProbability() float64 {
-	return rand.Float64()
+func GetRandomProbability(rng *rand.Rand) float64 {
+	return rng.Float64()
 }